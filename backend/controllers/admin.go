@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"time"
 
+	"bakeflow/i18n"
 	"bakeflow/models"
 
 	"github.com/gorilla/mux"
@@ -154,6 +155,11 @@ func AdminUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("✅ Order #%d status updated to: %s", orderID, requestBody.Status)
 
+	// Ingredients are used once baking starts
+	if requestBody.Status == "preparing" {
+		deductOrderIngredients(orderID)
+	}
+
 	// Respond immediately before potentially slow external notification
 	resp := map[string]interface{}{
 		"success":   true,
@@ -167,32 +173,142 @@ func AdminUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Async notification (non-blocking)
 	if currentOrder.SenderID != "" {
-		go func(orderID int, senderID, status string) {
+		go func(order *models.Order, status string) {
 			defer func() {
 				if r := recover(); r != nil {
-					log.Printf("⚠️ Panic recovered in notification goroutine for order #%d: %v", orderID, r)
+					log.Printf("⚠️ Panic recovered in notification goroutine for order #%d: %v", order.ID, r)
 				}
 			}()
-			statusMessages := map[string]string{
-				"pending":   "✅ Your order #%d has been received! We'll start preparing it soon.",
-				"preparing": "🍰 Great news! We've started preparing your order #%d. It will be ready soon!",
-				"ready":     "✅ Your order #%d is ready! Please come pick it up or wait for delivery.",
-				"delivered": "🎉 Your order #%d has been delivered! Enjoy your delicious treats!",
-			}
-			if msgTemplate, ok := statusMessages[status]; ok {
-				text := fmt.Sprintf(msgTemplate, orderID)
-				if err := SendMessage(senderID, text); err != nil {
-					log.Printf("⚠️ Failed to send async notification for order #%d: %v", orderID, err)
+			lang := orderLanguage(order)
+			if text, ok := statusNotificationText(status, lang, order.ID); ok {
+				if err := SendMessage(order.SenderID, text); err != nil {
+					log.Printf("⚠️ Failed to send async notification for order #%d: %v", order.ID, err)
 				} else {
-					log.Printf("📬 Async status notification queued for order #%d", orderID)
+					log.Printf("📬 Async status notification (%s) queued for order #%d", lang, order.ID)
 				}
 			} else {
-				log.Printf("ℹ️ Status '%s' not configured for notifications (order #%d)", status, orderID)
+				log.Printf("ℹ️ Status '%s' not configured for notifications (order #%d)", status, order.ID)
 			}
 			// Optional: small delay to avoid hammering external service bursts (tunable)
 			time.Sleep(10 * time.Millisecond)
-		}(orderID, currentOrder.SenderID, requestBody.Status)
+		}(currentOrder, requestBody.Status)
 	} else {
 		log.Printf("ℹ️ No SenderID for order #%d; skipping async notification", orderID)
 	}
 }
+
+// notificationStatuses are the order statuses customers are notified about
+var notificationStatuses = []string{"pending", "preparing", "ready", "delivered"}
+
+// statusNotificationText renders the status notification in lang, preferring an admin-edited
+// template over the message catalog. Returns false if the status has no notification.
+func statusNotificationText(status, lang string, orderID int) (string, bool) {
+	key := "notify.status." + status
+	if !i18n.Has(key) {
+		return "", false
+	}
+	args := i18n.Args{"id": orderID, "status": i18n.T(lang, "status."+status)}
+
+	tmpl, err := models.GetNotificationTemplate(status, lang)
+	if err != nil {
+		log.Printf("⚠️ Could not load notification template %s/%s: %v", status, lang, err)
+	}
+	if tmpl != nil {
+		return i18n.Format(tmpl.Template, args), true
+	}
+	return i18n.T(lang, key, args), true
+}
+
+// notificationTemplateView is one status/language pair with its effective text
+type notificationTemplateView struct {
+	Status     string `json:"status"`
+	Language   string `json:"language"`
+	Template   string `json:"template"`
+	Default    string `json:"default"`
+	Customized bool   `json:"customized"`
+}
+
+// AdminGetNotificationTemplates lists the status notification text for every status and language
+func AdminGetNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	overrides, err := models.GetNotificationTemplates()
+	if err != nil {
+		log.Printf("❌ Error fetching notification templates: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "Error fetching notification templates", "details": err.Error()})
+		return
+	}
+	custom := map[string]string{}
+	for _, t := range overrides {
+		custom[t.Status+"/"+t.Language] = t.Template
+	}
+
+	templates := []notificationTemplateView{}
+	for _, status := range notificationStatuses {
+		for _, lang := range i18n.Languages() {
+			// Show the raw catalog text so placeholders stay visible to the editor
+			def := i18n.T(lang, "notify.status."+status)
+			view := notificationTemplateView{Status: status, Language: lang, Template: def, Default: def}
+			if text, ok := custom[status+"/"+lang]; ok {
+				view.Template = text
+				view.Customized = true
+			}
+			templates = append(templates, view)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"templates":    templates,
+		"placeholders": []string{"{id}", "{status}"},
+	})
+}
+
+// AdminUpdateNotificationTemplate saves an admin override for one status and language
+func AdminUpdateNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var requestBody struct {
+		Template string `json:"template"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tmpl := models.NotificationTemplate{Status: vars["status"], Language: vars["lang"], Template: requestBody.Template}
+	if err := tmpl.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := models.SaveNotificationTemplate(&tmpl); err != nil {
+		log.Printf("❌ Error saving notification template: %v", err)
+		http.Error(w, "Error saving notification template", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("✅ Notification template %s/%s updated", tmpl.Status, tmpl.Language)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tmpl)
+}
+
+// AdminResetNotificationTemplate removes an override so the built-in text is used again
+func AdminResetNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	deleted, err := models.DeleteNotificationTemplate(vars["status"], vars["lang"])
+	if err != nil {
+		log.Printf("❌ Error deleting notification template: %v", err)
+		http.Error(w, "Error resetting notification template", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Template is not customized", http.StatusNotFound)
+		return
+	}
+	log.Printf("✅ Notification template %s/%s reset to default", vars["status"], vars["lang"])
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "status": vars["status"], "language": vars["lang"]})
+}
@@ -0,0 +1,496 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+
+	"github.com/gorilla/mux"
+)
+
+// shopScheduleTTL is how long the schedule is reused before being reloaded from the DB.
+// Admin changes invalidate it immediately.
+const shopScheduleTTL = time.Minute
+
+// nextOpeningSearchDays limits how far ahead we look for the next opening
+const nextOpeningSearchDays = 31
+
+// defaultShopTimezone is used when no timezone is configured (SHOP_TIMEZONE overrides it)
+const defaultShopTimezone = "Asia/Yangon"
+
+// shopSchedule is the opening hours, closures and overrides evaluated in the shop timezone
+type shopSchedule struct {
+	loc          *time.Location
+	weekly       map[time.Weekday]models.BusinessHours
+	closures     []models.BusinessClosure
+	closedUntil  time.Time
+	closedReason string
+	builtAt      time.Time
+}
+
+var (
+	cachedSchedule *shopSchedule
+	scheduleMutex  sync.Mutex
+)
+
+// getShopSchedule returns the cached schedule, reloading it if stale.
+// Returns nil if it cannot be loaded (callers then treat the shop as open).
+func getShopSchedule() *shopSchedule {
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+
+	if cachedSchedule != nil && time.Since(cachedSchedule.builtAt) < shopScheduleTTL {
+		return cachedSchedule
+	}
+
+	s, err := loadShopSchedule()
+	if err != nil {
+		log.Printf("⚠️ Failed to load business hours: %v", err)
+		return cachedSchedule
+	}
+	cachedSchedule = s
+	return cachedSchedule
+}
+
+// invalidateShopSchedule forces a reload on next use (call after admin changes)
+func invalidateShopSchedule() {
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+	cachedSchedule = nil
+}
+
+// shopLocation returns the configured shop timezone
+func shopLocation() *time.Location {
+	if s := getShopSchedule(); s != nil {
+		return s.loc
+	}
+	return loadLocation("")
+}
+
+// loadLocation resolves a timezone name, falling back to SHOP_TIMEZONE and then the default
+func loadLocation(name string) *time.Location {
+	for _, candidate := range []string{name, os.Getenv("SHOP_TIMEZONE"), defaultShopTimezone} {
+		if candidate == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(candidate); err == nil {
+			return loc
+		}
+		log.Printf("⚠️ Unknown timezone %q", candidate)
+	}
+	return time.Local
+}
+
+func loadShopSchedule() (*shopSchedule, error) {
+	if configs.DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	settings, err := models.GetShopSettings()
+	if err != nil {
+		return nil, err
+	}
+	s := &shopSchedule{
+		loc:          loadLocation(settings[models.SettingTimezone]),
+		weekly:       map[time.Weekday]models.BusinessHours{},
+		closedReason: settings[models.SettingClosedReason],
+		builtAt:      time.Now(),
+	}
+	if v := settings[models.SettingClosedUntil]; v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			s.closedUntil = t
+		}
+	}
+
+	hours, err := models.GetBusinessHours()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hours {
+		s.weekly[time.Weekday(h.Weekday)] = h
+	}
+
+	s.closures, err = models.GetBusinessClosures(time.Now().In(s.loc).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// closureOn returns the closure covering the given day, if any
+func (s *shopSchedule) closureOn(day time.Time) *models.BusinessClosure {
+	date := day.In(s.loc).Format("2006-01-02")
+	for i, c := range s.closures {
+		if c.StartDate <= date && date <= c.EndDate {
+			return &s.closures[i]
+		}
+	}
+	return nil
+}
+
+// hoursOn returns the opening and closing time for the given day, or false if closed all day.
+// With no weekly hours configured the shop is open around the clock.
+func (s *shopSchedule) hoursOn(day time.Time) (time.Time, time.Time, bool) {
+	day = day.In(s.loc)
+	if s.closureOn(day) != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.loc)
+	if len(s.weekly) == 0 {
+		return midnight, midnight.AddDate(0, 0, 1), true
+	}
+
+	h, ok := s.weekly[day.Weekday()]
+	if !ok || h.IsClosed {
+		return time.Time{}, time.Time{}, false
+	}
+	return atClock(midnight, h.OpenTime), atClock(midnight, h.CloseTime), true
+}
+
+// atClock returns midnight moved to an HH:MM wall-clock time on the same day
+func atClock(midnight time.Time, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return midnight
+	}
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), t.Hour(), t.Minute(), 0, 0, midnight.Location())
+}
+
+// isOpenAt reports whether orders are accepted at t, with the closure reason when closed
+func (s *shopSchedule) isOpenAt(t time.Time) (bool, string) {
+	t = t.In(s.loc)
+	if t.Before(s.closedUntil) {
+		return false, s.closedReason
+	}
+	if c := s.closureOn(t); c != nil {
+		return false, c.Reason
+	}
+	open, closeAt, ok := s.hoursOn(t)
+	return ok && !t.Before(open) && t.Before(closeAt), ""
+}
+
+// nextOpening returns when the shop next opens after t (zero if not within nextOpeningSearchDays)
+func (s *shopSchedule) nextOpening(t time.Time) time.Time {
+	t = t.In(s.loc)
+	for i := 0; i <= nextOpeningSearchDays; i++ {
+		open, closeAt, ok := s.hoursOn(t.AddDate(0, 0, i))
+		if !ok {
+			continue
+		}
+		start := open
+		if t.After(start) {
+			start = t
+		}
+		if s.closedUntil.After(start) {
+			start = s.closedUntil.In(s.loc)
+		}
+		if start.Before(closeAt) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// getNextOpeningTime returns a formatted string of when the business opens next
+func getNextOpeningTime(state *UserState) string {
+	s := getShopSchedule()
+	if s == nil {
+		return tr(state, "closed.next_unknown")
+	}
+	now := time.Now().In(s.loc)
+	next := s.nextOpening(now)
+	if next.IsZero() {
+		return tr(state, "closed.next_unknown")
+	}
+
+	clock := next.Format(tr(state, "format.time"))
+	if next.Year() == now.Year() && next.YearDay() == now.YearDay() {
+		return tr(state, "closed.next_today", i18n.Args{"time": clock})
+	}
+	day := tr(state, "weekday."+strconv.Itoa(int(next.Weekday()))) + ", " + next.Format(tr(state, "format.date"))
+	return tr(state, "closed.next_day", i18n.Args{"time": clock, "day": day})
+}
+
+// formatBusinessHours summarises the weekly hours, grouping consecutive days with the same hours
+// (e.g. "Monday - Friday: 8:00 AM - 8:00 PM"). Uniform hours are shown without days.
+func formatBusinessHours(state *UserState) string {
+	s := getShopSchedule()
+	if s == nil || len(s.weekly) == 0 {
+		return tr(state, "hours.always_open")
+	}
+
+	layout := tr(state, "format.time")
+	describe := func(d time.Weekday) string {
+		h, ok := s.weekly[d]
+		if !ok || h.IsClosed {
+			return tr(state, "hours.closed")
+		}
+		midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		return atClock(midnight, h.OpenTime).Format(layout) + " - " + atClock(midnight, h.CloseTime).Format(layout)
+	}
+
+	// Week starts on Monday for display
+	order := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+	var lines []string
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && describe(order[j+1]) == describe(order[i]) {
+			j++
+		}
+		if i == 0 && j == len(order)-1 {
+			return describe(order[i])
+		}
+		days := tr(state, "weekday."+strconv.Itoa(int(order[i])))
+		if j > i {
+			days += " - " + tr(state, "weekday."+strconv.Itoa(int(order[j])))
+		}
+		lines = append(lines, days+": "+describe(order[i]))
+		i = j + 1
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
+// checkBusinessHours checks if ordering is allowed (business hours check)
+func checkBusinessHours(userID string) bool {
+	s := getShopSchedule()
+	if s == nil {
+		return true
+	}
+	open, reason := s.isOpenAt(time.Now())
+	if open {
+		return true
+	}
+
+	state := GetUserState(userID)
+	reasonText := ""
+	if reason != "" {
+		reasonText = tr(state, "closed.reason", i18n.Args{"reason": reason})
+	}
+	closedMsg := tr(state, "closed.text", i18n.Args{
+		"reason":    reasonText,
+		"hours":     formatBusinessHours(state),
+		"next_open": getNextOpeningTime(state),
+	})
+
+	SendMessage(userID, closedMsg)
+	return false
+}
+
+// businessHoursResponse is the admin view of the shop schedule
+type businessHoursResponse struct {
+	Timezone     string                   `json:"timezone"`
+	Weekly       []models.BusinessHours   `json:"weekly"`
+	Closures     []models.BusinessClosure `json:"closures"`
+	ClosedUntil  *time.Time               `json:"closed_until,omitempty"`
+	ClosedReason string                   `json:"closed_reason,omitempty"`
+	OpenNow      bool                     `json:"open_now"`
+	NextOpening  *time.Time               `json:"next_opening,omitempty"`
+}
+
+// AdminGetBusinessHours returns weekly hours, upcoming closures and the current open state
+func AdminGetBusinessHours(w http.ResponseWriter, r *http.Request) {
+	invalidateShopSchedule()
+	s := getShopSchedule()
+	if s == nil {
+		http.Error(w, "Error loading business hours", http.StatusInternalServerError)
+		return
+	}
+
+	resp := businessHoursResponse{
+		Timezone:     s.loc.String(),
+		Weekly:       []models.BusinessHours{},
+		Closures:     s.closures,
+		ClosedReason: s.closedReason,
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if h, ok := s.weekly[d]; ok {
+			resp.Weekly = append(resp.Weekly, h)
+		}
+	}
+	now := time.Now()
+	if now.Before(s.closedUntil) {
+		until := s.closedUntil.In(s.loc)
+		resp.ClosedUntil = &until
+	}
+	resp.OpenNow, _ = s.isOpenAt(now)
+	if !resp.OpenNow {
+		if next := s.nextOpening(now); !next.IsZero() {
+			resp.NextOpening = &next
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AdminUpdateBusinessHours updates the shop timezone and/or weekly opening hours
+func AdminUpdateBusinessHours(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		Timezone string                 `json:"timezone"`
+		Weekly   []models.BusinessHours `json:"weekly"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestBody.Timezone != "" {
+		if _, err := time.LoadLocation(requestBody.Timezone); err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+	}
+	seen := map[int]bool{}
+	for i := range requestBody.Weekly {
+		h := &requestBody.Weekly[i]
+		if err := h.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if seen[h.Weekday] {
+			http.Error(w, "Duplicate weekday", http.StatusBadRequest)
+			return
+		}
+		seen[h.Weekday] = true
+	}
+
+	if requestBody.Timezone != "" {
+		if err := models.SetShopSetting(models.SettingTimezone, requestBody.Timezone); err != nil {
+			log.Printf("❌ Error saving shop timezone: %v", err)
+			http.Error(w, "Error saving timezone", http.StatusInternalServerError)
+			return
+		}
+	}
+	if len(requestBody.Weekly) > 0 {
+		if err := models.SaveBusinessHours(requestBody.Weekly); err != nil {
+			log.Printf("❌ Error saving business hours: %v", err)
+			http.Error(w, "Error saving business hours", http.StatusInternalServerError)
+			return
+		}
+	}
+	log.Printf("✅ Business hours updated")
+
+	AdminGetBusinessHours(w, r)
+}
+
+// AdminCreateBusinessClosure adds a holiday or closure date range
+func AdminCreateBusinessClosure(w http.ResponseWriter, r *http.Request) {
+	var closure models.BusinessClosure
+	if err := json.NewDecoder(r.Body).Decode(&closure); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := closure.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := models.CreateBusinessClosure(&closure); err != nil {
+		log.Printf("❌ Error creating closure: %v", err)
+		http.Error(w, "Error creating closure", http.StatusInternalServerError)
+		return
+	}
+	invalidateShopSchedule()
+	log.Printf("✅ Closure #%d added (%s to %s)", closure.ID, closure.StartDate, closure.EndDate)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(closure)
+}
+
+// AdminDeleteBusinessClosure removes a closure
+func AdminDeleteBusinessClosure(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid closure ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := models.DeleteBusinessClosure(id)
+	if err != nil {
+		log.Printf("❌ Error deleting closure: %v", err)
+		http.Error(w, "Error deleting closure", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Closure not found", http.StatusNotFound)
+		return
+	}
+	invalidateShopSchedule()
+	log.Printf("✅ Closure #%d deleted", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "id": id})
+}
+
+// AdminCloseShop temporarily stops orders, by default until the end of today (shop time)
+func AdminCloseShop(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		Until  string `json:"until"` // optional RFC3339 time
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now().In(shopLocation())
+	until := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	if requestBody.Until != "" {
+		t, err := time.Parse(time.RFC3339, requestBody.Until)
+		if err != nil {
+			http.Error(w, "until must be an RFC3339 time", http.StatusBadRequest)
+			return
+		}
+		if !t.After(now) {
+			http.Error(w, "until must be in the future", http.StatusBadRequest)
+			return
+		}
+		until = t
+	}
+
+	if err := models.SetShopSetting(models.SettingClosedUntil, until.Format(time.RFC3339)); err != nil {
+		log.Printf("❌ Error closing shop: %v", err)
+		http.Error(w, "Error closing shop", http.StatusInternalServerError)
+		return
+	}
+	reason := strings.TrimSpace(requestBody.Reason)
+	if reason != "" {
+		err := models.SetShopSetting(models.SettingClosedReason, reason)
+		if err != nil {
+			log.Printf("⚠️ Error saving closure reason: %v", err)
+		}
+	} else if err := models.DeleteShopSetting(models.SettingClosedReason); err != nil {
+		log.Printf("⚠️ Error clearing closure reason: %v", err)
+	}
+	log.Printf("🔒 Shop closed until %s", until.Format(time.RFC3339))
+
+	AdminGetBusinessHours(w, r)
+}
+
+// AdminReopenShop cancels a temporary closure
+func AdminReopenShop(w http.ResponseWriter, r *http.Request) {
+	if err := models.DeleteShopSetting(models.SettingClosedUntil); err != nil {
+		log.Printf("❌ Error reopening shop: %v", err)
+		http.Error(w, "Error reopening shop", http.StatusInternalServerError)
+		return
+	}
+	if err := models.DeleteShopSetting(models.SettingClosedReason); err != nil {
+		log.Printf("⚠️ Error clearing closure reason: %v", err)
+	}
+	log.Printf("🔓 Temporary closure lifted")
+
+	AdminGetBusinessHours(w, r)
+}
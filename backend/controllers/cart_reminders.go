@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// defaultCartReminderDelay is how long a filled cart must be idle before the customer is
+// reminded when CART_REMINDER_DELAY is unset
+const defaultCartReminderDelay = 2 * time.Hour
+
+// cartReminderCheckInterval is how often idle carts are looked for
+const cartReminderCheckInterval = 5 * time.Minute
+
+// messagingWindow is how long after the customer's last message Messenger lets the page
+// send ordinary messages
+const messagingWindow = 24 * time.Hour
+
+// StartCartReminder periodically reminds customers who left items in their cart and went
+// quiet for CART_REMINDER_DELAY (default 2h). Each conversation is reminded once, and only
+// while Messenger's 24-hour messaging window is still open.
+func StartCartReminder() {
+	delay := defaultCartReminderDelay
+	if v := os.Getenv("CART_REMINDER_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d >= messagingWindow {
+			log.Printf("⚠️ Invalid CART_REMINDER_DELAY %q (must be under %v), using %v", v, messagingWindow, delay)
+		} else {
+			delay = d
+		}
+	}
+
+	go func() {
+		for {
+			time.Sleep(cartReminderCheckInterval)
+			checkAbandonedCarts(delay)
+		}
+	}()
+	log.Printf("✅ Cart reminders sent after %v of inactivity", delay)
+}
+
+// checkAbandonedCarts reminds every customer whose cart has been idle for at least delay
+func checkAbandonedCarts(delay time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic recovered in cart reminder: %v", r)
+		}
+	}()
+
+	StateMutex.RLock()
+	userIDs := make([]string, 0, len(UserStates))
+	for userID := range UserStates {
+		userIDs = append(userIDs, userID)
+	}
+	StateMutex.RUnlock()
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if reminder := claimCartReminder(userID, now, delay); reminder != nil {
+			sendCartReminder(userID, reminder)
+		}
+	}
+}
+
+// claimCartReminder marks an idle cart as reminded, under the customer's lock so a reminder
+// is never sent twice, and returns a copy of what the reminder needs (nil if none is due)
+func claimCartReminder(userID string, now time.Time, delay time.Duration) *UserState {
+	defer lockUser(userID)()
+	state := peekUserState(userID)
+	if state == nil || len(state.Cart) == 0 || state.CartReminded || state.LastActivity.IsZero() {
+		return nil
+	}
+	idle := now.Sub(state.LastActivity)
+	if idle < delay || idle >= messagingWindow {
+		return nil
+	}
+	state.CartReminded = true
+	return &UserState{
+		Language:  state.Language,
+		SessionID: state.SessionID,
+		Cart:      append([]CartItem(nil), state.Cart...),
+	}
+}
+
+// sendCartReminder asks the customer to come back to their cart, from a copy of their state
+func sendCartReminder(userID string, state *UserState) {
+	totalItems := 0
+	for _, item := range state.Cart {
+		totalItems += item.Quantity
+	}
+	subtotal, _, _, err := calculateOrderTotals(state.Cart, "pickup", "")
+	if err != nil {
+		// Still remind the customer; the reminder report just misses this cart's value
+		log.Printf("⚠️ Failed to price cart of %s: %v", userID, err)
+	}
+
+	buttons := []Button{
+		{Type: "postback", Title: tr(state, "button.resume_checkout"), Payload: "RESUME_CHECKOUT"},
+		{Type: "postback", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
+	}
+	if err := SendButtonTemplate(userID, tr(state, "cart_reminder.text", i18n.Args{"count": totalItems}), buttons); err != nil {
+		log.Printf("❌ Failed to send cart reminder to %s: %v", userID, err)
+		return
+	}
+	if _, err := models.CreateCartReminder(state.SessionID, userID, totalItems, subtotal); err != nil {
+		log.Printf("⚠️ Failed to record cart reminder for %s: %v", userID, err)
+	}
+	log.Printf("🛒 Cart reminder sent to %s (%d items)", userID, totalItems)
+}
+
+// resumeCheckout brings a reminded customer back to their cart
+func resumeCheckout(userID string) {
+	state := GetUserState(userID)
+	if state.CartReminded && state.SessionID != "" {
+		sessionID := state.SessionID
+		go func() {
+			if err := models.MarkCartReminderResumed(sessionID); err != nil {
+				log.Printf("⚠️ Failed to record resumed checkout for %s: %v", userID, err)
+			}
+		}()
+	}
+
+	if len(state.Cart) == 0 {
+		// The cart was ordered or cleared since the reminder
+		showProducts(userID)
+		return
+	}
+	showCart(userID)
+	askAddMore(userID)
+}
+
+// trackCartReminderConversion links an order to the reminder sent in the same conversation
+func trackCartReminderConversion(userID string, orderID int) {
+	state := peekUserState(userID)
+	if state == nil || !state.CartReminded || state.SessionID == "" {
+		return
+	}
+
+	sessionID := state.SessionID
+	go func() {
+		if err := models.MarkCartReminderConverted(sessionID, orderID); err != nil {
+			log.Printf("⚠️ Failed to record cart reminder conversion for order #%d: %v", orderID, err)
+		}
+	}()
+}
+
+// AdminGetCartReminderReport returns how many cart reminders were sent and how many led
+// customers back to checkout and to an order
+// GET /api/admin/reports/cart-reminders?from=&to=[&timezone=]
+func AdminGetCartReminderReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	stats, err := models.GetCartReminderStats(rng)
+	if err != nil {
+		log.Printf("❌ Error building cart reminder report: %v", err)
+		http.Error(w, "Error building cart reminder report", http.StatusInternalServerError)
+		return
+	}
+	writeSalesReport(w, rng, map[string]interface{}{"reminders": stats})
+}
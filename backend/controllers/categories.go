@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// categoriesTTL is how long the category list is cached for emoji and name lookups.
+// Admin category changes invalidate it immediately; the TTL covers direct DB edits.
+const categoriesTTL = 5 * time.Minute
+
+// maxCategoryReplies leaves room for Cancel within Messenger's 13 quick replies
+const maxCategoryReplies = 12
+
+var (
+	categoryCache      map[int]models.Category
+	categoryCacheAt    time.Time
+	categoryCacheMutex sync.Mutex
+)
+
+// getCategory returns a category (active or not) from the cache, reloading it if stale
+func getCategory(id int) (models.Category, bool) {
+	categoryCacheMutex.Lock()
+	defer categoryCacheMutex.Unlock()
+
+	if categoryCache == nil || time.Since(categoryCacheAt) >= categoriesTTL {
+		if configs.DB == nil {
+			return models.Category{}, false
+		}
+		categories, err := models.GetCategories(configs.DB, false)
+		if err != nil {
+			log.Printf("⚠️ Failed to load categories: %v", err)
+		} else {
+			categoryCache = map[int]models.Category{}
+			for _, c := range categories {
+				categoryCache[c.ID] = c
+			}
+			categoryCacheAt = time.Now()
+		}
+	}
+	c, ok := categoryCache[id]
+	return c, ok
+}
+
+// invalidateCategories forces a reload on next use (call after category changes)
+func invalidateCategories() {
+	categoryCacheMutex.Lock()
+	defer categoryCacheMutex.Unlock()
+	categoryCache = nil
+}
+
+// categoryEmoji returns the display emoji for a product category
+func categoryEmoji(categoryID int) string {
+	if c, ok := getCategory(categoryID); ok && c.Emoji != "" {
+		return c.Emoji
+	}
+	return models.DefaultCategoryEmoji
+}
+
+// showCategories asks which category to browse; with a single category the products are shown directly
+func showCategories(userID string) {
+	state := GetUserState(userID)
+
+	var categories []models.Category
+	if configs.DB != nil {
+		var err error
+		categories, err = models.GetMenuCategories(configs.DB, time.Now().In(shopLocation()))
+		if err != nil {
+			log.Printf("⚠️ Failed to load menu categories: %v", err)
+		}
+	}
+	if len(categories) <= 1 {
+		showCategoryProducts(userID, 0)
+		return
+	}
+
+	if len(categories) > maxCategoryReplies {
+		log.Printf("⚠️ %d menu categories, only the first %d fit in the picker", len(categories), maxCategoryReplies)
+		categories = categories[:maxCategoryReplies]
+	}
+	var quickReplies []QuickReply
+	for _, c := range categories {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       c.Emoji + " " + c.DisplayName(state.Language),
+			Payload:     fmt.Sprintf("CATEGORY_%d", c.ID),
+		})
+	}
+	quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"})
+
+	state.State = "awaiting_category"
+	state.CurrentCategoryID = 0
+	clearCurrentItem(state)
+	state.OptionQueue = nil
+	SendQuickReplies(userID, tr(state, "category.ask"), quickReplies)
+}
+
+// handleCategoryPostback shows the products of the category in CATEGORY_<id>
+func handleCategoryPostback(userID, payload string) {
+	id, err := strconv.Atoi(strings.TrimPrefix(payload, "CATEGORY_"))
+	if err != nil {
+		showCategories(userID)
+		return
+	}
+	showCategoryProducts(userID, id)
+}
+
+// showCategoryProducts shows the first page of one category's products (0 = all categories)
+func showCategoryProducts(userID string, categoryID int) {
+	state := GetUserState(userID)
+	state.CurrentCategoryID = categoryID
+	state.SearchQuery = ""
+	showProductPage(userID, 0)
+}
+
+// showSearchResults shows the first page of products matching a customer's search
+func showSearchResults(userID, query string) {
+	state := GetUserState(userID)
+	if query = models.NormalizeSearch(query); query == "" {
+		SendMessage(userID, tr(state, "search.usage"))
+		return
+	}
+	if !checkBusinessHours(userID) {
+		return
+	}
+	state.CurrentCategoryID = 0
+	state.SearchQuery = query
+	showProductPage(userID, 0)
+}
+
+// showProductPage shows a page of the current category's product carousel
+func showProductPage(userID string, page int) {
+	state := GetUserState(userID)
+	state.State = "awaiting_product"
+	state.ProductPage = page
+	clearCurrentItem(state)
+	state.OptionQueue = nil
+
+	elements := getProductElements(state)
+	if len(elements) == 0 && page > 0 {
+		// The catalog shrank since this page was offered
+		showProductPage(userID, 0)
+		return
+	}
+	if len(elements) == 0 && state.SearchQuery != "" {
+		SendMessage(userID, tr(state, "search.no_results", i18n.Args{"query": state.SearchQuery}))
+		state.SearchQuery = ""
+		showCategories(userID)
+		return
+	}
+	if len(elements) == 0 && state.CurrentCategoryID != 0 {
+		// Emptied since the picker was shown
+		SendMessage(userID, tr(state, "category.empty"))
+		showCategories(userID)
+		return
+	}
+	if state.SearchQuery != "" && page == 0 {
+		SendMessage(userID, tr(state, "search.results", i18n.Args{"query": state.SearchQuery}))
+	}
+	trackFunnelStep(userID, models.FunnelCarousel)
+	SendGenericTemplate(userID, elements)
+}
+
+// handleProductPagePostback shows the carousel page in PRODUCTS_PAGE_<n>
+func handleProductPagePostback(userID, payload string) {
+	page, err := strconv.Atoi(strings.TrimPrefix(payload, "PRODUCTS_PAGE_"))
+	if err != nil || page < 0 {
+		page = 0
+	}
+	showProductPage(userID, page)
+}
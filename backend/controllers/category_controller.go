@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"bakeflow/models"
+)
+
+// resolveProductCategory fills a product's category ID and name from whichever the client sent
+// (category_id, or the category name used by older clients). Writes the error response and
+// returns false when the category does not exist.
+func (pc *ProductController) resolveProductCategory(w http.ResponseWriter, p *models.Product) bool {
+	if p.CategoryID == 0 && p.Category == "" {
+		return true // reported by Validate
+	}
+
+	var category *models.Category
+	var err error
+	if p.CategoryID != 0 {
+		category, err = models.GetCategoryByID(pc.DB, p.CategoryID)
+	} else {
+		category, err = models.GetCategoryByName(pc.DB, p.Category)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch category", err)
+		return false
+	}
+	if category == nil {
+		respondWithError(w, http.StatusBadRequest, "Unknown category", nil)
+		return false
+	}
+	p.CategoryID, p.Category = category.ID, category.Name
+	return true
+}
+
+// GetCategories handles GET /api/categories - all categories in menu order with product counts
+func (pc *ProductController) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := models.GetCategories(pc.DB, r.URL.Query().Get("active") == "true")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch categories", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+// CreateCategory handles POST /api/categories
+func (pc *ProductController) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	category := models.Category{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := category.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	existing, err := models.GetCategoryByName(pc.DB, category.Name)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check category", err)
+		return
+	}
+	if existing != nil {
+		respondWithError(w, http.StatusConflict, "A category with this name already exists", nil)
+		return
+	}
+
+	if err := models.CreateCategory(pc.DB, &category); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create category", err)
+		return
+	}
+	invalidateCategories()
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"success":  true,
+		"message":  "Category created successfully",
+		"category": category,
+	})
+}
+
+// UpdateCategory handles PUT /api/categories/:id - omitted fields keep their value
+func (pc *ProductController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	existing, err := models.GetCategoryByID(pc.DB, ids[0])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch category", err)
+		return
+	}
+	if existing == nil {
+		respondWithError(w, http.StatusNotFound, "Category not found", nil)
+		return
+	}
+
+	category := *existing
+	category.Names = nil // replaced as a whole when sent
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if category.Names == nil {
+		category.Names = existing.Names
+	}
+	category.ID = existing.ID
+	if err := category.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if other, err := models.GetCategoryByName(pc.DB, category.Name); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check category", err)
+		return
+	} else if other != nil && other.ID != category.ID {
+		respondWithError(w, http.StatusConflict, "A category with this name already exists", nil)
+		return
+	}
+
+	if err := models.UpdateCategory(pc.DB, &category, existing.Name); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update category", err)
+		return
+	}
+	invalidateCategories()
+	if category.Name != existing.Name {
+		invalidateProductMatcher()
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"message":  "Category updated successfully",
+		"category": category,
+	})
+}
+
+// DeleteCategory handles DELETE /api/categories/:id - only categories without products
+func (pc *ProductController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	deleted, err := models.DeleteCategory(pc.DB, ids[0])
+	if err == models.ErrCategoryInUse {
+		respondWithError(w, http.StatusConflict, "Move or delete the products in this category first", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete category", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Category not found", nil)
+		return
+	}
+	invalidateCategories()
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Category deleted successfully",
+	})
+}
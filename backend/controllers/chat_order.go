@@ -1,10 +1,13 @@
 package controllers
 
 import (
-	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
 type ChatOrderRequest struct {
@@ -16,6 +19,7 @@ type ChatOrderRequest struct {
 	CustomerPhone string         `json:"customer_phone"`
 	DeliveryType string          `json:"delivery_type"`
 	Address      string          `json:"address"`
+	SlotStart    string          `json:"slot_start"` // optional RFC3339 slot start from GET /api/chat/slots
 }
 
 type ChatOrderItem struct {
@@ -61,33 +65,68 @@ func CreateChatOrder(w http.ResponseWriter, r *http.Request) {
 		customerInfo += " (" + req.CustomerPhone + ")"
 	}
 
-	// Insert order into database
-	var orderID int
-	err := configs.DB.QueryRow(`
-		INSERT INTO orders (customer_name, delivery_type, address, status, total_items, subtotal, delivery_fee, total_amount, sender_id, created_at)
-		VALUES ($1, $2, $3, 'pending', $4, $5, 0, $5, $6, NOW())
-		RETURNING id
-	`, customerInfo, req.DeliveryType, req.Address, totalItems, total, req.UserID).Scan(&orderID)
+	order := models.Order{
+		CustomerName: customerInfo,
+		DeliveryType: req.DeliveryType,
+		Address:      req.Address,
+		Status:       "pending",
+		TotalItems:   totalItems,
+		Subtotal:     total,
+		TotalAmount:  total,
+		SenderID:     req.UserID,
+		Language:     userLanguage(req.UserID),
+	}
 
-	if err != nil {
-		log.Printf("❌ Failed to create order: %v", err)
-		http.Error(w, "failed to create order", http.StatusInternalServerError)
-		return
+	// Optional scheduled slot: must still be bookable for these products
+	if req.SlotStart != "" {
+		start, err := time.Parse(time.RFC3339, req.SlotStart)
+		if err != nil {
+			http.Error(w, "slot_start must be an RFC3339 time", http.StatusBadRequest)
+			return
+		}
+		planner := newSlotPlanner()
+		if planner == nil {
+			http.Error(w, "scheduling unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var ids []int
+		for _, item := range req.Items {
+			ids = append(ids, item.ProductID)
+		}
+		slot, err := planner.findSlot(start.In(planner.schedule.loc), productCategories(ids))
+		if err != nil {
+			log.Printf("❌ Failed to check slot: %v", err)
+			http.Error(w, "failed to check slot", http.StatusInternalServerError)
+			return
+		}
+		if slot == nil {
+			http.Error(w, "time slot is not available", http.StatusConflict)
+			return
+		}
+		order.SlotStart, order.SlotEnd = &slot.Start, &slot.End
 	}
 
-	// Insert order items
+	var orderItems []models.OrderItem
 	for _, item := range req.Items {
-		_, err = configs.DB.Exec(`
-			INSERT INTO order_items (order_id, product, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, NOW())
-		`, orderID, item.Name, item.Qty, item.Price)
+		orderItems = append(orderItems, models.OrderItem{Product: item.Name, ProductID: item.ProductID, Quantity: item.Qty, Price: item.Price})
+	}
 
-		if err != nil {
-			log.Printf("⚠️  Failed to insert item %s: %v", item.Name, err)
-		}
+	// Insert order and items in one transaction
+	err := models.CreateOrder(&order, orderItems, time.Now().In(shopLocation()))
+	if err == models.ErrSlotFull {
+		http.Error(w, "time slot is fully booked", http.StatusConflict)
+		return
 	}
+	if err != nil {
+		log.Printf("❌ Failed to create order: %v", err)
+		http.Error(w, "failed to create order", http.StatusInternalServerError)
+		return
+	}
+	orderID := order.ID
 
 	log.Printf("✅ Order #%d created successfully", orderID)
+	trackFunnelStep(req.UserID, models.FunnelOrdered)
+	trackCartReminderConversion(req.UserID, orderID)
 
 	// Send response
 	resp := ChatOrderResponse{
@@ -103,22 +142,27 @@ func CreateChatOrder(w http.ResponseWriter, r *http.Request) {
 	go func() {
 		defer func() { _ = recover() }()
 		
+		lang := userLanguage(req.UserID)
 		itemsList := ""
 		for i, item := range req.Items {
 			if i < 3 {
-				itemsList += item.Name + " × " + string(rune(item.Qty+'0')) + "\n"
+				itemsList += fmt.Sprintf("%s × %d\n", item.Name, item.Qty)
 			}
 		}
 		if len(req.Items) > 3 {
-			itemsList += "...and more\n"
+			itemsList += i18n.T(lang, "history.more_items", i18n.Args{"count": len(req.Items) - 3}) + "\n"
 		}
 
-		msg := "🎉 Order Confirmed!\n\n" +
-			"Order #" + string(rune(orderID+'0')) + "\n" +
-			itemsList +
-			"\nTotal: $" + string(rune(int(total))) + "\n" +
-			"Status: ⏳ Pending\n\n" +
-			"We'll start preparing your order soon!"
+		msg := i18n.T(lang, "notify.chat_order_confirmed", i18n.Args{
+			"id":     orderID,
+			"items":  itemsList,
+			"total":  fmt.Sprintf("%.2f", total),
+			"status": i18n.T(lang, "status.pending"),
+		})
+		if order.SlotStart != nil {
+			state := &UserState{Language: lang}
+			msg += "\n\n" + i18n.T(lang, "order.eta_scheduled", i18n.Args{"slot": formatSlotRange(state, *order.SlotStart, *order.SlotEnd)})
+		}
 
 		SendMessage(req.UserID, msg)
 	}()
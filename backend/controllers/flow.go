@@ -3,8 +3,8 @@ package controllers
 import (
 	"fmt"
 	"log"
-	"strings"
 
+	"bakeflow/i18n"
 	"bakeflow/models"
 )
 
@@ -17,23 +17,8 @@ import (
 // showHelp displays help information
 // showHelp displays ordering instructions
 func showHelp(userID string) {
-	help := "🆘 *How to Order* / *မှာယူနည်း*\n\n" +
-		"1️⃣ Choose what you'd like to order\n" +
-		"    လိုချင်တဲ့ပစ္စည်းကို ရွေးပါ\n" +
-		"2️⃣ Select quantity / အရေအတွက် ရွေးပါ\n" +
-		"3️⃣ Enter your name / နာမည် ထည့်ပါ\n" +
-		"4️⃣ Choose pickup or delivery\n" +
-		"    ကိုယ်တိုင်ယူမလား ပို့မလား ရွေးပါ\n" +
-		"5️⃣ Confirm your order / အတည်ပြုပါ\n\n" +
-		"*You can type naturally:* / *သဘာဝအတိုင်း စာရိုက်နိုင်ပါတယ်*\n" +
-		"• \"I want chocolate cake\" / \"ချောကလက်ကိတ်လိုချင်တယ်\"\n" +
-		"• \"Give me 2\" / \"2 ခု ပေးပါ\"\n" +
-		"• \"I want to cancel\" / \"ပယ်ဖျက်ချင်တယ်\"\n" +
-		"• \"Show menu\" / \"မီနူး ပြပါ\"\n\n" +
-		"*Quick Commands:*\n" +
-		"• 'menu' - View products\n" +
-		"• 'cancel' - Start over\n" +
-		"• 'help' - Show this message"
+	state := GetUserState(userID)
+	help := tr(state, "help.text")
 
 	SendMessage(userID, help)
 
@@ -47,8 +32,12 @@ func goBack(userID string) {
 	state := GetUserState(userID)
 
 	switch state.State {
-	case "awaiting_quantity":
-		// Go back to product selection
+	case "awaiting_option", "awaiting_inscription", "awaiting_quantity":
+		// Go back to the carousel page the product was picked from
+		showProductPage(userID, state.ProductPage)
+
+	case "awaiting_product":
+		// Go back to the category picker
 		showProducts(userID)
 
 	case "awaiting_cart_decision":
@@ -63,40 +52,27 @@ func goBack(userID string) {
 		// Go back to name input
 		state.State = "awaiting_name"
 		quickReplies := []QuickReply{
-			{ContentType: "text", Title: "⬅️ Back to Cart", Payload: "GO_BACK"},
-			{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+			{ContentType: "text", Title: tr(state, "button.back_to_cart"), Payload: "GO_BACK"},
+			{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 		}
-		SendQuickReplies(userID, "What's your name?", quickReplies)
+		SendQuickReplies(userID, tr(state, "name.ask"), quickReplies)
 
 	case "awaiting_address":
 		// Go back to pickup/delivery selection
-		state.State = "awaiting_delivery_type"
-		quickReplies := []QuickReply{
-			{ContentType: "text", Title: "🏠 Pickup", Payload: "PICKUP"},
-			{ContentType: "text", Title: "🚚 Delivery", Payload: "DELIVERY"},
-			{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-			{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-		}
-		SendQuickReplies(userID, fmt.Sprintf("Thanks %s! Would you like pickup or delivery?", state.CustomerName), quickReplies)
+		askDeliveryType(userID)
+
+	case "confirming", "awaiting_slot":
+		// Go back to date/time selection
+		askOrderTime(userID)
 
-	case "confirming":
+	case "awaiting_order_date":
 		// Go back to address or delivery type
 		if state.DeliveryType == "delivery" {
 			state.State = "awaiting_address"
-			quickReplies := []QuickReply{
-				{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-				{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-			}
-			SendQuickReplies(userID, "Please type your delivery address:\n(Street, City, ZIP)", quickReplies)
+			trackFunnelStep(userID, models.FunnelAddress)
+			SendQuickReplies(userID, tr(state, "address.ask"), backCancelQuickReplies(state))
 		} else {
-			state.State = "awaiting_delivery_type"
-			quickReplies := []QuickReply{
-				{ContentType: "text", Title: "🏠 Pickup", Payload: "PICKUP"},
-				{ContentType: "text", Title: "🚚 Delivery", Payload: "DELIVERY"},
-				{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-				{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-			}
-			SendQuickReplies(userID, fmt.Sprintf("Thanks %s! Would you like pickup or delivery?", state.CustomerName), quickReplies)
+			askDeliveryType(userID)
 		}
 
 	default:
@@ -111,30 +87,19 @@ func goBack(userID string) {
 
 // showOrderHistory displays user's past orders with beautiful card design
 func showOrderHistory(userID string) {
+	state := GetUserState(userID)
+
 	// Get all orders (in future, filter by userID)
 	orders, err := models.GetAllOrders()
 	if err != nil {
 		log.Printf("❌ Error fetching orders: %v", err)
-		SendMessage(userID, "😞 Sorry, couldn't load your order history. Please try again later.")
+		SendMessage(userID, tr(state, "history.error"))
 		return
 	}
 
 	// Check if empty
 	if len(orders) == 0 {
-		state := GetUserState(userID)
-		emptyMsg := "🛒 **No Orders Yet!**\n\n" +
-			"You haven't placed any orders with us.\n\n" +
-			"Ready to try our delicious baked goods?\n\n" +
-			"Type 'menu' to start ordering! 🍰"
-
-		if state.Language == "my" {
-			emptyMsg = "🛒 **မှာထားမှုမရှိသေးပါ!**\n\n" +
-				"သင် ကျွန်ုပ်တို့နှင့် မှာထားမှုမလုပ်ရသေးပါ။\n\n" +
-				"ကျွန်ုပ်တို့ရဲ့ အရသာရှိတဲ့ မုန့်တွေကို စမ်းကြည့်ဖို့ အဆင်သင့်လား?\n\n" +
-				"'မီနူး' လို့ရိုက်ပြီး မှာယူလိုက်ပါ! 🍰"
-		}
-
-		SendMessage(userID, emptyMsg)
+		SendMessage(userID, tr(state, "history.empty"))
 		return
 	}
 
@@ -158,29 +123,24 @@ func showOrderHistory(userID string) {
 			}
 		}
 		if len(order.Items) > 3 {
-			itemsList += fmt.Sprintf("...and %d more items\n", len(order.Items)-3)
+			itemsList += tr(state, "history.more_items", i18n.Args{"count": len(order.Items) - 3}) + "\n"
 		}
 
 		// Status badge
 		statusEmoji := "⏳"
-		statusText := "Pending"
 		switch order.Status {
 		case "pending":
 			statusEmoji = "⏳"
-			statusText = "Pending"
 		case "preparing":
 			statusEmoji = "👨‍🍳"
-			statusText = "Preparing"
 		case "ready":
 			statusEmoji = "✅"
-			statusText = "Ready"
 		case "delivered":
 			statusEmoji = "🎉"
-			statusText = "Delivered"
 		case "completed":
 			statusEmoji = "✔️"
-			statusText = "Completed"
 		}
+		statusText := tr(state, "status."+order.Status)
 
 		// Delivery icon
 		deliveryIcon := "🏠"
@@ -192,24 +152,24 @@ func showOrderHistory(userID string) {
 		dateStr := order.CreatedAt.Format("Jan 2, 3:04 PM")
 
 		// Build subtitle
-		subtitle := fmt.Sprintf("%s %s • %s %s\n%s\nTotal: $%.2f",
+		subtitle := fmt.Sprintf("%s %s • %s %s\n%s\n%s",
 			statusEmoji, statusText,
-			deliveryIcon, strings.Title(order.DeliveryType),
+			deliveryIcon, tr(state, "delivery_type."+order.DeliveryType),
 			dateStr,
-			order.TotalAmount)
+			tr(state, "history.total", i18n.Args{"total": fmt.Sprintf("%.2f", order.TotalAmount)}))
 
 		element := Element{
-			Title:    fmt.Sprintf("Order #%d - %s", order.ID, order.CustomerName),
+			Title:    tr(state, "history.card_title", i18n.Args{"id": order.ID, "name": order.CustomerName}),
 			Subtitle: subtitle + "\n\n" + itemsList,
 			Buttons: []Button{
 				{
 					Type:    "postback",
-					Title:   "🔄 Reorder",
+					Title:   tr(state, "button.reorder"),
 					Payload: fmt.Sprintf("REORDER_%d", order.ID),
 				},
 				{
 					Type:    "postback",
-					Title:   "⭐ Rate",
+					Title:   tr(state, "button.rate"),
 					Payload: fmt.Sprintf("RATE_ORDER_%d", order.ID),
 				},
 			},
@@ -218,7 +178,7 @@ func showOrderHistory(userID string) {
 		elements = append(elements, element)
 	}
 
-	SendMessage(userID, fmt.Sprintf("📋 **Your Recent Orders** (Showing %d of %d)", len(displayOrders), len(orders)))
+	SendMessage(userID, tr(state, "history.header", i18n.Args{"shown": len(displayOrders), "count": len(orders)}))
 	SendGenericTemplate(userID, elements)
 }
 
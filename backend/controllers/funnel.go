@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+
+	"bakeflow/models"
+)
+
+// abandonedCartIdle is how long a conversation with a filled cart must be quiet before the
+// funnel report counts it as abandoned
+const abandonedCartIdle = time.Hour
+
+// newSessionID returns a random ID for a new bot conversation
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// trackFunnelStep records that the customer's current conversation reached a funnel step.
+// Customers without a conversation (e.g. webview orders after a restart) are not tracked.
+func trackFunnelStep(userID, step string) {
+	state := peekUserState(userID)
+	if state == nil || state.SessionID == "" {
+		return
+	}
+
+	sessionID, at := state.SessionID, time.Now()
+	go func() {
+		if err := models.RecordFunnelEvent(sessionID, userID, step, at); err != nil {
+			log.Printf("⚠️ Failed to record funnel step %s for %s: %v", step, userID, err)
+		}
+	}()
+}
+
+// AdminGetFunnelReport returns how many bot conversations reached each ordering step, the
+// drop-off after each step, the median time spent on it and the abandoned carts
+// GET /api/admin/reports/funnel?from=&to=[&timezone=]
+func AdminGetFunnelReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	steps, err := models.GetFunnel(rng)
+	if err != nil {
+		log.Printf("❌ Error building funnel: %v", err)
+		http.Error(w, "Error building funnel report", http.StatusInternalServerError)
+		return
+	}
+	abandoned, err := models.CountAbandonedCarts(rng, time.Now().Add(-abandonedCartIdle))
+	if err != nil {
+		log.Printf("❌ Error counting abandoned carts: %v", err)
+		http.Error(w, "Error building funnel report", http.StatusInternalServerError)
+		return
+	}
+
+	sessions := 0
+	if len(steps) > 0 {
+		sessions = steps[0].Sessions
+	}
+	writeSalesReport(w, rng, map[string]interface{}{
+		"sessions":        sessions,
+		"steps":           steps,
+		"abandoned_carts": abandoned,
+	})
+}
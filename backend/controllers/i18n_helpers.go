@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"log"
+	"strings"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// tr looks up a customer-facing message in the user's chosen language
+func tr(state *UserState, key string, args ...i18n.Args) string {
+	return i18n.T(state.Language, key, args...)
+}
+
+// userLanguage returns a user's chosen language without creating conversation state.
+// Falls back to the saved customer language, then the default language.
+func userLanguage(userID string) string {
+	if lang := stateLanguage(userID); lang != "" {
+		return lang
+	}
+	if lang := savedLanguage(userID); lang != "" {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
+
+// orderLanguage picks the language for messages about an order sent outside the conversation:
+// the customer's current or saved choice, then the language the order was placed in
+func orderLanguage(order *models.Order) string {
+	if lang := stateLanguage(order.SenderID); lang != "" {
+		return lang
+	}
+	if lang := savedLanguage(order.SenderID); lang != "" {
+		return lang
+	}
+	if i18n.IsSupported(order.Language) {
+		return order.Language
+	}
+	return i18n.DefaultLanguage
+}
+
+// stateLanguage returns the language of an in-progress conversation ("" if none)
+func stateLanguage(userID string) string {
+	StateMutex.RLock()
+	defer StateMutex.RUnlock()
+	if s, ok := UserStates[userID]; ok {
+		return s.Language
+	}
+	return ""
+}
+
+// savedLanguage loads the customer's stored language ("" if unknown or unsupported)
+func savedLanguage(userID string) string {
+	if userID == "" || configs.DB == nil {
+		return ""
+	}
+	lang, err := models.GetCustomerLanguage(userID)
+	if err != nil {
+		log.Printf("⚠️ Could not load language for %s: %v", userID, err)
+		return ""
+	}
+	if !i18n.IsSupported(lang) {
+		return ""
+	}
+	return lang
+}
+
+// setUserLanguage switches the conversation language and remembers it for later visits
+func setUserLanguage(userID string, state *UserState, lang string) {
+	state.Language = lang
+	if configs.DB == nil {
+		return
+	}
+	go func() {
+		if err := models.SaveCustomerLanguage(userID, lang); err != nil {
+			log.Printf("⚠️ Could not save language for %s: %v", userID, err)
+		}
+	}()
+}
+
+// languagePayload returns the postback payload that selects a language (LANG_EN, LANG_MY, ...)
+func languagePayload(lang string) string {
+	return "LANG_" + strings.ToUpper(lang)
+}
+
+// deliveryTypeQuickReplies builds the Pickup/Delivery/Back/Cancel quick replies
+func deliveryTypeQuickReplies(state *UserState) []QuickReply {
+	return []QuickReply{
+		{ContentType: "text", Title: tr(state, "button.pickup"), Payload: "PICKUP"},
+		{ContentType: "text", Title: tr(state, "button.delivery"), Payload: "DELIVERY"},
+		{ContentType: "text", Title: tr(state, "button.back"), Payload: "GO_BACK"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
+	}
+}
+
+// backCancelQuickReplies builds the Back/Cancel quick replies used on free-text steps
+func backCancelQuickReplies(state *UserState) []QuickReply {
+	return []QuickReply{
+		{ContentType: "text", Title: tr(state, "button.back"), Payload: "GO_BACK"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
+	}
+}
+
+// askDeliveryType asks whether the order is for pickup or delivery
+func askDeliveryType(userID string) {
+	state := GetUserState(userID)
+	state.State = "awaiting_delivery_type"
+	SendQuickReplies(userID, tr(state, "delivery.ask", i18n.Args{"name": state.CustomerName}), deliveryTypeQuickReplies(state))
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+
+	"github.com/gorilla/mux"
+)
+
+// GetIngredients handles GET /api/ingredients - list ingredient stock levels
+func (pc *ProductController) GetIngredients(w http.ResponseWriter, r *http.Request) {
+	ingredients, err := models.GetIngredients(pc.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch ingredients", err)
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, i := range ingredients {
+		items = append(items, map[string]interface{}{
+			"id":                  i.ID,
+			"name":                i.Name,
+			"unit":                i.Unit,
+			"stock":               i.Stock,
+			"low_stock_threshold": i.LowStockThreshold,
+			"low_stock":           i.IsLowStock(),
+			"updated_at":          i.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"ingredients": items,
+		"count":       len(items),
+	})
+}
+
+// CreateIngredient handles POST /api/ingredients - add an ingredient with opening stock
+func (pc *ProductController) CreateIngredient(w http.ResponseWriter, r *http.Request) {
+	var ingredient models.Ingredient
+	if err := json.NewDecoder(r.Body).Decode(&ingredient); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if err := ingredient.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if ingredient.Stock < 0 {
+		respondWithError(w, http.StatusBadRequest, "ingredient stock cannot be negative", nil)
+		return
+	}
+
+	if err := models.CreateIngredient(pc.DB, &ingredient); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create ingredient", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"success":    true,
+		"message":    "Ingredient created successfully",
+		"ingredient": ingredient,
+	})
+}
+
+// UpdateIngredient handles PUT /api/ingredients/:id - rename, change unit or alert threshold
+func (pc *ProductController) UpdateIngredient(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ingredient ID", err)
+		return
+	}
+
+	var ingredient models.Ingredient
+	if err := json.NewDecoder(r.Body).Decode(&ingredient); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	ingredient.ID = id
+	if err := ingredient.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	found, err := models.UpdateIngredient(pc.DB, &ingredient)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update ingredient", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Ingredient not found", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"message":    "Ingredient updated successfully",
+		"ingredient": ingredient,
+	})
+}
+
+// DeleteIngredient handles DELETE /api/ingredients/:id - only when no recipe uses it
+func (pc *ProductController) DeleteIngredient(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ingredient ID", err)
+		return
+	}
+
+	deleted, err := models.DeleteIngredient(pc.DB, id)
+	if err == models.ErrIngredientInUse {
+		respondWithError(w, http.StatusConflict, "Ingredient is still used by a recipe", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete ingredient", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Ingredient not found", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Ingredient deleted successfully",
+	})
+}
+
+// AdjustIngredientStock handles POST /api/ingredients/:id/stock - restock or correct stock
+// Body: {"change": 5000, "reason": "restock"}; a negative change removes stock
+func (pc *ProductController) AdjustIngredientStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ingredient ID", err)
+		return
+	}
+
+	var req struct {
+		Change float64 `json:"change"`
+		Reason string  `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if req.Change == 0 {
+		respondWithError(w, http.StatusBadRequest, "change must not be zero", nil)
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = models.MovementRestock
+		if req.Change < 0 {
+			req.Reason = models.MovementAdjustment
+		}
+	}
+	if req.Reason != models.MovementRestock && req.Reason != models.MovementAdjustment {
+		respondWithError(w, http.StatusBadRequest, "reason must be restock or adjustment", nil)
+		return
+	}
+
+	ingredient, err := models.AdjustIngredientStock(pc.DB, id, req.Change, req.Reason)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to adjust stock", err)
+		return
+	}
+	if ingredient == nil {
+		respondWithError(w, http.StatusNotFound, "Ingredient not found", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"message":    "Stock updated successfully",
+		"ingredient": ingredient,
+		"low_stock":  ingredient.IsLowStock(),
+	})
+}
+
+// GetProductRecipe handles GET /api/products/:id/recipe - ingredients per product unit
+func (pc *ProductController) GetProductRecipe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	recipe, err := models.GetProductRecipe(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch recipe", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id": id,
+		"recipe":     recipe,
+		"count":      len(recipe),
+	})
+}
+
+// UpdateProductRecipe handles PUT /api/products/:id/recipe - replace the recipe
+// Body: {"items": [{"ingredient_id": 1, "quantity": 250}]}
+func (pc *ProductController) UpdateProductRecipe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req struct {
+		Items []models.RecipeItem `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	for _, it := range req.Items {
+		ingredient, err := models.GetIngredientByID(pc.DB, it.IngredientID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to fetch ingredient", err)
+			return
+		}
+		if ingredient == nil {
+			respondWithError(w, http.StatusBadRequest, "Unknown ingredient ID "+strconv.Itoa(it.IngredientID), nil)
+			return
+		}
+	}
+
+	if err := models.SaveProductRecipe(pc.DB, id, req.Items); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to save recipe", err)
+		return
+	}
+
+	adminID := getAdminIDFromContext(r)
+	changes := map[string]interface{}{
+		"action": "recipe_updated",
+		"items":  req.Items,
+	}
+	go models.CreateLogEntry(pc.DB, id, adminID, "RECIPE_UPDATE", changes)
+
+	recipe, err := models.GetProductRecipe(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch recipe", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Recipe saved successfully",
+		"recipe":  recipe,
+	})
+}
+
+// deductOrderIngredients takes an order's recipe ingredients out of stock and logs low-stock alerts
+func deductOrderIngredients(orderID int) {
+	if configs.DB == nil {
+		return
+	}
+	changed, err := models.DeductOrderIngredients(configs.DB, orderID)
+	if err != nil {
+		log.Printf("❌ Failed to deduct ingredients for order #%d: %v", orderID, err)
+		return
+	}
+	if len(changed) > 0 {
+		log.Printf("🧂 Deducted %d ingredient(s) for order #%d", len(changed), orderID)
+	}
+	for _, i := range changed {
+		if i.IsLowStock() {
+			log.Printf("⚠️ Low ingredient: %s at %.3f %s (threshold %.3f)", i.Name, i.Stock, i.Unit, i.LowStockThreshold)
+		}
+	}
+}
@@ -3,35 +3,88 @@ package controllers
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
+// Commands a whole message can be, whatever the conversation step
+const (
+	commandMenu    = "menu"
+	commandHelp    = "help"
+	commandCancel  = "cancel"
+	commandHistory = "history"
+)
+
+// commandWords maps messages that are exactly a command, in every supported language, to it
+var commandWords = map[string]string{
+	"menu": commandMenu, "catalog": commandMenu, "မီနူး": commandMenu, "เมนู": commandMenu,
+	"help": commandHelp, "?": commandHelp, "ကူညီ": commandHelp, "ช่วย": commandHelp,
+	"cancel": commandCancel, "reset": commandCancel, "start over": commandCancel,
+	"ပယ်ဖျက်": commandCancel, "ပြန်စမယ်": commandCancel, "ยกเลิก": commandCancel,
+	"orders": commandHistory, "history": commandHistory, "my orders": commandHistory,
+	"ငါ့မှာတာ": commandHistory, "ประวัติ": commandHistory,
+}
+
+// isCommandMessage reports whether a message is a command (menu, help, cancel, order
+// history or a product search) rather than free text
+func isCommandMessage(messageText string) bool {
+	if commandWords[strings.ToLower(strings.TrimSpace(messageText))] != "" {
+		return true
+	}
+	_, ok := parseSearchCommand(messageText)
+	return ok
+}
+
 // handleMessage processes text messages from users
 func handleMessage(userID, messageText string) {
 	state := GetUserState(userID)
 	msgLower := strings.ToLower(strings.TrimSpace(messageText))
 
-	// ========== SMART TEXT MATCHING (English + Burmese) ==========
+	// A rating comment is taken verbatim, even when it mentions cancelling or the menu,
+	// unless the customer has clearly moved on
+	if state.State == "awaiting_rating_comment" {
+		if isRatingComment(state, messageText) {
+			handleRatingComment(userID, messageText)
+			return
+		}
+		ResetUserState(userID)
+		state = GetUserState(userID)
+	}
+
+	// ========== SMART TEXT MATCHING (English + Burmese + Thai) ==========
 
 	// Cancel/Reset - Natural language understanding
 	if strings.Contains(msgLower, "cancel") ||
 		strings.Contains(msgLower, "ပယ်ဖျက်") ||
 		strings.Contains(msgLower, "reset") ||
 		strings.Contains(msgLower, "start over") ||
-		strings.Contains(msgLower, "ပြန်စမယ်") {
-		ResetUserState(userID)
-		SendMessage(userID, "❌ Order cancelled.")
-		SendMessage(userID, "━━━━━━━━━━━━━━━━━")
-		SendMessage(userID, "Ready to start fresh? Type 'menu' to see our products!")
+		strings.Contains(msgLower, "ပြန်စမယ်") ||
+		strings.Contains(msgLower, "ยกเลิก") {
+		sendOrderCancelled(userID)
 		return
 	}
 
+	// Inscription text is taken verbatim ("Happy birthday, menu lover!" must not open the menu)
+	if state.State == "awaiting_inscription" {
+		handleInscriptionText(userID, messageText)
+		return
+	}
+
+	// Product search: "search cheesecake", "ရှာ ချိစ်ကိတ်", "ค้นหา ชีสเค้ก"
+	if state.State != "awaiting_name" && state.State != "awaiting_address" {
+		if query, ok := parseSearchCommand(messageText); ok {
+			showSearchResults(userID, query)
+			return
+		}
+	}
+
 	// Menu/Catalog
 	if strings.Contains(msgLower, "menu") ||
 		strings.Contains(msgLower, "catalog") ||
 		strings.Contains(msgLower, "product") ||
 		strings.Contains(msgLower, "show me") ||
 		strings.Contains(msgLower, "မီနူး") ||
-		strings.Contains(msgLower, "ပစ္စည်း") {
+		strings.Contains(msgLower, "ပစ္စည်း") ||
+		strings.Contains(msgLower, "เมนู") {
 		showMenu(userID)
 		return
 	}
@@ -40,58 +93,38 @@ func handleMessage(userID, messageText string) {
 	if strings.Contains(msgLower, "help") ||
 		msgLower == "?" ||
 		strings.Contains(msgLower, "how") ||
-		strings.Contains(msgLower, "ကူညီ") {
+		strings.Contains(msgLower, "ကူညီ") ||
+		strings.Contains(msgLower, "ช่วย") {
 		showHelp(userID)
 		return
 	}
 
 	// Order History
 	if strings.Contains(msgLower, "order") && (strings.Contains(msgLower, "history") || strings.Contains(msgLower, "my")) ||
-		strings.Contains(msgLower, "ငါ့မှာတာ") {
+		strings.Contains(msgLower, "ငါ့မှာတာ") ||
+		strings.Contains(msgLower, "ประวัติ") {
 		showOrderHistory(userID)
 		return
 	}
 
-	// Product name matching - English + Burmese
-	if state.State == "awaiting_product" || state.State == "greeting" {
-		// Chocolate Cake
-		if strings.Contains(msgLower, "chocolate") || strings.Contains(msgLower, "choco") || strings.Contains(msgLower, "ချောကလက်") {
-			handlePostback(userID, "ORDER_CHOCOLATE_CAKE")
-			return
-		}
-		// Vanilla Cake
-		if strings.Contains(msgLower, "vanilla") || strings.Contains(msgLower, "ဗနီလာ") {
-			handlePostback(userID, "ORDER_VANILLA_CAKE")
-			return
-		}
-		// Red Velvet
-		if strings.Contains(msgLower, "red velvet") || strings.Contains(msgLower, "velvet") || strings.Contains(msgLower, "အနီရောင်") {
-			handlePostback(userID, "ORDER_RED_VELVET")
-			return
-		}
-		// Coffee
-		if strings.Contains(msgLower, "coffee") || strings.Contains(msgLower, "ကော်ဖီ") {
-			handlePostback(userID, "ORDER_COFFEE")
-			return
-		}
-		// Croissant
-		if strings.Contains(msgLower, "croissant") || strings.Contains(msgLower, "ခရို့ဆန့်") {
-			handlePostback(userID, "ORDER_CROISSANT")
+	// Multi-item orders in one message: "2 croissants and 1 chocolate cake for pickup"
+	if state.State == "awaiting_product" || state.State == "greeting" || state.State == "main_menu" ||
+		state.State == "awaiting_cart_decision" {
+		if parsed := parseOrderMessage(messageText); parsed.IsMultiItemOrder() {
+			confirmParsedOrder(userID, parsed)
 			return
 		}
-		// Cinnamon Roll
-		if strings.Contains(msgLower, "cinnamon") || strings.Contains(msgLower, "roll") || strings.Contains(msgLower, "ဆင်နမွန်") {
-			handlePostback(userID, "ORDER_CINNAMON_ROLL")
-			return
-		}
-		// Cupcake
-		if strings.Contains(msgLower, "cupcake") || strings.Contains(msgLower, "cup cake") || strings.Contains(msgLower, "ကပ်ကိတ်") {
-			handlePostback(userID, "ORDER_CHOCOLATE_CUPCAKE")
+	}
+
+	// Product name matching against the live catalog (names + admin aliases, typo tolerant)
+	if state.State == "awaiting_product" || state.State == "greeting" || state.State == "main_menu" {
+		match, suggestions := getProductMatcher().Resolve(messageText)
+		if match != nil {
+			handlePostback(userID, fmt.Sprintf("ORDER_PRODUCT_%d", match.ProductID))
 			return
 		}
-		// Bread
-		if strings.Contains(msgLower, "bread") || strings.Contains(msgLower, "ပေါင်မုန့်") {
-			handlePostback(userID, "ORDER_BREAD")
+		if len(suggestions) > 0 {
+			askProductSuggestions(userID, suggestions)
 			return
 		}
 	}
@@ -136,23 +169,18 @@ func handleMessage(userID, messageText string) {
 	// ========== END SMART MATCHING ==========
 
 	// Handle special commands at any time (keep for exact matches)
-	if msgLower == "menu" || msgLower == "catalog" {
+	switch commandWords[msgLower] {
+	case commandMenu:
 		showMenu(userID)
 		return
-	}
-
-	if msgLower == "help" || msgLower == "?" {
+	case commandHelp:
 		showHelp(userID)
 		return
-	}
-
-	if msgLower == "cancel" || msgLower == "reset" {
+	case commandCancel:
 		ResetUserState(userID)
-		SendMessage(userID, "Order cancelled. Type anything to start a new order!")
+		SendMessage(userID, tr(state, "order.cancelled_short"))
 		return
-	}
-
-	if msgLower == "orders" || msgLower == "history" || msgLower == "my orders" {
+	case commandHistory:
 		showOrderHistory(userID)
 		return
 	}
@@ -167,39 +195,43 @@ func handleMessage(userID, messageText string) {
 	case "awaiting_name":
 		// Validate name
 		if len(messageText) < 2 {
-			SendMessage(userID, "Please enter a valid name (at least 2 characters).")
+			SendMessage(userID, tr(state, "name.invalid"))
 			return
 		}
 
 		// User is providing their name
 		state.CustomerName = messageText
 
+		// Delivery preference already given in a free-text order → skip the question
+		if state.PreferredDelivery != "" {
+			preferred := state.PreferredDelivery
+			state.PreferredDelivery = ""
+			if preferred == "pickup" {
+				handlePostback(userID, "PICKUP")
+			} else {
+				handlePostback(userID, "DELIVERY")
+			}
+			return
+		}
+
 		// Show typing indicator for better UX
 		SendTypingIndicator(userID, true)
 
 		// Ask: Pickup or Delivery?
-		state.State = "awaiting_delivery_type"
-		quickReplies := []QuickReply{
-			{ContentType: "text", Title: "🏠 Pickup", Payload: "PICKUP"},
-			{ContentType: "text", Title: "🚚 Delivery", Payload: "DELIVERY"},
-			{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-			{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-		}
-		SendQuickReplies(userID, fmt.Sprintf("Thanks %s! Would you like pickup or delivery?", state.CustomerName), quickReplies)
+		askDeliveryType(userID)
 
 	case "awaiting_address":
 		// Validate address
 		if len(messageText) < 5 {
-			SendMessage(userID, "Please enter a complete delivery address.")
+			SendMessage(userID, tr(state, "address.invalid"))
 			return
 		}
 
 		// User is providing delivery address
 		state.Address = messageText
-		state.State = "confirming"
 
 		SendTypingIndicator(userID, true)
-		showOrderSummary(userID)
+		continueToSummary(userID)
 
 	default:
 		// For any other text input during button/quick-reply steps, guide them back
@@ -211,32 +243,59 @@ func handleMessage(userID, messageText string) {
 			startOrderingFlow(userID)
 		} else if state.State == "awaiting_product" {
 			// Re-show products if they type instead of clicking
-			SendMessage(userID, "Please select a product using the buttons:")
-			showProducts(userID)
+			SendMessage(userID, tr(state, "prompt.select_product"))
+			showProductPage(userID, state.ProductPage)
+		} else if state.State == "awaiting_category" {
+			// Re-show the category picker
+			showCategories(userID)
+		} else if state.State == "awaiting_option" {
+			// Re-show the variant choices
+			askProductOptions(userID)
 		} else if state.State == "awaiting_quantity" {
 			// Re-show quantity options
-			SendMessage(userID, "Please select quantity using the buttons:")
+			SendMessage(userID, tr(state, "prompt.select_quantity"))
 			askQuantity(userID)
+		} else if state.State == "confirming_parsed_cart" {
+			// Re-show the interpreted cart
+			showParsedOrder(userID)
 		} else if state.State == "awaiting_cart_decision" {
 			// Re-show add more or checkout buttons
-			SendMessage(userID, "Please choose an option:")
+			SendMessage(userID, tr(state, "prompt.choose_option"))
 			askAddMore(userID)
 		} else if state.State == "awaiting_delivery_type" {
 			// Re-show delivery type options
-			SendMessage(userID, "Please select pickup or delivery:")
-			quickReplies := []QuickReply{
-				{ContentType: "text", Title: "🏪 Pickup", Payload: "PICKUP"},
-				{ContentType: "text", Title: "🚚 Delivery", Payload: "DELIVERY"},
-				{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-				{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-			}
-			SendQuickReplies(userID, fmt.Sprintf("Thanks %s! Would you like pickup or delivery?", state.CustomerName), quickReplies)
+			SendMessage(userID, tr(state, "prompt.select_delivery"))
+			askDeliveryType(userID)
+		} else if state.State == "awaiting_order_date" || state.State == "awaiting_slot" {
+			// Re-show date/time options
+			askOrderTime(userID)
 		} else if state.State == "confirming" {
 			// Re-show order confirmation
-			SendMessage(userID, "Please confirm your order:")
+			SendMessage(userID, tr(state, "prompt.confirm_order"))
 			showOrderSummary(userID)
 		} else {
-			SendMessage(userID, "Type 'menu' to see products or 'help' for assistance.")
+			SendMessage(userID, tr(state, "prompt.fallback"))
+		}
+	}
+}
+
+// searchCommands are the words that start a product search, in every supported language
+// (Burmese and Thai need no space after the command)
+var searchCommands = []string{"search", "find", "ရှာဖွေ", "ရှာ", "ค้นหา"}
+
+// parseSearchCommand returns the search term of a message starting with a search command
+func parseSearchCommand(messageText string) (string, bool) {
+	text := strings.TrimSpace(messageText)
+	lower := strings.ToLower(text)
+	for _, command := range searchCommands {
+		if !strings.HasPrefix(lower, command) {
+			continue
+		}
+		rest := text[len(command):]
+		if command[0] < utf8.RuneSelf && rest != "" && rest[0] != ' ' && rest[0] != ':' {
+			continue // "finder", "searching"
 		}
+		return strings.TrimSpace(strings.TrimLeft(rest, ": ")), true
 	}
+	return "", false
 }
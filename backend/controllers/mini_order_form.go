@@ -3,6 +3,9 @@ package controllers
 import (
 	"fmt"
 	"log"
+
+	"bakeflow/i18n"
+	"bakeflow/models"
 )
 
 // ShowMiniOrderForm displays an interactive quick-order interface
@@ -32,12 +35,12 @@ func ShowMiniOrderForm(userID string) {
 		buttons := []Button{
 			{
 				Type: "postback",
-				Title: "➕ +1",
+				Title: tr(state, "quick.add_one"),
 				Payload: fmt.Sprintf("QUICK_ADD_%s", prod.Payload),
 			},
 			{
 				Type: "postback",
-				Title: "🛒 View",
+				Title: tr(state, "quick.view"),
 				Payload: fmt.Sprintf("QUICK_VIEW_%s", prod.Payload),
 			},
 		}
@@ -52,17 +55,17 @@ func ShowMiniOrderForm(userID string) {
 
 	// Add action buttons
 	elements = append(elements, Element{
-		Title: "📋 My Cart",
-		Subtitle: "Review items & checkout",
+		Title: tr(state, "quick.my_cart"),
+		Subtitle: tr(state, "quick.my_cart_subtitle"),
 		Buttons: []Button{
 			{
 				Type: "postback",
-				Title: "View Cart",
+				Title: tr(state, "quick.view_cart"),
 				Payload: "QUICK_SHOW_CART",
 			},
 			{
 				Type: "postback",
-				Title: "Proceed",
+				Title: tr(state, "quick.proceed"),
 				Payload: "QUICK_CHECKOUT",
 			},
 		},
@@ -93,7 +96,7 @@ func handleQuickAddProduct(userID, productKey string) {
 
 	prod, exists := productMap[productKey]
 	if !exists {
-		SendMessage(userID, "❌ Product not found")
+		SendMessage(userID, tr(state, "quick.product_not_found"))
 		return
 	}
 
@@ -121,11 +124,10 @@ func handleQuickAddProduct(userID, productKey string) {
 		})
 	}
 
+	trackFunnelStep(userID, models.FunnelCart)
+
 	// Confirm addition
-	msg := fmt.Sprintf("✅ Added %s %s to cart!", prod.Emoji, prod.Name)
-	if state.Language == "my" {
-		msg = fmt.Sprintf("✅ %s %s စတုံအိုးသို့ ထည့်သွင်းပြီး!", prod.Emoji, prod.Name)
-	}
+	msg := tr(state, "quick.added", i18n.Args{"emoji": prod.Emoji, "product": prod.Name})
 
 	SendMessage(userID, msg)
 	showQuickCartSummary(userID)
@@ -136,12 +138,12 @@ func showQuickCartSummary(userID string) {
 	state := GetUserState(userID)
 
 	if len(state.Cart) == 0 {
-		SendMessage(userID, "🛒 Cart is empty. Add items to get started!")
+		SendMessage(userID, tr(state, "quick.cart_empty"))
 		return
 	}
 
 	// Build cart summary - show items with simple prices
-	summary := "🛒 **Your Quick Cart:**\n\n"
+	items := ""
 	total := 0.0
 
 	// Map products to prices (temporary, should come from DB)
@@ -159,40 +161,17 @@ func showQuickCartSummary(userID string) {
 		}
 		subtotal := price * float64(item.Quantity)
 		total += subtotal
-		summary += fmt.Sprintf("%s %s × %d = $%.2f\n", 
+		items += fmt.Sprintf("%s %s × %d = $%.2f\n", 
 			item.ProductEmoji, item.Product, item.Quantity, subtotal)
 	}
 
-	summary += fmt.Sprintf("\n**Total: $%.2f**\n\n", total)
-	summary += "What would you like to do?"
-
-	if state.Language == "my" {
-		summary = "🛒 **သင်၏စတုံအိုး:**\n\n"
-		priceMap := map[string]float64{
-			"Chocolate Cake": 25.00,
-			"Vanilla Cake":   22.00,
-			"Croissant":      8.00,
-			"Cinnamon Roll":  12.00,
-		}
-		for _, item := range state.Cart {
-			price, exists := priceMap[item.Product]
-			if !exists {
-				price = 20.00
-			}
-			subtotal := price * float64(item.Quantity)
-			total += subtotal
-			summary += fmt.Sprintf("%s %s × %d = $%.2f\n", 
-				item.ProductEmoji, item.Product, item.Quantity, subtotal)
-		}
-		summary += fmt.Sprintf("\n**စုစုပေါင်း: $%.2f**\n\n", total)
-		summary += "ဘာလုပ်မည်လဲ?"
-	}
+	summary := tr(state, "quick.summary", i18n.Args{"items": items, "total": fmt.Sprintf("%.2f", total)})
 
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "➕ Add More", Payload: "QUICK_ADD_MORE"},
-		{ContentType: "text", Title: "🛒 Review", Payload: "QUICK_SHOW_CART"},
-		{ContentType: "text", Title: "✅ Checkout", Payload: "QUICK_CHECKOUT"},
-		{ContentType: "text", Title: "❌ Clear", Payload: "QUICK_CLEAR_CART"},
+		{ContentType: "text", Title: tr(state, "button.add_more_plus"), Payload: "QUICK_ADD_MORE"},
+		{ContentType: "text", Title: tr(state, "quick.review"), Payload: "QUICK_SHOW_CART"},
+		{ContentType: "text", Title: tr(state, "quick.checkout"), Payload: "QUICK_CHECKOUT"},
+		{ContentType: "text", Title: tr(state, "quick.clear"), Payload: "QUICK_CLEAR_CART"},
 	}
 
 	SendQuickReplies(userID, summary, quickReplies)
@@ -203,7 +182,7 @@ func handleQuickCheckout(userID string) {
 	state := GetUserState(userID)
 
 	if len(state.Cart) == 0 {
-		SendMessage(userID, "❌ Cart is empty. Please add items first!")
+		SendMessage(userID, tr(state, "quick.checkout_empty"))
 		ShowMiniOrderForm(userID)
 		return
 	}
@@ -211,14 +190,11 @@ func handleQuickCheckout(userID string) {
 	// Move to name entry
 	state.State = "awaiting_name"
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "⬅️ Back", Payload: "QUICK_ADD_MORE"},
-		{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.back"), Payload: "QUICK_ADD_MORE"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 	}
 
-	msg := "📝 What's your name?"
-	if state.Language == "my" {
-		msg = "📝 သင်၏နာမည်ကဘာလဲ?"
-	}
+	msg := tr(state, "quick.ask_name")
 
 	SendQuickReplies(userID, msg, quickReplies)
 }
@@ -228,18 +204,15 @@ func handleQuickClearCart(userID string) {
 	state := GetUserState(userID)
 	state.Cart = []CartItem{}
 
-	msg := "🗑️ Cart cleared!"
-	if state.Language == "my" {
-		msg = "🗑️ စတုံအိုးအလွတ်ပြီး!"
-	}
+	msg := tr(state, "quick.cleared")
 	SendMessage(userID, msg)
 
 	// Show mini form again
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "🛍️ Shop", Payload: "QUICK_SHOP"},
-		{ContentType: "text", Title: "🏠 Home", Payload: "MENU_ORDER"},
+		{ContentType: "text", Title: tr(state, "quick.shop"), Payload: "QUICK_SHOP"},
+		{ContentType: "text", Title: tr(state, "quick.home"), Payload: "MENU_ORDER"},
 	}
-	SendQuickReplies(userID, "What next?", quickReplies)
+	SendQuickReplies(userID, tr(state, "quick.what_next"), quickReplies)
 }
 
 // LogCartState logs the current cart for debugging
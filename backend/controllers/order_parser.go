@@ -0,0 +1,380 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// ParsedOrder is what could be understood from a free-text order like
+// "2 croissants and 1 chocolate cake for pickup"
+type ParsedOrder struct {
+	Items        []CartItem
+	DeliveryType string   // "pickup", "delivery" or "" if not mentioned
+	Unrecognized []string // segments that did not match a product
+	TooMany      []string // segments asking for more than maxParsedQuantity
+	explicitQty  bool     // at least one quantity was typed
+}
+
+// orderSeparators splits a message into one segment per product
+var orderSeparators = regexp.MustCompile(`\s+and\s+|\s+plus\s+|[,&+;\n]|နဲ့|နှင့်|၊|။`)
+
+// Delivery phrases are removed from segments before product matching
+var (
+	pickupPattern   = regexp.MustCompile(`(for\s+)?(pick\s?up|collect(ion)?|take\s?away)|ကိုယ်တိုင်ယူ(မယ်)?`)
+	deliveryPattern = regexp.MustCompile(`(for\s+)?(deliver(y|ed)?|send\s+to\s+me)|ပို့(ပေး)?(ပါ)?`)
+)
+
+// quantityPattern finds "2", "2x", "x2", "၂" (Burmese digits) etc.
+var quantityPattern = regexp.MustCompile(`(?:^|\s)x?([0-9၀-၉]+)\s*(?:x|pcs|pieces|ခု|လုံး)?(?:\s|$)`)
+
+// quantityWords maps spelled-out quantities (English + Burmese) to numbers
+var quantityWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "dozen": 12,
+	"တစ်": 1, "နှစ်": 2, "သုံး": 3, "လေး": 4, "ငါး": 5,
+}
+
+// fillerWords are ignored when reporting segments that matched no product
+var fillerWords = map[string]bool{
+	"please": true, "pls": true, "thanks": true, "thank": true, "you": true, "i": true,
+	"want": true, "would": true, "like": true, "to": true, "order": true, "can": true,
+	"get": true, "me": true, "some": true, "also": true, "hi": true, "hello": true,
+}
+
+// maxParsedQuantity guards against typos like "200 croissants"
+const maxParsedQuantity = 50
+
+// parseOrderMessage extracts product/quantity pairs and a delivery preference from a message
+func parseOrderMessage(message string) ParsedOrder {
+	var parsed ParsedOrder
+	text := strings.ToLower(strings.TrimSpace(message))
+
+	if pickupPattern.MatchString(text) {
+		parsed.DeliveryType = "pickup"
+		text = pickupPattern.ReplaceAllString(text, " ")
+	} else if deliveryPattern.MatchString(text) {
+		parsed.DeliveryType = "delivery"
+		text = deliveryPattern.ReplaceAllString(text, " ")
+	}
+
+	matcher := getProductMatcher()
+	for _, segment := range orderSeparators.Split(text, -1) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		qty, explicit, rest := extractQuantity(segment)
+		match, _ := matcher.Resolve(rest)
+		if match == nil {
+			if !isFillerSegment(rest) {
+				parsed.Unrecognized = append(parsed.Unrecognized, segment)
+			}
+			continue
+		}
+		if explicit {
+			parsed.explicitQty = true
+		}
+		if qty == 0 {
+			parsed.TooMany = append(parsed.TooMany, segment)
+			continue
+		}
+		parsed.addItem(CartItem{
+			Product:      match.Name,
+			ProductEmoji: categoryEmoji(match.CategoryID),
+			Quantity:     qty,
+			ProductID:    match.ProductID,
+			UnitPrice:    match.Price,
+		})
+	}
+	return parsed
+}
+
+// addItem adds an item, merging quantities if the product was mentioned twice
+func (p *ParsedOrder) addItem(item CartItem) {
+	for i := range p.Items {
+		if p.Items[i].Product == item.Product {
+			p.Items[i].Quantity += item.Quantity
+			return
+		}
+	}
+	p.Items = append(p.Items, item)
+}
+
+// IsMultiItemOrder reports whether the message reads like a complete order (several
+// products, one product with a quantity or delivery preference, or too large a quantity)
+func (p ParsedOrder) IsMultiItemOrder() bool {
+	if len(p.Items) >= 2 || len(p.TooMany) > 0 {
+		return true
+	}
+	return len(p.Items) == 1 && (p.explicitQty || p.DeliveryType != "")
+}
+
+// extractQuantity returns the quantity in a segment (default 1, 0 when above
+// maxParsedQuantity) and the remaining text
+func extractQuantity(segment string) (int, bool, string) {
+	if loc := quantityPattern.FindStringSubmatchIndex(segment); loc != nil {
+		if n, err := strconv.Atoi(burmeseToASCIIDigits(segment[loc[2]:loc[3]])); err == nil && n > 0 {
+			rest := segment[:loc[0]] + " " + segment[loc[1]:]
+			if n > maxParsedQuantity {
+				return 0, true, strings.TrimSpace(rest)
+			}
+			return n, true, strings.TrimSpace(rest)
+		}
+	}
+
+	words := strings.Fields(segment)
+	for i, w := range words {
+		if n, ok := quantityWords[w]; ok {
+			rest := append(append([]string{}, words[:i]...), words[i+1:]...)
+			// "a"/"an" are articles, not a typed quantity
+			return n, w != "a" && w != "an", strings.Join(rest, " ")
+		}
+	}
+	return 1, false, segment
+}
+
+// isFillerSegment reports whether a segment only contains polite filler ("please", "i want")
+func isFillerSegment(segment string) bool {
+	for _, w := range strings.Fields(segment) {
+		if !fillerWords[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// burmeseToASCIIDigits converts Myanmar digits (၀-၉) to 0-9
+func burmeseToASCIIDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '၀' && r <= '၉' {
+			return '0' + (r - '၀')
+		}
+		return r
+	}, s)
+}
+
+// confirmParsedOrder shows the interpreted cart and lets the customer edit it before checkout
+func confirmParsedOrder(userID string, parsed ParsedOrder) {
+	if !checkBusinessHours(userID) {
+		return
+	}
+
+	state := GetUserState(userID)
+	state.PendingCart = parsed.Items
+	state.PreferredDelivery = parsed.DeliveryType
+	showParsedOrder(userID)
+
+	if len(parsed.Unrecognized) > 0 {
+		SendMessage(userID, tr(state, "parsed.unrecognized", i18n.Args{"items": strings.Join(parsed.Unrecognized, ", ")}))
+	}
+	if len(parsed.TooMany) > 0 {
+		SendMessage(userID, tr(state, "parsed.too_many", i18n.Args{"max": maxParsedQuantity, "items": strings.Join(parsed.TooMany, ", ")}))
+	}
+}
+
+// showParsedOrder sends the pending cart with confirm/edit quick replies
+func showParsedOrder(userID string) {
+	state := GetUserState(userID)
+	state.State = "confirming_parsed_cart"
+
+	if len(state.PendingCart) == 0 {
+		state.State = "awaiting_product"
+		showCart(userID)
+		return
+	}
+
+	items := ""
+	for _, item := range state.PendingCart {
+		items += fmt.Sprintf("• %d× %s %s\n", item.Quantity, item.ProductEmoji, item.Product)
+	}
+	delivery := ""
+	if state.PreferredDelivery != "" {
+		delivery = "\n" + tr(state, "button."+state.PreferredDelivery)
+	}
+	msg := tr(state, "parsed.summary", i18n.Args{"items": items, "delivery": delivery})
+
+	quickReplies := []QuickReply{
+		{ContentType: "text", Title: tr(state, "button.looks_good"), Payload: "PARSED_CONFIRM"},
+	}
+	// Messenger allows 13 quick replies; keep room for the fixed buttons
+	for i, item := range state.PendingCart {
+		if i == 9 {
+			break
+		}
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       truncateTitle(tr(state, "button.edit_item", i18n.Args{"product": item.Product}), 20),
+			Payload:     fmt.Sprintf("PARSED_EDIT_%d", i),
+		})
+	}
+	quickReplies = append(quickReplies,
+		QuickReply{ContentType: "text", Title: tr(state, "button.add_more_plus"), Payload: "PARSED_ADD_MORE"},
+		QuickReply{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
+	)
+	SendQuickReplies(userID, msg, quickReplies)
+}
+
+// editParsedItem asks for a new quantity (or removal) for one pending item
+func editParsedItem(userID string, index int) {
+	state := GetUserState(userID)
+	if index < 0 || index >= len(state.PendingCart) {
+		showParsedOrder(userID)
+		return
+	}
+	item := state.PendingCart[index]
+
+	quickReplies := []QuickReply{}
+	for n := 1; n <= 5; n++ {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       strconv.Itoa(n),
+			Payload:     fmt.Sprintf("PARSED_QTY_%d_%d", index, n),
+		})
+	}
+	quickReplies = append(quickReplies,
+		QuickReply{ContentType: "text", Title: tr(state, "button.remove"), Payload: fmt.Sprintf("PARSED_REMOVE_%d", index)},
+		QuickReply{ContentType: "text", Title: tr(state, "button.back"), Payload: "PARSED_REVIEW"},
+	)
+
+	SendQuickReplies(userID, tr(state, "quantity.ask", i18n.Args{"emoji": item.ProductEmoji, "product": item.Product}), quickReplies)
+}
+
+// handleParsedOrderPostback handles PARSED_* edit payloads; returns false if not one of them
+func handleParsedOrderPostback(userID, payload string) bool {
+	state := GetUserState(userID)
+
+	switch {
+	case strings.HasPrefix(payload, "PARSED_EDIT_"):
+		index, err := strconv.Atoi(strings.TrimPrefix(payload, "PARSED_EDIT_"))
+		if err != nil {
+			return false
+		}
+		editParsedItem(userID, index)
+		return true
+
+	case strings.HasPrefix(payload, "PARSED_QTY_"):
+		parts := strings.Split(strings.TrimPrefix(payload, "PARSED_QTY_"), "_")
+		if len(parts) != 2 {
+			return false
+		}
+		index, err1 := strconv.Atoi(parts[0])
+		qty, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if index >= 0 && index < len(state.PendingCart) && qty > 0 {
+			state.PendingCart[index].Quantity = qty
+		}
+		showParsedOrder(userID)
+		return true
+
+	case strings.HasPrefix(payload, "PARSED_REMOVE_"):
+		index, err := strconv.Atoi(strings.TrimPrefix(payload, "PARSED_REMOVE_"))
+		if err != nil {
+			return false
+		}
+		if index >= 0 && index < len(state.PendingCart) {
+			state.PendingCart = append(state.PendingCart[:index], state.PendingCart[index+1:]...)
+		}
+		showParsedOrder(userID)
+		return true
+	}
+	return false
+}
+
+// acceptParsedOrder moves the pending items into the cart and continues checkout
+func acceptParsedOrder(userID string) {
+	state := GetUserState(userID)
+	if len(state.PendingCart) == 0 && len(state.Cart) == 0 {
+		showProducts(userID)
+		return
+	}
+	movePendingToCart(userID)
+	trackFunnelStep(userID, models.FunnelCart)
+	if customizeNextQueuedItem(userID) {
+		// Sizes, flavours etc. are asked first; checkout continues from the cart
+		return
+	}
+	if len(state.Cart) == 0 {
+		// Everything parsed became unavailable
+		showProducts(userID)
+		return
+	}
+
+	// Skip straight to the time step when we already know who and how
+	// (the cart changed, so any earlier slot choice is asked again)
+	if state.CustomerName != "" && state.PreferredDelivery == "" && state.DeliveryType != "" &&
+		(state.DeliveryType == "pickup" || state.Address != "") {
+		askOrderTime(userID)
+		return
+	}
+	if state.CustomerName != "" && state.PreferredDelivery != "" {
+		preferred := state.PreferredDelivery
+		state.PreferredDelivery = ""
+		if preferred == "pickup" {
+			handlePostback(userID, "PICKUP")
+		} else {
+			handlePostback(userID, "DELIVERY")
+		}
+		return
+	}
+	askName(userID)
+}
+
+// acceptParsedOrderAndBrowse keeps the parsed items and returns to the product carousel
+func acceptParsedOrderAndBrowse(userID string) {
+	movePendingToCart(userID)
+	trackFunnelStep(userID, models.FunnelCart)
+	if customizeNextQueuedItem(userID) {
+		return
+	}
+	showProducts(userID)
+}
+
+// movePendingToCart merges the parsed items into the cart, leaving out products that can no
+// longer be ordered. Items of products with options are queued in OptionQueue instead, to be
+// customized before they are added.
+func movePendingToCart(userID string) {
+	state := GetUserState(userID)
+	for _, item := range state.PendingCart {
+		if item.ProductID != 0 && configs.DB != nil {
+			p := orderableProduct(userID, item)
+			if p == nil {
+				continue
+			}
+			item.UnitPrice = p.Price
+		}
+		if hasOptionGroups(item.ProductID) {
+			state.OptionQueue = append(state.OptionQueue, item)
+			continue
+		}
+		merged := false
+		for i := range state.Cart {
+			if samePlainCartItem(state.Cart[i], item) {
+				state.Cart[i].Quantity += item.Quantity
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			state.Cart = append(state.Cart, item)
+		}
+	}
+	state.PendingCart = nil
+}
+
+// samePlainCartItem reports whether two cart lines are the same product without options
+// or inscription, so their quantities can be added up
+func samePlainCartItem(a, b CartItem) bool {
+	if len(a.Options) > 0 || len(b.Options) > 0 || a.Inscription != "" || b.Inscription != "" {
+		return false
+	}
+	return a.ProductID == b.ProductID && a.Product == b.Product
+}
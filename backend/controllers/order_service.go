@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"bakeflow/configs"
+	"bakeflow/i18n"
 	"bakeflow/models"
 )
 
@@ -36,16 +38,31 @@ func calculateDeliveryFee(deliveryType, address string) float64 {
 	return 4.00
 }
 
+// unitPrice is the price of one cart item: the catalog price with option deltas, or the
+// legacy ProductCatalog price for items added from the hardcoded menu. Items with neither
+// are an error rather than free.
+func unitPrice(item CartItem) (float64, error) {
+	if item.ProductID != 0 || item.UnitPrice > 0 {
+		return item.UnitPrice, nil
+	}
+	if product, exists := ProductCatalog[item.Product]; exists {
+		priceStr := strings.ReplaceAll(product.Price, "$", "")
+		if price, err := strconv.ParseFloat(priceStr, 64); err == nil {
+			return price, nil
+		}
+	}
+	return 0, fmt.Errorf("no price for %q: not linked to the catalog", item.Product)
+}
+
 // calculateOrderTotals calculates subtotal, delivery fee, and total
-func calculateOrderTotals(cart []CartItem, deliveryType, address string) (subtotal, deliveryFee, total float64) {
+func calculateOrderTotals(cart []CartItem, deliveryType, address string) (subtotal, deliveryFee, total float64, err error) {
 	// Calculate subtotal from cart
 	for _, item := range cart {
-		if product, exists := ProductCatalog[item.Product]; exists {
-			priceStr := strings.ReplaceAll(product.Price, "$", "")
-			if price, err := strconv.ParseFloat(priceStr, 64); err == nil {
-				subtotal += price * float64(item.Quantity)
-			}
+		price, err := unitPrice(item)
+		if err != nil {
+			return 0, 0, 0, err
 		}
+		subtotal += price * float64(item.Quantity)
 	}
 
 	// Calculate delivery fee
@@ -54,31 +71,7 @@ func calculateOrderTotals(cart []CartItem, deliveryType, address string) (subtot
 	// Total = subtotal + delivery fee
 	total = subtotal + deliveryFee
 
-	return subtotal, deliveryFee, total
-}
-
-// isBusinessOpen checks if current time is within business hours (8 AM - 8 PM)
-func isBusinessOpen() bool {
-	// TEMP: Always open for testing. Original logic (8AM-8PM) commented below.
-	// now := time.Now()
-	// hour := now.Hour()
-	// return hour >= 8 && hour < 20
-	return true
-}
-
-// getNextOpeningTime returns a formatted string of when the business opens next
-func getNextOpeningTime() string {
-	now := time.Now()
-	hour := now.Hour()
-
-	if hour < 8 {
-		// Opens today at 8 AM
-		return "8:00 AM today"
-	} else {
-		// Opens tomorrow at 8 AM
-		tomorrow := now.Add(24 * time.Hour)
-		return fmt.Sprintf("8:00 AM on %s", tomorrow.Format("Monday, Jan 2"))
-	}
+	return subtotal, deliveryFee, total, nil
 }
 
 // confirmOrder saves the order to the database and sends confirmation
@@ -92,7 +85,13 @@ func confirmOrder(userID string) {
 	}
 
 	// Calculate totals (subtotal, delivery fee, total amount)
-	subtotal, deliveryFee, totalAmount := calculateOrderTotals(state.Cart, state.DeliveryType, state.Address)
+	subtotal, deliveryFee, totalAmount, err := calculateOrderTotals(state.Cart, state.DeliveryType, state.Address)
+	if err != nil {
+		log.Printf("❌ Error pricing order for %s: %v", userID, err)
+		SendMessage(userID, tr(state, "order.error"))
+		ResetUserState(userID)
+		return
+	}
 
 	// Create order in database (include Messenger sender ID for notifications)
 	order := models.Order{
@@ -105,92 +104,86 @@ func confirmOrder(userID string) {
 		DeliveryFee:  deliveryFee,
 		TotalAmount:  totalAmount,
 		SenderID:     userID,
+		Language:     state.Language,
+	}
+	if !state.SlotStart.IsZero() {
+		slotStart, slotEnd := state.SlotStart, state.SlotEnd
+		order.SlotStart, order.SlotEnd = &slotStart, &slotEnd
 	}
 
-	// Convert cart items to order items
+	// Convert cart items to order items (all priced, checked above)
 	var orderItems []models.OrderItem
 	for _, item := range state.Cart {
-		// Get price from ProductCatalog
-		price := 0.00
-		if product, exists := ProductCatalog[item.Product]; exists {
-			// Parse price string (e.g., "$25.00" → 25.00)
-			priceStr := strings.ReplaceAll(product.Price, "$", "")
-			if parsedPrice, err := strconv.ParseFloat(priceStr, 64); err == nil {
-				price = parsedPrice
-			}
-		}
-
+		price, _ := unitPrice(item)
 		orderItems = append(orderItems, models.OrderItem{
-			Product:  item.Product,
-			Quantity: item.Quantity,
-			Price:    price,
+			Product:     item.Product,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			Price:       price,
+			Options:     item.Options,
+			Inscription: item.Inscription,
 		})
 	}
 
-	err := models.CreateOrder(&order, orderItems)
+	err = models.CreateOrder(&order, orderItems, time.Now().In(shopLocation()))
+	if err == models.ErrSlotFull {
+		// Someone else took the last place in this slot; let the customer pick again
+		log.Printf("⚠️ Slot %s full for %s", state.SlotStart.Format(time.RFC3339), userID)
+		SendMessage(userID, tr(state, "slot.full"))
+		askOrderTime(userID)
+		return
+	}
+	if err == models.ErrOptionUnavailable {
+		// A chosen size/flavour sold out while the customer was checking out
+		for _, item := range dropUnavailableItems(state) {
+			SendMessage(userID, tr(state, "option.sold_out_checkout", i18n.Args{"product": cartItemLabel(state, item)}))
+		}
+		if len(state.Cart) == 0 {
+			showProducts(userID)
+			return
+		}
+		askAddMore(userID)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Error creating order: %v", err)
-		SendMessage(userID, "😞 Sorry, there was an error placing your order. Please try again later.")
+		SendMessage(userID, tr(state, "order.error"))
 		ResetUserState(userID)
 		return
 	}
 
 	deliveryIcon := "🏠"
-	estimatedTime := "Ready in 15-20 minutes"
+	estimatedTime := tr(state, "order.eta_pickup")
 	if state.DeliveryType == "delivery" {
 		deliveryIcon = "🚚"
-		estimatedTime = "Delivered in 30-45 minutes"
+		estimatedTime = tr(state, "order.eta_delivery")
+	}
+	if order.SlotStart != nil {
+		estimatedTime = tr(state, "order.eta_scheduled", i18n.Args{"slot": orderTimeText(state)})
 	}
 
 	// Build cart display with prices for confirmation
 	cartDisplay := ""
 	for _, item := range state.Cart {
-		itemPrice := 0.00
-		if product, exists := ProductCatalog[item.Product]; exists {
-			priceStr := strings.ReplaceAll(product.Price, "$", "")
-			if price, err := strconv.ParseFloat(priceStr, 64); err == nil {
-				itemPrice = price * float64(item.Quantity)
-			}
-		}
-		cartDisplay += fmt.Sprintf("• %d× %s %s - $%.2f\n", item.Quantity, item.ProductEmoji, item.Product, itemPrice)
+		price, _ := unitPrice(item)
+		cartDisplay += fmt.Sprintf("• %d× %s %s - $%.2f\n", item.Quantity, item.ProductEmoji, cartItemLabel(state, item), price*float64(item.Quantity))
 	}
 
-	// Build pricing breakdown
-	pricingBreakdown := fmt.Sprintf(
-		"\n💰 **Pricing:**\n"+
-			"Subtotal: $%.2f\n"+
-			"Delivery Fee: $%.2f\n"+
-			"━━━━━━━━━━━━\n"+
-			"**Total: $%.2f**",
-		order.Subtotal,
-		order.DeliveryFee,
-		order.TotalAmount,
-	)
-
 	// Send rich confirmation
-	confirmation := fmt.Sprintf(
-		"✅ **Order Confirmed!**\n\n"+
-			"Order #%d\n\n"+
-			"🛒 **Your Order:**\n"+
-			"%s"+
-			"%s\n\n"+
-			"👤 %s\n"+
-			"%s %s\n"+
-			"📍 %s\n"+
-			"📊 Status: %s\n\n"+
-			"⏱ %s\n\n"+
-			"Thank you for choosing BakeFlow! 🎉\n\n"+
-			"Type 'menu' to order more, or 'orders' to view history.",
-		order.ID,
-		cartDisplay,
-		pricingBreakdown,
-		state.CustomerName,
-		deliveryIcon, strings.Title(state.DeliveryType),
-		order.Address,
-		strings.Title(order.Status),
-		estimatedTime,
-	)
+	confirmation := tr(state, "order.confirmed", i18n.Args{
+		"id":            order.ID,
+		"items":         cartDisplay,
+		"pricing":       "\n" + pricingBreakdown(state, order.Subtotal, order.DeliveryFee, order.TotalAmount),
+		"name":          state.CustomerName,
+		"delivery_icon": deliveryIcon,
+		"delivery_type": tr(state, "delivery_type."+state.DeliveryType),
+		"address":       order.Address,
+		"status":        tr(state, "status."+order.Status),
+		"eta":           estimatedTime,
+	})
 	SendMessage(userID, confirmation)
+	trackFunnelStep(userID, models.FunnelOrdered)
+	trackCartReminderConversion(userID, order.ID)
 
 	// Reset state for next order
 	ResetUserState(userID)
@@ -198,16 +191,17 @@ func confirmOrder(userID string) {
 
 // handleReorder pre-fills cart with items from previous order
 func handleReorder(userID string, orderID int) {
+	state := GetUserState(userID)
+
 	// Get the order
 	order, err := models.GetOrderByID(orderID)
 	if err != nil {
 		log.Printf("❌ Error fetching order for reorder: %v", err)
-		SendMessage(userID, "😞 Sorry, couldn't load that order. Please try again.")
+		SendMessage(userID, tr(state, "reorder.error"))
 		return
 	}
 
 	// Reset state and pre-fill cart
-	state := GetUserState(userID)
 	state.Cart = []CartItem{}
 
 	// Convert order items to cart items
@@ -217,12 +211,34 @@ func handleReorder(userID string, orderID int) {
 			emoji = product.Emoji
 		}
 
-		state.Cart = append(state.Cart, CartItem{
+		// Keep the price paid last time for variants and products no longer in the catalog
+		cartItem := CartItem{
 			Product:      item.Product,
 			ProductEmoji: emoji,
 			Quantity:     item.Quantity,
-		})
+			ProductID:    item.ProductID,
+			UnitPrice:    item.Price,
+			Options:      item.Options,
+			Inscription:  item.Inscription,
+		}
+		if item.ProductID != 0 && configs.DB != nil {
+			product, err := models.GetProductByID(configs.DB, item.ProductID)
+			if err == nil && product == nil {
+				// Deleted from the catalog since the earlier order
+				SendMessage(userID, tr(state, "reorder.unavailable", i18n.Args{"product": item.Product}))
+				continue
+			}
+			if err == nil && len(item.Options) == 0 {
+				cartItem.UnitPrice = product.Price
+			}
+		}
+		state.Cart = append(state.Cart, cartItem)
 	}
+	if len(state.Cart) == 0 {
+		showProducts(userID)
+		return
+	}
+	trackFunnelStep(userID, models.FunnelCart)
 
 	// Calculate total items
 	totalItems := 0
@@ -231,7 +247,7 @@ func handleReorder(userID string, orderID int) {
 	}
 
 	// Send confirmation message
-	SendMessage(userID, fmt.Sprintf("🔄 **Reordering from Order #%d**\n\n✅ Added %d items to your cart!", order.ID, totalItems))
+	SendMessage(userID, tr(state, "reorder.added", i18n.Args{"id": order.ID, "count": totalItems}))
 
 	// Show cart
 	showCart(userID)
@@ -244,26 +260,30 @@ func handleReorder(userID string, orderID int) {
 // askForRating sends rating request with star buttons
 func askForRating(userID string, orderID int) {
 	state := GetUserState(userID)
+
+	// Only the customer who placed the order can rate it, once
+	order, err := models.GetOrderByID(orderID)
+	if err != nil || order.SenderID != userID {
+		SendMessage(userID, tr(state, "error.generic"))
+		return
+	}
+	if order.RatingID != nil {
+		SendMessage(userID, tr(state, "rating.already_rated", i18n.Args{"id": orderID}))
+		return
+	}
+
 	state.State = "awaiting_rating"
 	state.CurrentProduct = strconv.Itoa(orderID) // Temporarily store orderID
 
-	ratingMsg := "⭐ **How was your order?**\n\n" +
-		"We'd love to hear your feedback!\n" +
-		"Please rate your experience:"
-
-	if state.Language == "my" {
-		ratingMsg = "⭐ **အော်ဒါက ဘယ်လိုလဲ?**\n\n" +
-			"သင့်ရဲ့ အကြံပြုချက်ကို ကြားလိုပါတယ်!\n" +
-			"သင့်အတွေ့အကြုံကို အဆင့်သတ်မှတ်ပေးပါ:"
-	}
+	ratingMsg := tr(state, "rating.ask")
 
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "⭐ 1 Star - Poor", Payload: "RATING_1"},
-		{ContentType: "text", Title: "⭐⭐ 2 Stars", Payload: "RATING_2"},
-		{ContentType: "text", Title: "⭐⭐⭐ 3 Stars", Payload: "RATING_3"},
-		{ContentType: "text", Title: "⭐⭐⭐⭐ 4 Stars", Payload: "RATING_4"},
-		{ContentType: "text", Title: "⭐⭐⭐⭐⭐ 5 Stars - Excellent!", Payload: "RATING_5"},
-		{ContentType: "text", Title: "Skip", Payload: "SKIP_RATING"},
+		{ContentType: "text", Title: tr(state, "rating.button_1"), Payload: "RATING_1"},
+		{ContentType: "text", Title: tr(state, "rating.button_2"), Payload: "RATING_2"},
+		{ContentType: "text", Title: tr(state, "rating.button_3"), Payload: "RATING_3"},
+		{ContentType: "text", Title: tr(state, "rating.button_4"), Payload: "RATING_4"},
+		{ContentType: "text", Title: tr(state, "rating.button_5"), Payload: "RATING_5"},
+		{ContentType: "text", Title: tr(state, "button.skip"), Payload: "SKIP_RATING"},
 	}
 
 	SendQuickReplies(userID, ratingMsg, quickReplies)
@@ -276,7 +296,7 @@ func handleRating(userID string, stars int) {
 	// Get orderID from temporary storage
 	orderID, err := strconv.Atoi(state.CurrentProduct)
 	if err != nil {
-		SendMessage(userID, "😞 Sorry, something went wrong. Please try again.")
+		SendMessage(userID, tr(state, "error.generic"))
 		ResetUserState(userID)
 		return
 	}
@@ -290,71 +310,69 @@ func handleRating(userID string, stars int) {
 	}
 
 	err = models.CreateRating(&rating)
+	if err == models.ErrAlreadyRated {
+		SendMessage(userID, tr(state, "rating.already_rated", i18n.Args{"id": orderID}))
+		ResetUserState(userID)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Error saving rating: %v", err)
-		SendMessage(userID, "😞 Sorry, couldn't save your rating. Please try again later.")
+		SendMessage(userID, tr(state, "rating.save_error"))
 		return
 	}
 
 	// Send thank you message
-	thankYouMsg := ""
+	thankYouMsg := tr(state, "rating.thanks_low")
 	if stars >= 4 {
-		thankYouMsg = "🎉 **Thank you so much!**\n\n" +
-			"We're thrilled you loved your order! ⭐⭐⭐⭐⭐\n\n" +
-			"Your feedback means the world to us. Looking forward to serving you again! 🍰"
-
-		if state.Language == "my" {
-			thankYouMsg = "🎉 **အရမ်းကျေးဇူးတင်ပါတယ်!**\n\n" +
-				"သင့် အော်ဒါကို နှစ်သက်တာ သိရတာ အရမ်းဝမ်းသာပါတယ်! ⭐⭐⭐⭐⭐\n\n" +
-				"သင့်ရဲ့ အကြံပြုချက်က ကျွန်ုပ်တို့အတွက် အရမ်းအရေးကြီးပါတယ်။ နောက်တစ်ခါ ထပ်ဆောင်ရွက်ပေးဖို့ မျှော်လင့်နေပါတယ်! 🍰"
-		}
+		thankYouMsg = tr(state, "rating.thanks_high")
 	} else if stars == 3 {
-		thankYouMsg = "😊 **Thank you for your feedback!**\n\n" +
-			"We appreciate your honesty. We're always working to improve!\n\n" +
-			"Type 'menu' to order again! 🍰"
-
-		if state.Language == "my" {
-			thankYouMsg = "😊 **သင့်အကြံပြုချက်အတွက် ကျေးဇူးတင်ပါတယ်!**\n\n" +
-				"သင့်ရိုးသားမှုကို တန်ဖိုးထားပါတယ်။ ကျွန်ုပ်တို့ အမြဲတမ်း တိုးတက်အောင် လုပ်ဆောင်နေပါတယ်!\n\n" +
-				"'မီနူး' လို့ရိုက်ပြီး ထပ်မှာလိုက်ပါ! 🍰"
-		}
-	} else {
-		thankYouMsg = "😔 **We're sorry you weren't satisfied.**\n\n" +
-			"Your feedback is important to us. We'll do better next time!\n\n" +
-			"Please give us another chance. Type 'menu' to order! 🍰"
-
-		if state.Language == "my" {
-			thankYouMsg = "😔 **သင် မကျေနပ်မှုအတွက် တောင်းပန်ပါတယ်။**\n\n" +
-				"သင့်အကြံပြုချက်က ကျွန်ုပ်တို့အတွက် အရေးကြီးပါတယ်။ နောက်တစ်ခါ ပိုကောင်းအောင် လုပ်ပါမယ်!\n\n" +
-				"နောက်တစ်ခါ အခွင့်အရေးပေးပါ။ 'မီနူး' လို့ရိုက်ပြီး မှာလိုက်ပါ! 🍰"
-		}
+		thankYouMsg = tr(state, "rating.thanks_mid")
 	}
 
 	SendMessage(userID, thankYouMsg)
-	ResetUserState(userID)
+
+	// Ask for an optional comment about the rating
+	state.State = "awaiting_rating_comment"
+	state.RatingID = rating.ID
+	state.RatingAskedAt = time.Now()
+	SendQuickReplies(userID, tr(state, "rating.comment_ask"), []QuickReply{
+		{ContentType: "text", Title: tr(state, "button.skip"), Payload: "SKIP_RATING_COMMENT"},
+	})
 }
 
-// checkBusinessHours checks if ordering is allowed (business hours check)
-func checkBusinessHours(userID string) bool {
-	if isBusinessOpen() {
-		return true
+// isRatingComment reports whether a message typed while a rating comment is awaited is the
+// comment: it must come soon after the question and not be a command or an order
+func isRatingComment(state *UserState, messageText string) bool {
+	if time.Since(state.RatingAskedAt) > ratingCommentWindow {
+		return false
+	}
+	if isCommandMessage(messageText) {
+		return false
 	}
+	parsed := parseOrderMessage(messageText)
+	return !parsed.IsMultiItemOrder() || len(parsed.Unrecognized) > 0
+}
 
+// handleRatingComment saves the comment typed after a rating
+func handleRatingComment(userID, comment string) {
 	state := GetUserState(userID)
-	closedMsg := "🔒 **We're Currently Closed**\n\n" +
-		"Business Hours: 8:00 AM - 8:00 PM\n\n" +
-		fmt.Sprintf("We'll be open again at %s.\n\n", getNextOpeningTime()) +
-		"You can browse our menu, but ordering is temporarily unavailable.\n\n" +
-		"See you soon! 🍰"
-
-	if state.Language == "my" {
-		closedMsg = "🔒 **ကျွန်ုပ်တို့ လောလောဆယ် ပိတ်နေပါတယ်**\n\n" +
-			"စီးပွားရေး အချိန်: နံနက် 8:00 - ည 8:00\n\n" +
-			fmt.Sprintf("ကျွန်ုပ်တို့ %s မှာ ပြန်ဖွင့်ပါမယ်။\n\n", getNextOpeningTime()) +
-			"မီနူးကို ကြည့်နိုင်ပေမယ့် မှာယူခြင်းကို ယာယီ မရနိုင်ပါဘူး။\n\n" +
-			"မကြာခင် တွေ့ရအောင်! 🍰"
-	}
-
-	SendMessage(userID, closedMsg)
-	return false
+	comment = strings.TrimSpace(comment)
+	if runes := []rune(comment); len(runes) > maxRatingCommentLength {
+		comment = string(runes[:maxRatingCommentLength])
+	}
+
+	if err := models.SetRatingComment(state.RatingID, comment); err != nil {
+		log.Printf("❌ Error saving rating comment: %v", err)
+		SendMessage(userID, tr(state, "rating.save_error"))
+		return
+	}
+	SendMessage(userID, tr(state, "rating.comment_thanks"))
+	ResetUserState(userID)
+}
+
+// skipRatingComment ends the rating without a comment
+func skipRatingComment(userID string) {
+	state := GetUserState(userID)
+	SendMessage(userID, tr(state, "rating.comment_skipped"))
+	ResetUserState(userID)
 }
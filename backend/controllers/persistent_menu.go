@@ -8,6 +8,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+
+	"bakeflow/i18n"
 )
 
 // SetupPersistentMenu creates a persistent menu (hamburger menu) in Messenger
@@ -18,57 +20,34 @@ func SetupPersistentMenu() error {
 		return fmt.Errorf("PAGE_ACCESS_TOKEN not set")
 	}
 
-	// Define menu for English users (Max 3 items per Facebook's limit)
-	menuEN := map[string]interface{}{
-		"locale": "default",
-		"composer_input_disabled": false,
-		"call_to_actions": []map[string]interface{}{
-			{
-				"type":    "postback",
-				"title":   "🛒 Order Now",
-				"payload": "MENU_ORDER",
-			},
-			{
-				"type":    "postback",
-				"title":   "📋 Order History",
-				"payload": "MENU_ORDER_HISTORY",
-			},
-			{
-				"type":    "postback",
-				"title":   "ℹ️ About & Help",
-				"payload": "MENU_ABOUT",
-			},
-		},
-	}
-
-	// Define menu for Myanmar/Burmese users (Max 3 items per Facebook's limit)
-	menuMY := map[string]interface{}{
-		"locale": "my_MM",
-		"composer_input_disabled": false,
-		"call_to_actions": []map[string]interface{}{
-			{
-				"type":    "postback",
-				"title":   "🛒 အော်ဒါမှာမယ်",
-				"payload": "MENU_ORDER",
-			},
-			{
-				"type":    "postback",
-				"title":   "📋 မှာထားမှုများ",
-				"payload": "MENU_ORDER_HISTORY",
+	// One menu per catalog language (Max 3 items per Facebook's limit)
+	var menus []map[string]interface{}
+	for _, lang := range i18n.Languages() {
+		menus = append(menus, map[string]interface{}{
+			"locale":                  i18n.T(lang, "meta.messenger_locale"),
+			"composer_input_disabled": false,
+			"call_to_actions": []map[string]interface{}{
+				{
+					"type":    "postback",
+					"title":   i18n.T(lang, "persistent_menu.order"),
+					"payload": "MENU_ORDER",
+				},
+				{
+					"type":    "postback",
+					"title":   i18n.T(lang, "persistent_menu.history"),
+					"payload": "MENU_ORDER_HISTORY",
+				},
+				{
+					"type":    "postback",
+					"title":   i18n.T(lang, "persistent_menu.about"),
+					"payload": "MENU_ABOUT",
+				},
 			},
-			{
-				"type":    "postback",
-				"title":   "ℹ️ အကြောင်းနှင့်အကူအညီ",
-				"payload": "MENU_ABOUT",
-			},
-		},
+		})
 	}
 
 	payload := map[string]interface{}{
-		"persistent_menu": []map[string]interface{}{
-			menuEN,
-			menuMY,
-		},
+		"persistent_menu": menus,
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
@@ -129,17 +108,16 @@ func SetupGreetingText() error {
 		return fmt.Errorf("PAGE_ACCESS_TOKEN not set")
 	}
 
+	var greetings []map[string]interface{}
+	for _, lang := range i18n.Languages() {
+		greetings = append(greetings, map[string]interface{}{
+			"locale": i18n.T(lang, "meta.messenger_locale"),
+			"text":   i18n.T(lang, "greeting.text"),
+		})
+	}
+
 	payload := map[string]interface{}{
-		"greeting": []map[string]interface{}{
-			{
-				"locale": "default",
-				"text":   "Hi! 👋 Welcome to BakeFlow! Click 'Get Started' to begin ordering delicious cakes and pastries! 🍰",
-			},
-			{
-				"locale": "my_MM",
-				"text":   "မင်္ဂလာပါ! 👋 BakeFlow မှ ကြိုဆိုပါတယ်! စတင်ရန် 'Get Started' ကို နှိပ်ပြီး အရသာရှိတဲ့ ကိတ်မုန့်တွေ မှာယူပါ! 🍰",
-			},
-		},
+		"greeting": greetings,
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
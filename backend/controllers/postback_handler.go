@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"bakeflow/configs"
+	"bakeflow/i18n"
 	"bakeflow/models"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // handlePostback processes button clicks (postback payloads)
@@ -12,19 +14,6 @@ func handlePostback(userID, payload string) {
 	state := GetUserState(userID)
 
 	switch payload {
-	// Language selection
-	case "LANG_EN":
-		state.Language = "en"
-		state.State = "greeting"
-		SendMessage(userID, "✅ English selected!")
-		startOrderingFlow(userID)
-
-	case "LANG_MY":
-		state.Language = "my"
-		state.State = "greeting"
-		SendMessage(userID, "✅ မြန်မာဘာသာ ရွေးချယ်ပြီးပါပြီ!")
-		startOrderingFlow(userID)
-
 	// Persistent Menu Actions (from ☰ menu)
 	case "MENU_ORDER":
 		startOrderingFlow(userID)
@@ -39,7 +28,7 @@ func handlePostback(userID, payload string) {
 		showLanguageSelection(userID)
 
 	// Main Menu Actions (from card buttons)
-	case "MENU_ORDER_PRODUCTS":
+	case "MENU_ORDER_PRODUCTS", "SHOW_CATEGORIES":
 		showProducts(userID)
 
 	case "MENU_HELP":
@@ -51,7 +40,7 @@ func handlePostback(userID, payload string) {
 	// Product selection
 	case "ORDER_CHOCOLATE_CAKE":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Chocolate Cake"].Name
@@ -62,7 +51,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_VANILLA_CAKE":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Vanilla Cake"].Name
@@ -73,7 +62,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_RED_VELVET":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Red Velvet"].Name
@@ -84,7 +73,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_CROISSANT":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Croissant"].Name
@@ -95,7 +84,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_CINNAMON_ROLL":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Cinnamon Roll"].Name
@@ -106,7 +95,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_CUPCAKE":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Chocolate Cupcake"].Name
@@ -117,7 +106,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_COFFEE":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Coffee"].Name
@@ -128,7 +117,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_BREAD":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Bread"].Name
@@ -139,7 +128,7 @@ func handlePostback(userID, payload string) {
 
 	case "ORDER_CHOCOLATE_CUPCAKE":
 		if state.State != "awaiting_product" {
-			SendMessage(userID, "⚠️ Please complete your current step first, or type 'cancel' to start over.")
+			SendMessage(userID, tr(state, "error.complete_step"))
 			return
 		}
 		state.CurrentProduct = ProductCatalog["Chocolate Cupcake"].Name
@@ -151,7 +140,7 @@ func handlePostback(userID, payload string) {
 	// Quantity selection
 	case "QTY_1":
 		if state.State != "awaiting_quantity" {
-			SendMessage(userID, "⚠️ Please select a product first!")
+			SendMessage(userID, tr(state, "error.select_product_first"))
 			return
 		}
 		state.CurrentQuantity = 1
@@ -160,7 +149,7 @@ func handlePostback(userID, payload string) {
 
 	case "QTY_2":
 		if state.State != "awaiting_quantity" {
-			SendMessage(userID, "⚠️ Please select a product first!")
+			SendMessage(userID, tr(state, "error.select_product_first"))
 			return
 		}
 		state.CurrentQuantity = 2
@@ -169,7 +158,7 @@ func handlePostback(userID, payload string) {
 
 	case "QTY_3":
 		if state.State != "awaiting_quantity" {
-			SendMessage(userID, "⚠️ Please select a product first!")
+			SendMessage(userID, tr(state, "error.select_product_first"))
 			return
 		}
 		state.CurrentQuantity = 3
@@ -178,7 +167,7 @@ func handlePostback(userID, payload string) {
 
 	case "QTY_4":
 		if state.State != "awaiting_quantity" {
-			SendMessage(userID, "⚠️ Please select a product first!")
+			SendMessage(userID, tr(state, "error.select_product_first"))
 			return
 		}
 		state.CurrentQuantity = 4
@@ -187,7 +176,7 @@ func handlePostback(userID, payload string) {
 
 	case "QTY_5":
 		if state.State != "awaiting_quantity" {
-			SendMessage(userID, "⚠️ Please select a product first!")
+			SendMessage(userID, tr(state, "error.select_product_first"))
 			return
 		}
 		state.CurrentQuantity = 5
@@ -204,6 +193,21 @@ func handlePostback(userID, payload string) {
 		SendTypingIndicator(userID, true)
 		askName(userID)
 
+	// Free-text (parsed) order review
+	case "PARSED_CONFIRM":
+		SendTypingIndicator(userID, true)
+		acceptParsedOrder(userID)
+
+	case "PARSED_REVIEW":
+		showParsedOrder(userID)
+
+	case "PARSED_ADD_MORE":
+		acceptParsedOrderAndBrowse(userID)
+
+	// Inscription left blank
+	case "INSCRIPTION_SKIP":
+		skipInscription(userID)
+
 	// Navigation
 	case "GO_BACK":
 		goBack(userID)
@@ -216,20 +220,16 @@ func handlePostback(userID, payload string) {
 	case "PICKUP":
 		state.DeliveryType = "pickup"
 		state.Address = "Pickup at store"
-		state.State = "confirming"
 		SendTypingIndicator(userID, true)
-		showOrderSummary(userID)
+		continueToSummary(userID)
 
 	case "DELIVERY":
 		state.DeliveryType = "delivery"
 		state.State = "awaiting_address"
+		trackFunnelStep(userID, models.FunnelAddress)
 
 		// Add navigation options when asking for address
-		quickReplies := []QuickReply{
-			{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-			{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
-		}
-		SendQuickReplies(userID, "Perfect! Please type your delivery address:\n(Street, City, ZIP)", quickReplies)
+		SendQuickReplies(userID, tr(state, "address.ask_perfect"), backCancelQuickReplies(state))
 
 	// Order confirmation
 	case "CONFIRM_ORDER":
@@ -237,10 +237,11 @@ func handlePostback(userID, payload string) {
 		confirmOrder(userID)
 
 	case "CANCEL_ORDER":
-		ResetUserState(userID)
-		SendMessage(userID, "❌ Order cancelled.")
-		SendMessage(userID, "━━━━━━━━━━━━━━━━━")
-		SendMessage(userID, "Ready to start fresh? Type 'menu' to see our products!")
+		sendOrderCancelled(userID)
+
+	// Abandoned cart reminder
+	case "RESUME_CHECKOUT":
+		resumeCheckout(userID)
 
 	// Mini Quick Order Form
 	case "QUICK_SHOP":
@@ -276,10 +277,24 @@ func handlePostback(userID, payload string) {
 	case "RATING_5":
 		handleRating(userID, 5)
 	case "SKIP_RATING":
-		SendMessage(userID, "No problem! Feel free to rate us anytime.\n\nType 'menu' to order again! 🍰")
+		SendMessage(userID, tr(state, "rating.skipped"))
 		ResetUserState(userID)
+	case "SKIP_RATING_COMMENT":
+		skipRatingComment(userID)
 
 	default:
+		// Language selection (LANG_EN, LANG_MY, LANG_TH, ...)
+		if strings.HasPrefix(payload, "LANG_") {
+			lang := strings.ToLower(strings.TrimPrefix(payload, "LANG_"))
+			if i18n.IsSupported(lang) {
+				setUserLanguage(userID, state, lang)
+				state.State = "greeting"
+				SendMessage(userID, tr(state, "language.selected"))
+				startOrderingFlow(userID)
+				return
+			}
+		}
+
 		// Dynamic product ordering by ID
 		if strings.HasPrefix(payload, "ORDER_PRODUCT_") {
 			idStr := strings.TrimPrefix(payload, "ORDER_PRODUCT_")
@@ -288,33 +303,54 @@ func handlePostback(userID, payload string) {
 					return
 				}
 				if p, err := models.GetProductByID(configs.DB, pid); err == nil && p != nil {
-					emoji := "🍰"
-					switch strings.ToLower(p.Category) {
-					case "cakes":
-						emoji = "🎂"
-					case "cupcakes":
-						emoji = "🧁"
-					case "coffee":
-						emoji = "☕"
-					case "bread":
-						emoji = "🍞"
-					case "muffins":
-						emoji = "🧁"
-					case "tarts":
-						emoji = "🥧"
-					case "pastries":
-						emoji = "🥐"
+					// Every card tap is a view, even if the product turns out to be unavailable
+					go models.IncrementViews(configs.DB, p.ID, time.Now().In(shopLocation()))
+					if p.Status != "active" {
+						// Unpublished or deactivated since the card was sent
+						SendMessage(userID, tr(state, "product.unavailable_now", i18n.Args{"product": p.DisplayName(state.Language)}))
+						showProducts(userID)
+						return
+					}
+					if p.IsSoldOut() || p.AutoHidden {
+						SendMessage(userID, tr(state, "product.sold_out", i18n.Args{"product": p.DisplayName(state.Language)}))
+						showProducts(userID)
+						return
+					}
+					if windows, err := models.GetAvailabilityWindows(configs.DB, p.ID); err == nil && !models.AvailableAt(windows, time.Now().In(shopLocation())) {
+						SendMessage(userID, tr(state, "product.unavailable_now", i18n.Args{"product": p.DisplayName(state.Language)}))
+						showProducts(userID)
+						return
 					}
-					state.CurrentProduct = p.Name
-					state.CurrentEmoji = emoji
-					state.State = "awaiting_quantity"
 					SendTypingIndicator(userID, true)
-					askQuantity(userID)
+					startProductOptions(userID, p, 0)
 					return
 				}
 			}
 		}
 
+		// Category picked from the menu (CATEGORY_<id>)
+		if strings.HasPrefix(payload, "CATEGORY_") {
+			handleCategoryPostback(userID, payload)
+			return
+		}
+
+		// "See more" card of the product carousel (PRODUCTS_PAGE_<n>)
+		if strings.HasPrefix(payload, "PRODUCTS_PAGE_") {
+			handleProductPagePostback(userID, payload)
+			return
+		}
+
+		// Variant choice for the product being ordered (OPTION_<groupID>_<optionID>)
+		if strings.HasPrefix(payload, "OPTION_") {
+			handleOptionPostback(userID, payload)
+			return
+		}
+
+		// Edits to a parsed free-text order (PARSED_EDIT_0, PARSED_QTY_0_2, PARSED_REMOVE_0)
+		if strings.HasPrefix(payload, "PARSED_") && handleParsedOrderPostback(userID, payload) {
+			return
+		}
+
 		// Quick add/view from webview
 		if strings.HasPrefix(payload, "QUICK_ADD_") {
 			productKey := strings.TrimPrefix(payload, "QUICK_ADD_")
@@ -348,7 +384,12 @@ func handlePostback(userID, payload string) {
 			}
 		}
 
-		SendMessage(userID, "Sorry, I didn't understand that. Let's start over!")
+		// Pickup/delivery date and time slot selection
+		if strings.HasPrefix(payload, "SLOT_") && handleSlotPostback(userID, payload) {
+			return
+		}
+
+		SendMessage(userID, tr(state, "error.not_understood"))
 		ResetUserState(userID)
 	}
 }
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bakeflow/models"
+)
+
+// defaultReportDays is the date range of a report when none is given
+const defaultReportDays = 30
+
+// maxReportDays caps the date range of one report
+const maxReportDays = 366
+
+// reportDateRange reads ?from=YYYY-MM-DD&to=YYYY-MM-DD (inclusive, shop timezone),
+// defaulting to the last defaultReportDays days up to today
+func reportDateRange(r *http.Request, loc *time.Location) (from, to time.Time, err error) {
+	today := time.Now().In(loc)
+	to = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if s := r.URL.Query().Get("to"); s != "" {
+		if to, err = time.ParseInLocation("2006-01-02", s, loc); err != nil {
+			return from, to, fmt.Errorf("to must be YYYY-MM-DD")
+		}
+	}
+	from = to.AddDate(0, 0, -(defaultReportDays - 1))
+	if s := r.URL.Query().Get("from"); s != "" {
+		if from, err = time.ParseInLocation("2006-01-02", s, loc); err != nil {
+			return from, to, fmt.Errorf("from must be YYYY-MM-DD")
+		}
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxReportDays*24*time.Hour {
+		return from, to, fmt.Errorf("a report covers at most %d days", maxReportDays)
+	}
+	return from, to, nil
+}
+
+// sumDailyStats totals a daily series
+func sumDailyStats(days []models.DailyProductStats) (views, purchases int) {
+	for _, d := range days {
+		views += d.Views
+		purchases += d.Purchases
+	}
+	return views, purchases
+}
+
+// GetProductAnalytics handles GET /api/products/:id/analytics?from=&to= - lifetime counters
+// and daily views and purchased units of one product
+func (pc *ProductController) GetProductAnalytics(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	loc := shopLocation()
+	from, to, err := reportDateRange(r, loc)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, ids[0])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	days, err := models.GetProductDailyStats(pc.DB, product.ID, from, to)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch analytics", err)
+		return
+	}
+	lifetimeViews, lifetimePurchases, err := models.GetProductLifetimeStats(pc.DB, product.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch analytics", err)
+		return
+	}
+	views, purchases := sumDailyStats(days)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id": product.ID,
+		"name":       product.Name,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"timezone":   loc.String(),
+		"views":      views,
+		"purchases":  purchases,
+		"lifetime": map[string]int{
+			"views":     lifetimeViews,
+			"purchases": lifetimePurchases,
+		},
+		"daily": days,
+	})
+}
+
+// GetProductsAnalytics handles GET /api/products/analytics?from=&to=&sort=purchases|views&limit=
+// - daily views and purchased units across the catalog, and the top products in the range
+func (pc *ProductController) GetProductsAnalytics(w http.ResponseWriter, r *http.Request) {
+	loc := shopLocation()
+	from, to, err := reportDateRange(r, loc)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = models.TrendByPurchases
+	}
+	if sortBy != models.TrendByPurchases && sortBy != models.TrendByViews {
+		respondWithError(w, http.StatusBadRequest, "sort must be purchases or views", nil)
+		return
+	}
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	days, err := models.GetProductDailyStats(pc.DB, 0, from, to)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch analytics", err)
+		return
+	}
+	products, err := models.GetProductTrends(pc.DB, from, to, sortBy, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch analytics", err)
+		return
+	}
+	views, purchases := sumDailyStats(days)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"timezone":  loc.String(),
+		"views":     views,
+		"purchases": purchases,
+		"daily":     days,
+		"products":  products,
+	})
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+
+	"bakeflow/models"
+)
+
+// syncProductAvailability applies the out-of-stock behavior of one product (0 = all products)
+// and refreshes the chat catalog on change. Returns true if any product was hidden or shown again.
+func syncProductAvailability(db *sql.DB, productID int) bool {
+	if db == nil {
+		return false
+	}
+	changed, err := models.SyncProductAvailability(db, productID)
+	if err != nil {
+		log.Printf("❌ Failed to sync product availability: %v", err)
+		return false
+	}
+	if len(changed) == 0 {
+		return false
+	}
+	log.Printf("📦 Product availability changed automatically for %v", changed)
+	invalidateProductMatcher()
+	return true
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"bakeflow/models"
+)
+
+// BulkProducts handles POST /api/products/bulk - apply one change to many products.
+// Products are picked by "ids" or by a "filter" (category_id, category, status, search,
+// min_price, max_price), e.g. closing a category for the day:
+//
+//	{"operation": "status", "status": "inactive", "filter": {"category_id": 3}}
+//
+// Other operations: {"operation": "price", "price": {"mode": "percent", "value": 10}},
+// {"operation": "stock", "stock": {"mode": "add", "value": 12}},
+// {"operation": "category", "category_id": 2} and {"operation": "delete"}.
+// Everything runs in one transaction: if any product fails, none is changed.
+func (pc *ProductController) BulkProducts(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		models.BulkOperation
+		IDs    []int                 `json:"ids"`
+		Filter *models.ProductFilter `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	op := req.BulkOperation
+	if err := op.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		respondWithError(w, http.StatusBadRequest, "Send either ids or filter", nil)
+		return
+	}
+
+	ids := []int{}
+	if req.Filter != nil {
+		var err error
+		ids, err = models.FindProductIDs(pc.DB, *req.Filter, models.MaxBulkProducts+1)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to find products", err)
+			return
+		}
+	} else {
+		seen := map[int]bool{}
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) > models.MaxBulkProducts {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A bulk operation is limited to %d products", models.MaxBulkProducts), nil)
+		return
+	}
+	if len(ids) == 0 {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "No products matched",
+			"count":   0,
+			"results": []models.BulkResult{},
+		})
+		return
+	}
+
+	if op.Operation == models.BulkCategory {
+		category, err := models.GetCategoryByID(pc.DB, op.CategoryID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to fetch category", err)
+			return
+		}
+		if category == nil {
+			respondWithError(w, http.StatusBadRequest, "Unknown category", nil)
+			return
+		}
+		op.CategoryName = category.Name
+	}
+
+	results, err := models.ApplyBulkOperation(pc.DB, op, ids, getAdminIDFromContext(r))
+	if errors.Is(err, models.ErrBulkRejected) {
+		respondWithJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"success": false,
+			"error":   "No products were changed: fix the listed products and try again",
+			"results": results,
+		})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to apply bulk operation", err)
+		return
+	}
+
+	invalidateProductMatcher()
+	if op.Operation == models.BulkStatus || op.Operation == models.BulkStock {
+		syncProductAvailability(pc.DB, 0)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"message":   fmt.Sprintf("Applied %s to %d product(s)", op.Operation, len(results)),
+		"operation": op.Operation,
+		"count":     len(results),
+		"results":   results,
+	})
+}
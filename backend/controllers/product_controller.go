@@ -6,20 +6,25 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"bakeflow/models"
+	"bakeflow/storage"
 
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 )
 
 type ProductController struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Images storage.ImageStore // uploaded product images; nil disables uploads
 }
 
 // GetProducts handles GET /api/products - list all products with filters
 func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters for filtering
 	category := r.URL.Query().Get("category")
+	categoryIDStr := r.URL.Query().Get("category_id")
 	status := r.URL.Query().Get("status")
 	search := r.URL.Query().Get("search")
 	minPriceStr := r.URL.Query().Get("min_price")
@@ -31,8 +36,9 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 
 	// Build query
 	query := `
-		SELECT p.id, p.name, p.description, p.category, p.price, p.stock, 
-		       p.image_url, p.status, p.created_at, p.updated_at,
+		SELECT p.id, COALESCE(p.sku, ''), p.name, p.description, p.category_id, p.category, p.price, p.stock, p.low_stock_threshold,
+		       p.out_of_stock_behavior, p.auto_hidden, p.image_url, p.status, p.created_at, p.updated_at,
+		       p.publish_at, p.unpublish_at, p.names, p.tags,
 		       COALESCE(pa.views, 0) as views, COALESCE(pa.purchases, 0) as purchases
 		FROM products p
 		LEFT JOIN product_analytics pa ON p.id = pa.product_id
@@ -43,18 +49,27 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 
 	// Apply filters
 	if category != "" {
-		query += fmt.Sprintf(" AND p.category = $%d", argNum)
+		query += fmt.Sprintf(" AND LOWER(p.category) = LOWER($%d)", argNum)
 		args = append(args, category)
 		argNum++
 	}
+	if categoryIDStr != "" {
+		if categoryID, err := strconv.Atoi(categoryIDStr); err == nil {
+			query += fmt.Sprintf(" AND p.category_id = $%d", argNum)
+			args = append(args, categoryID)
+			argNum++
+		}
+	}
 	if status != "" {
 		query += fmt.Sprintf(" AND p.status = $%d", argNum)
 		args = append(args, status)
 		argNum++
 	}
-	if search != "" {
-		query += fmt.Sprintf(" AND (p.name ILIKE $%d OR p.description ILIKE $%d)", argNum, argNum)
-		args = append(args, "%"+search+"%")
+	searchArg := 0
+	if search = models.NormalizeSearch(search); search != "" {
+		query += " AND " + models.ProductSearchSQL("p", argNum)
+		args = append(args, search)
+		searchArg = argNum
 		argNum++
 	}
 	if minPriceStr != "" {
@@ -86,7 +101,12 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 	if sortDir != "ASC" && sortDir != "DESC" {
 		sortDir = "DESC"
 	}
-	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortDir)
+	if searchArg != 0 && r.URL.Query().Get("sort_by") == "" {
+		// Best matches first unless another order was asked for
+		query += " ORDER BY " + models.ProductSearchRankSQL("p", searchArg) + " DESC, p.id DESC"
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortDir)
+	}
 
 	// Pagination
 	limit := 50
@@ -118,9 +138,12 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 		var views, purchases int
 		var desc sql.NullString
 		var img sql.NullString
-		err := rows.Scan(&p.ID, &p.Name, &desc, &p.Category, &p.Price,
-			&p.Stock, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt, &views, &purchases)
-		if err != nil {
+		var names []byte
+		var tags []string
+		err := rows.Scan(&p.ID, &p.SKU, &p.Name, &desc, &p.CategoryID, &p.Category, &p.Price,
+			&p.Stock, &p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt,
+			&p.PublishAt, &p.UnpublishAt, &names, pq.Array(&tags), &views, &purchases)
+		if err != nil || p.SetSearchFields(names, tags) != nil {
 			continue
 		}
 		if desc.Valid {
@@ -131,11 +154,14 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 		}
 		products = append(products, map[string]interface{}{
 			"id":          p.ID,
+			"sku":         p.SKU,
 			"name":        p.Name,
 			"description": p.Description,
+			"category_id": p.CategoryID,
 			"category":    p.Category,
 			"price":       p.Price,
 			"stock":       p.Stock,
+			"low_stock_threshold": p.LowStockThreshold,
 			"image_url":   p.ImageURL,
 			"status":      p.Status,
 			"created_at":  p.CreatedAt,
@@ -144,6 +170,13 @@ func (pc *ProductController) GetProducts(w http.ResponseWriter, r *http.Request)
 			"purchases":   purchases,
 			"low_stock":   p.IsLowStock(),
 			"out_of_stock": p.IsOutOfStock(),
+			"out_of_stock_behavior": p.OutOfStockBehavior,
+			"auto_hidden": p.AutoHidden,
+			"sold_out":    p.IsSoldOut(),
+			"publish_at":  p.PublishAt,
+			"unpublish_at": p.UnpublishAt,
+			"names":       p.Names,
+			"tags":        p.Tags,
 		})
 	}
 
@@ -163,8 +196,9 @@ func (pc *ProductController) GetProduct(w http.ResponseWriter, r *http.Request)
 	}
 
 	query := `
-		SELECT p.id, p.name, p.description, p.category, p.price, p.stock, 
-		       p.image_url, p.status, p.created_at, p.updated_at,
+		SELECT p.id, COALESCE(p.sku, ''), p.name, p.description, p.category_id, p.category, p.price, p.stock, p.low_stock_threshold,
+		       p.out_of_stock_behavior, p.auto_hidden, p.image_url, p.status, p.created_at, p.updated_at,
+		       p.publish_at, p.unpublish_at, p.names, p.tags,
 		       COALESCE(pa.views, 0) as views, COALESCE(pa.purchases, 0) as purchases
 		FROM products p
 		LEFT JOIN product_analytics pa ON p.id = pa.product_id
@@ -175,10 +209,12 @@ func (pc *ProductController) GetProduct(w http.ResponseWriter, r *http.Request)
 	var views, purchases int
 	var desc sql.NullString
 	var img sql.NullString
+	var names []byte
+	var tags []string
 	err = pc.DB.QueryRow(query, id).Scan(
-		&p.ID, &p.Name, &desc, &p.Category, &p.Price,
-		&p.Stock, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt,
-		&views, &purchases,
+		&p.ID, &p.SKU, &p.Name, &desc, &p.CategoryID, &p.Category, &p.Price,
+		&p.Stock, &p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt,
+		&p.PublishAt, &p.UnpublishAt, &names, pq.Array(&tags), &views, &purchases,
 	)
 	if err == sql.ErrNoRows {
 		respondWithError(w, http.StatusNotFound, "Product not found", nil)
@@ -194,18 +230,27 @@ func (pc *ProductController) GetProduct(w http.ResponseWriter, r *http.Request)
 	if img.Valid {
 		p.ImageURL = img.String
 	}
+	if err := p.SetSearchFields(names, tags); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
 
 	// Increment view count
-	go models.IncrementViews(pc.DB, id)
+	go models.IncrementViews(pc.DB, id, time.Now().In(shopLocation()))
+
+	w.Header().Set("ETag", productETag(&p))
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"product": map[string]interface{}{
 			"id":          p.ID,
+			"sku":         p.SKU,
 			"name":        p.Name,
 			"description": p.Description,
+			"category_id": p.CategoryID,
 			"category":    p.Category,
 			"price":       p.Price,
 			"stock":       p.Stock,
+			"low_stock_threshold": p.LowStockThreshold,
 			"image_url":   p.ImageURL,
 			"status":      p.Status,
 			"created_at":  p.CreatedAt,
@@ -214,17 +259,27 @@ func (pc *ProductController) GetProduct(w http.ResponseWriter, r *http.Request)
 			"purchases":   purchases,
 			"low_stock":   p.IsLowStock(),
 			"out_of_stock": p.IsOutOfStock(),
+			"out_of_stock_behavior": p.OutOfStockBehavior,
+			"auto_hidden": p.AutoHidden,
+			"sold_out":    p.IsSoldOut(),
+			"publish_at":  p.PublishAt,
+			"unpublish_at": p.UnpublishAt,
+			"names":       p.Names,
+			"tags":        p.Tags,
 		},
 	})
 }
 
 // CreateProduct handles POST /api/products - create new product
 func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	var product models.Product
+	product := models.Product{LowStockThreshold: models.DefaultLowStockThreshold}
 	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
+	if !pc.resolveProductCategory(w, &product) {
+		return
+	}
 
 	// Validate product
 	if err := product.Validate(); err != nil {
@@ -232,6 +287,10 @@ func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !pc.checkSKUAvailable(w, product.SKU, 0) {
+		return
+	}
+
 	// Set default status if not provided
 	if product.Status == "" {
 		product.Status = "draft"
@@ -239,14 +298,17 @@ func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Reques
 
 	// Insert product
 	query := `
-		INSERT INTO products (name, description, category, price, stock, image_url, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO products (name, description, category, price, stock, image_url, status, low_stock_threshold, out_of_stock_behavior, category_id, sku,
+		                      publish_at, unpublish_at, names, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14, $15)
 		RETURNING id, created_at, updated_at
 	`
 	err := pc.DB.QueryRow(
 		query,
 		product.Name, product.Description, product.Category, 
-		product.Price, product.Stock, product.ImageURL, product.Status,
+		product.Price, product.Stock, product.ImageURL, product.Status, product.LowStockThreshold,
+		product.OutOfStockBehavior, product.CategoryID, product.SKU, product.PublishAt, product.UnpublishAt,
+		models.ProductNamesJSON(product.Names), pq.Array(product.Tags),
 	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 
 	if err != nil {
@@ -261,6 +323,12 @@ func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Reques
 		"product": product,
 	}
 	go models.CreateLogEntry(pc.DB, product.ID, adminID, "CREATE", changes)
+	invalidateProductMatcher()
+	if syncProductAvailability(pc.DB, product.ID) {
+		if p, err := models.GetProductByID(pc.DB, product.ID); err == nil && p != nil {
+			product.Status, product.AutoHidden = p.Status, p.AutoHidden
+		}
+	}
 
 	// Initialize analytics
 	go func() {
@@ -288,60 +356,70 @@ func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Get existing product for comparison
-	var oldProduct models.Product
-	query := `SELECT id, name, description, category, price, stock, image_url, status 
-	          FROM products WHERE id = $1 AND deleted_at IS NULL`
-	var desc sql.NullString
-	var img sql.NullString
-	err = pc.DB.QueryRow(query, id).Scan(
-		&oldProduct.ID, &oldProduct.Name, &desc,
-		&oldProduct.Category, &oldProduct.Price, &oldProduct.Stock,
-		&img, &oldProduct.Status,
-	)
-	if err == sql.ErrNoRows {
-		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+	// Get existing product for comparison; If-Match must name its current version
+	oldProduct, ok := pc.loadProductForUpdate(w, r, id)
+	if !ok {
 		return
 	}
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+
+	// Decode over the current product so fields the client omits keep their values
+	product := *oldProduct
+	product.CategoryID, product.Category = 0, ""
+	product.Names, product.Tags = nil, nil // decoding would merge into the shared map and slice
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
-	if desc.Valid {
-		oldProduct.Description = desc.String
+	if product.Names == nil {
+		product.Names = oldProduct.Names
 	}
-	if img.Valid {
-		oldProduct.ImageURL = img.String
+	if product.Tags == nil {
+		product.Tags = oldProduct.Tags
 	}
-
-	// Decode new product data
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+	if !product.UpdatedAt.Equal(oldProduct.UpdatedAt) {
+		respondProductConflict(w, oldProduct)
 		return
 	}
+	if product.CategoryID == 0 && product.Category == "" {
+		product.CategoryID, product.Category = oldProduct.CategoryID, oldProduct.Category
+	}
 	product.ID = id
+	if !pc.resolveProductCategory(w, &product) {
+		return
+	}
 
 	// Validate
 	if err := product.Validate(); err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	if !pc.checkSKUAvailable(w, product.SKU, id) {
+		return
+	}
 
-	// Update product
+	// Update product, unless someone else updated it since it was read
 	updateQuery := `
 		UPDATE products 
 		SET name = $1, description = $2, category = $3, price = $4, 
-		    stock = $5, image_url = $6, status = $7
-		WHERE id = $8 AND deleted_at IS NULL
-		RETURNING updated_at
+		    stock = $5, image_url = $6, status = $7, low_stock_threshold = $9,
+		    out_of_stock_behavior = $10, category_id = $11, sku = NULLIF($12, ''),
+		    publish_at = $13, unpublish_at = $14, names = $16, tags = $17,
+		    auto_hidden = auto_hidden AND status = $7 -- an admin status change takes over from the system
+		WHERE id = $8 AND deleted_at IS NULL AND updated_at = $15::timestamp
+		RETURNING updated_at, auto_hidden
 	`
 	err = pc.DB.QueryRow(
 		updateQuery,
 		product.Name, product.Description, product.Category,
-		product.Price, product.Stock, product.ImageURL, product.Status, id,
-	).Scan(&product.UpdatedAt)
+		product.Price, product.Stock, product.ImageURL, product.Status, id, product.LowStockThreshold,
+		product.OutOfStockBehavior, product.CategoryID, product.SKU, product.PublishAt, product.UnpublishAt,
+		sqlTimestamp(oldProduct.UpdatedAt), models.ProductNamesJSON(product.Names), pq.Array(product.Tags),
+	).Scan(&product.UpdatedAt, &product.AutoHidden)
 
+	if err == sql.ErrNoRows {
+		pc.respondUpdateConflict(w, id)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update product", err)
 		return
@@ -351,11 +429,22 @@ func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Reques
 	adminID := getAdminIDFromContext(r)
 	changes := map[string]interface{}{
 		"action": "updated",
+		"diff": productDiff(oldProduct, &product),
 		"old": oldProduct,
 		"new": product,
 	}
 	go models.CreateLogEntry(pc.DB, product.ID, adminID, "UPDATE", changes)
+	invalidateProductMatcher()
+	if oldProduct.ImageURL != product.ImageURL {
+		pc.deleteUnusedImage(oldProduct.ImageURL)
+	}
+	if syncProductAvailability(pc.DB, product.ID) {
+		if p, err := models.GetProductByID(pc.DB, product.ID); err == nil && p != nil {
+			product.Status, product.AutoHidden = p.Status, p.AutoHidden
+		}
+	}
 
+	w.Header().Set("ETag", productETag(&product))
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Product updated successfully",
@@ -381,10 +470,7 @@ func (pc *ProductController) UpdateProductStatus(w http.ResponseWriter, r *http.
 	}
 
 	// Validate status
-	validStatuses := map[string]bool{
-		"draft": true, "active": true, "inactive": true, "archived": true,
-	}
-	if !validStatuses[body.Status] {
+	if !validProductStatuses[body.Status] {
 		respondWithError(w, http.StatusBadRequest, "Invalid status", nil)
 		return
 	}
@@ -402,7 +488,7 @@ func (pc *ProductController) UpdateProductStatus(w http.ResponseWriter, r *http.
 	}
 
 	// Update status
-	query := `UPDATE products SET status = $1 WHERE id = $2 AND deleted_at IS NULL`
+	query := `UPDATE products SET status = $1, auto_hidden = FALSE WHERE id = $2 AND deleted_at IS NULL`
 	_, err = pc.DB.Exec(query, body.Status, id)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to update status", err)
@@ -417,6 +503,7 @@ func (pc *ProductController) UpdateProductStatus(w http.ResponseWriter, r *http.
 		"new_status": body.Status,
 	}
 	go models.CreateLogEntry(pc.DB, id, adminID, "STATUS_CHANGE", changes)
+	invalidateProductMatcher()
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -433,10 +520,10 @@ func (pc *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Check if product exists
-	var exists bool
-	err = pc.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
-	if err != nil || !exists {
+	// Check if product exists (its status is logged so a restore can bring it back)
+	var oldStatus string
+	err = pc.DB.QueryRow("SELECT status FROM products WHERE id = $1 AND deleted_at IS NULL", id).Scan(&oldStatus)
+	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Product not found", nil)
 		return
 	}
@@ -454,8 +541,10 @@ func (pc *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Reques
 	changes := map[string]interface{}{
 		"action": "deleted",
 		"product_id": id,
+		"old_status": oldStatus,
 	}
 	go models.CreateLogEntry(pc.DB, id, adminID, "DELETE", changes)
+	invalidateProductMatcher()
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -518,49 +607,168 @@ func (pc *ProductController) GetProductLogs(w http.ResponseWriter, r *http.Reque
 	})
 }
 
-// GetLowStockProducts handles GET /api/products/low-stock - get products with low stock
+// GetProductAliases handles GET /api/products/:id/aliases - list chat aliases for a product
+func (pc *ProductController) GetProductAliases(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	aliases, err := models.GetProductAliases(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch aliases", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"aliases": aliases,
+		"count":   len(aliases),
+	})
+}
+
+// CreateProductAlias handles POST /api/products/:id/aliases - add a chat alias/synonym
+func (pc *ProductController) CreateProductAlias(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var alias models.ProductAlias
+	if err := json.NewDecoder(r.Body).Decode(&alias); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	alias.ProductID = id
+
+	if err := alias.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	err = models.CreateProductAlias(pc.DB, &alias)
+	if err == models.ErrAliasExists {
+		respondWithError(w, http.StatusConflict, "Product already has this alias", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create alias", err)
+		return
+	}
+
+	adminID := getAdminIDFromContext(r)
+	changes := map[string]interface{}{
+		"action": "alias_added",
+		"alias":  alias,
+	}
+	go models.CreateLogEntry(pc.DB, id, adminID, "ALIAS_ADD", changes)
+	invalidateProductMatcher()
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Alias added successfully",
+		"alias":   alias,
+	})
+}
+
+// DeleteProductAlias handles DELETE /api/products/:id/aliases/:aliasId - remove a chat alias
+func (pc *ProductController) DeleteProductAlias(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	aliasID, err := strconv.Atoi(vars["aliasId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid alias ID", err)
+		return
+	}
+
+	deleted, err := models.DeleteProductAlias(pc.DB, id, aliasID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete alias", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Alias not found", nil)
+		return
+	}
+
+	adminID := getAdminIDFromContext(r)
+	changes := map[string]interface{}{
+		"action":   "alias_removed",
+		"alias_id": aliasID,
+	}
+	go models.CreateLogEntry(pc.DB, id, adminID, "ALIAS_DELETE", changes)
+	invalidateProductMatcher()
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Alias deleted successfully",
+	})
+}
+
+// GetLowStockProducts handles GET /api/products/low-stock - get products below their reorder threshold
+// An optional ?threshold=N overrides the per-product thresholds
 func (pc *ProductController) GetLowStockProducts(w http.ResponseWriter, r *http.Request) {
-	threshold := 10
+	threshold := 0
 	if t := r.URL.Query().Get("threshold"); t != "" {
 		if parsedT, err := strconv.Atoi(t); err == nil && parsedT > 0 {
 			threshold = parsedT
 		}
 	}
 
-	query := `
-		SELECT id, name, category, stock, status
-		FROM products
-		WHERE stock < $1 AND status = 'active' AND deleted_at IS NULL
-		ORDER BY stock ASC
-	`
-
-	rows, err := pc.DB.Query(query, threshold)
+	lowStock, err := models.GetLowStockProducts(pc.DB, threshold)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to fetch low stock products", err)
 		return
 	}
-	defer rows.Close()
 
 	products := []map[string]interface{}{}
-	for rows.Next() {
-		var id, stock int
-		var name, category, status string
-		if err := rows.Scan(&id, &name, &category, &stock, &status); err != nil {
-			continue
-		}
+	for _, p := range lowStock {
 		products = append(products, map[string]interface{}{
-			"id":       id,
-			"name":     name,
-			"category": category,
-			"stock":    stock,
-			"status":   status,
+			"id":                  p.ID,
+			"name":                p.Name,
+			"category":            p.Category,
+			"stock":               p.Stock,
+			"low_stock_threshold": p.LowStockThreshold,
+			"status":              p.Status,
 		})
 	}
 
+	// Ingredients use their own per-ingredient thresholds
+	ingredients, err := models.GetLowStockIngredients(pc.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch low stock ingredients", err)
+		return
+	}
+
+	// Report the override, or that each product's own threshold was used
+	var appliedThreshold interface{} = "per_product"
+	if threshold > 0 {
+		appliedThreshold = threshold
+	}
+
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"products": products,
-		"count":    len(products),
-		"threshold": threshold,
+		"products":         products,
+		"count":            len(products),
+		"threshold":        appliedThreshold,
+		"ingredients":      ingredients,
+		"ingredient_count": len(ingredients),
 	})
 }
 
@@ -603,8 +811,8 @@ func (pc *ProductController) SeedProducts(w http.ResponseWriter, r *http.Request
 	}
 
 	insertQuery := `
-		INSERT INTO products (name, description, category, price, stock, image_url, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO products (name, description, category, price, stock, image_url, status, category_id)
+		SELECT $1, $2, c.name, $4, $5, $6, $7, c.id FROM categories c WHERE LOWER(c.name) = LOWER($3)
 		RETURNING id
 	`
 
@@ -629,6 +837,7 @@ func (pc *ProductController) SeedProducts(w http.ResponseWriter, r *http.Request
 		respondWithError(w, http.StatusInternalServerError, "Failed to commit seed", err)
 		return
 	}
+	invalidateProductMatcher()
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -704,6 +913,20 @@ func getAdminIDFromContext(r *http.Request) sql.NullInt64 {
 	return sql.NullInt64{Valid: false}
 }
 
+// checkSKUAvailable writes a 409 and returns false when another product already has the SKU
+func (pc *ProductController) checkSKUAvailable(w http.ResponseWriter, sku string, productID int) bool {
+	taken, err := models.SKUTaken(pc.DB, sku, productID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check SKU", err)
+		return false
+	}
+	if taken {
+		respondWithError(w, http.StatusConflict, "Another product already uses this SKU", nil)
+		return false
+	}
+	return true
+}
+
 // Helper functions for JSON responses
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
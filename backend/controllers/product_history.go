@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+	"bakeflow/storage"
+
+	"github.com/lib/pq"
+)
+
+// defaultProductPurgeDays is how long deleted products can be restored when PRODUCT_PURGE_DAYS is unset
+const defaultProductPurgeDays = 30
+
+// productPurgeInterval is how often the purge runs
+const productPurgeInterval = 6 * time.Hour
+
+// validProductStatuses are the statuses an admin can set
+var validProductStatuses = map[string]bool{
+	"draft": true, "active": true, "inactive": true, "archived": true,
+}
+
+// productPurgeDays reads PRODUCT_PURGE_DAYS; 0 keeps deleted products forever
+func productPurgeDays() int {
+	v := os.Getenv("PRODUCT_PURGE_DAYS")
+	if v == "" {
+		return defaultProductPurgeDays
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 0 {
+		log.Printf("⚠️ Invalid PRODUCT_PURGE_DAYS %q, using %d", v, defaultProductPurgeDays)
+		return defaultProductPurgeDays
+	}
+	return days
+}
+
+// StartProductPurger permanently removes products that were deleted more than
+// PRODUCT_PURGE_DAYS days ago; until then they can be restored.
+func StartProductPurger() {
+	days := productPurgeDays()
+	if days == 0 {
+		log.Println("ℹ️ Deleted products are kept forever (PRODUCT_PURGE_DAYS=0)")
+		return
+	}
+
+	go func() {
+		for {
+			purgeDeletedProducts(days)
+			time.Sleep(productPurgeInterval)
+		}
+	}()
+	log.Printf("✅ Deleted products are purged after %d days", days)
+}
+
+// purgeDeletedProducts runs one purge, removes the uploaded images nothing refers to any
+// more and records it as a batch audit entry
+func purgeDeletedProducts(days int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic recovered in product purge: %v", r)
+		}
+	}()
+	if configs.DB == nil {
+		return
+	}
+
+	purged, err := models.PurgeDeletedProducts(configs.DB, days)
+	if err != nil {
+		log.Printf("❌ Failed to purge deleted products: %v", err)
+		return
+	}
+	if len(purged) == 0 {
+		return
+	}
+
+	pc := &ProductController{DB: configs.DB, Images: storage.NewImageStoreFromEnv()}
+	for _, p := range purged {
+		pc.deleteUnusedImage(p.ImageURL)
+	}
+
+	products := make([]map[string]interface{}, 0, len(purged))
+	for _, p := range purged {
+		products = append(products, map[string]interface{}{
+			"id":         p.ID,
+			"sku":        p.SKU,
+			"name":       p.Name,
+			"category":   p.Category,
+			"deleted_at": p.DeletedAt.Time,
+		})
+	}
+	changes := map[string]interface{}{
+		"action":     "purged",
+		"after_days": days,
+		"products":   products,
+	}
+	if err := models.CreateBatchLogEntry(configs.DB, sql.NullInt64{}, "PURGE", changes); err != nil {
+		log.Printf("⚠️ Failed to log product purge: %v", err)
+	}
+	log.Printf("🗑️ Purged %d product(s) deleted more than %d days ago", len(purged), days)
+}
+
+// GetDeletedProducts handles GET /api/products/deleted - the restorable products
+func (pc *ProductController) GetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := models.GetDeletedProducts(pc.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch deleted products", err)
+		return
+	}
+
+	days := productPurgeDays()
+	result := make([]map[string]interface{}, 0, len(products))
+	for _, p := range products {
+		item := map[string]interface{}{
+			"id":          p.ID,
+			"sku":         p.SKU,
+			"name":        p.Name,
+			"description": p.Description,
+			"category_id": p.CategoryID,
+			"category":    p.Category,
+			"price":       p.Price,
+			"stock":       p.Stock,
+			"status":      p.Status,
+			"created_at":  p.CreatedAt,
+			"deleted_at":  p.DeletedAt.Time,
+		}
+		if days > 0 {
+			item["purge_at"] = p.DeletedAt.Time.AddDate(0, 0, days)
+		}
+		result = append(result, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"products":   result,
+		"count":      len(result),
+		"purge_days": days,
+	})
+}
+
+// RestoreProduct handles POST /api/products/:id/restore - undelete a product.
+// It gets back the status it had when deleted unless the body names one ({"status": "draft"}).
+func (pc *ProductController) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	id := ids[0]
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+			return
+		}
+	}
+	if body.Status != "" && !validProductStatuses[body.Status] {
+		respondWithError(w, http.StatusBadRequest, "Invalid status", nil)
+		return
+	}
+
+	product, err := models.GetDeletedProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Deleted product not found", nil)
+		return
+	}
+	if product.SKU != "" && !pc.checkSKUAvailable(w, product.SKU, id) {
+		return
+	}
+
+	status := body.Status
+	if status == "" {
+		status = pc.statusBeforeDelete(id)
+	}
+	restored, err := models.RestoreProduct(pc.DB, id, status)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore product", err)
+		return
+	}
+	if !restored {
+		respondWithError(w, http.StatusNotFound, "Deleted product not found", nil)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action":     "restored",
+		"product_id": id,
+		"deleted_at": product.DeletedAt.Time,
+		"new_status": status,
+	}
+	go models.CreateLogEntry(pc.DB, id, getAdminIDFromContext(r), "RESTORE", changes)
+	invalidateProductMatcher()
+	syncProductAvailability(pc.DB, id)
+
+	restoredProduct, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Product restored as %s", status),
+		"product": restoredProduct,
+	})
+}
+
+// statusBeforeDelete returns the status logged by the product's last DELETE, or draft
+func (pc *ProductController) statusBeforeDelete(productID int) string {
+	entry, err := models.GetLatestProductLog(pc.DB, productID, "DELETE")
+	if err != nil || entry == nil {
+		return "draft"
+	}
+	var changes struct {
+		OldStatus string `json:"old_status"`
+	}
+	if json.Unmarshal(entry.Changes, &changes) != nil || !validProductStatuses[changes.OldStatus] {
+		return "draft"
+	}
+	return changes.OldStatus
+}
+
+// RevertProduct handles POST /api/products/:id/revert/:logId?to=old|new|product - roll a
+// product back to a snapshot from its audit trail. UPDATE and REVERT entries default to the
+// state before the change ("old"), CREATE entries to the product as created. Stock is not
+// rolled back since it has moved with orders since; deleted products must be restored first.
+func (pc *ProductController) RevertProduct(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "logId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product or log ID", err)
+		return
+	}
+	id, logID := ids[0], ids[1]
+
+	current, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if current == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found (deleted products must be restored first)", nil)
+		return
+	}
+	entry, err := models.GetProductLog(pc.DB, id, logID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch log entry", err)
+		return
+	}
+	if entry == nil {
+		respondWithError(w, http.StatusNotFound, "Log entry not found for this product", nil)
+		return
+	}
+
+	product, hasCategoryID, err := productSnapshot(entry, *current, r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+		return
+	}
+
+	// The snapshot's category may have been renamed or deleted since
+	var category *models.Category
+	if hasCategoryID {
+		category, err = models.GetCategoryByID(pc.DB, product.CategoryID)
+	} else {
+		category, err = models.GetCategoryByName(pc.DB, product.Category)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch category", err)
+		return
+	}
+	if category == nil {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Category %q no longer exists", product.Category), nil)
+		return
+	}
+	product.CategoryID, product.Category = category.ID, category.Name
+
+	// Uploaded images are deleted once replaced; keep the current one if the old file is gone
+	warnings := []string{}
+	if product.ImageURL != current.ImageURL && pc.Images != nil && pc.Images.Owns(product.ImageURL) {
+		inUse, err := models.ImageInUse(pc.DB, product.ImageURL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to check image", err)
+			return
+		}
+		if !inUse {
+			product.ImageURL = current.ImageURL
+			warnings = append(warnings, "The snapshot's uploaded image was deleted; the current image is kept")
+		}
+	}
+
+	if err := product.Validate(); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+		return
+	}
+	if !pc.checkSKUAvailable(w, product.SKU, id) {
+		return
+	}
+
+	err = pc.DB.QueryRow(`
+		UPDATE products
+		SET name = $1, description = $2, category = $3, price = $4, image_url = $5, status = $6,
+		    low_stock_threshold = $7, out_of_stock_behavior = $8, category_id = $9, sku = NULLIF($10, ''),
+		    names = $12, tags = $13, auto_hidden = auto_hidden AND status = $6
+		WHERE id = $11 AND deleted_at IS NULL
+		RETURNING updated_at, auto_hidden
+	`, product.Name, product.Description, product.Category, product.Price, product.ImageURL, product.Status,
+		product.LowStockThreshold, product.OutOfStockBehavior, product.CategoryID, product.SKU, id,
+		models.ProductNamesJSON(product.Names), pq.Array(product.Tags),
+	).Scan(&product.UpdatedAt, &product.AutoHidden)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to revert product", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action":        "reverted",
+		"log_id":        logID,
+		"log_action":    entry.Action,
+		"log_timestamp": entry.CreatedAt,
+		"old":           current,
+		"new":           product,
+	}
+	go models.CreateLogEntry(pc.DB, id, getAdminIDFromContext(r), "REVERT", changes)
+	invalidateProductMatcher()
+	if current.ImageURL != product.ImageURL {
+		pc.deleteUnusedImage(current.ImageURL)
+	}
+	if syncProductAvailability(pc.DB, id) {
+		if p, err := models.GetProductByID(pc.DB, id); err == nil && p != nil {
+			product.Status, product.AutoHidden = p.Status, p.AutoHidden
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"message":  fmt.Sprintf("Product reverted to log entry %d", logID),
+		"product":  product,
+		"warnings": warnings,
+	})
+}
+
+// productSnapshot decodes the snapshot named by key from a log entry over the current product,
+// so fields older entries did not record keep their current values. It also reports whether
+// the snapshot recorded a category ID (entries from before categories only have the name).
+func productSnapshot(entry *models.ProductLog, current models.Product, key string) (models.Product, bool, error) {
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal(entry.Changes, &parts); err != nil {
+		return current, false, fmt.Errorf("log entry %d cannot be read", entry.ID)
+	}
+	if key == "" {
+		key = "product"
+		if _, ok := parts["old"]; ok {
+			key = "old"
+		}
+	}
+	if key != "old" && key != "new" && key != "product" {
+		return current, false, fmt.Errorf("to must be old, new or product")
+	}
+	raw, ok := parts[key]
+	if !ok || string(raw) == "null" {
+		return current, false, fmt.Errorf("log entry %d (%s) has no product snapshot to revert to", entry.ID, entry.Action)
+	}
+
+	snapshot := current
+	snapshot.Names, snapshot.Tags = nil, nil // decoding would merge into the current map and slice
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return current, false, fmt.Errorf("log entry %d has an unreadable snapshot", entry.ID)
+	}
+	if snapshot.Names == nil {
+		snapshot.Names = current.Names
+	}
+	if snapshot.Tags == nil {
+		snapshot.Tags = current.Tags
+	}
+	var recorded struct {
+		CategoryID *int `json:"category_id"`
+	}
+	json.Unmarshal(raw, &recorded)
+
+	// Identity, timestamps and stock stay as they are now
+	snapshot.ID = current.ID
+	snapshot.Stock = current.Stock
+	snapshot.AutoHidden = current.AutoHidden
+	snapshot.CreatedAt = current.CreatedAt
+	snapshot.UpdatedAt = current.UpdatedAt
+	snapshot.DeletedAt = current.DeletedAt
+	return snapshot, recorded.CategoryID != nil, nil
+}
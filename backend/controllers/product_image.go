@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"bakeflow/models"
+	"bakeflow/storage"
+)
+
+// UploadProductImage handles POST /api/products/:id/image - multipart field "image".
+// The image is validated, cropped and resized for Messenger, stored, and replaces the
+// product's image_url; the previous uploaded image is removed.
+func (pc *ProductController) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	if pc.Images == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Image storage is not configured", nil)
+		return
+	}
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, ids[0])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	// Leave room for the multipart envelope around the file
+	r.Body = http.MaxBytesReader(w, r.Body, storage.MaxImageBytes+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, storage.ErrImageTooLarge.Error(), nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing image file (multipart field \"image\")", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, storage.MaxImageBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read image", err)
+		return
+	}
+	processed, err := storage.ProcessProductImage(data)
+	switch {
+	case errors.Is(err, storage.ErrImageTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+		return
+	case errors.Is(err, storage.ErrImageType):
+		respondWithError(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+		return
+	case errors.Is(err, storage.ErrImageDimensions):
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Failed to process image", err)
+		return
+	}
+
+	url, err := pc.Images.Save(processed, ".jpg")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store image", err)
+		return
+	}
+	err = pc.DB.QueryRow(`UPDATE products SET image_url = $1 WHERE id = $2 RETURNING updated_at`, url, product.ID).Scan(&product.UpdatedAt)
+	if err != nil {
+		pc.Images.Delete(url)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update product", err)
+		return
+	}
+	pc.deleteUnusedImage(product.ImageURL)
+
+	changes := map[string]interface{}{
+		"action":        "image_uploaded",
+		"old_image_url": product.ImageURL,
+		"new_image_url": url,
+	}
+	go models.CreateLogEntry(pc.DB, product.ID, getAdminIDFromContext(r), "IMAGE_UPLOAD", changes)
+
+	w.Header().Set("ETag", productETag(product))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"message":   "Image uploaded successfully",
+		"image_url": url,
+	})
+}
+
+// deleteUnusedImage removes an uploaded image once no product refers to it any more
+func (pc *ProductController) deleteUnusedImage(url string) {
+	if pc.Images == nil || url == "" || !pc.Images.Owns(url) {
+		return
+	}
+	inUse, err := models.ImageInUse(pc.DB, url)
+	if err != nil {
+		log.Printf("⚠️ Failed to check image usage %s: %v", url, err)
+		return
+	}
+	if inUse {
+		return
+	}
+	if err := pc.Images.Delete(url); err != nil {
+		log.Printf("⚠️ Failed to delete image %s: %v", url, err)
+		return
+	}
+	log.Printf("🗑️ Deleted unused product image %s", url)
+}
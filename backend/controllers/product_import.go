@@ -0,0 +1,485 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/models"
+)
+
+// productColumns are the fields of the import/export formats, in CSV column order
+var productColumns = []string{
+	"sku", "name", "description", "category", "price", "stock",
+	"low_stock_threshold", "out_of_stock_behavior", "image_url", "status",
+}
+
+// Import limits
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 2000
+)
+
+// productRecord is one product in the import/export formats
+type productRecord struct {
+	SKU                string  `json:"sku"`
+	Name               string  `json:"name"`
+	Description        string  `json:"description"`
+	Category           string  `json:"category"`
+	Price              float64 `json:"price"`
+	Stock              int     `json:"stock"`
+	LowStockThreshold  int     `json:"low_stock_threshold"`
+	OutOfStockBehavior string  `json:"out_of_stock_behavior"`
+	ImageURL           string  `json:"image_url"`
+	Status             string  `json:"status"`
+}
+
+func newProductRecord(p models.Product) productRecord {
+	return productRecord{
+		SKU:                p.SKU,
+		Name:               p.Name,
+		Description:        p.Description,
+		Category:           p.Category,
+		Price:              p.Price,
+		Stock:              p.Stock,
+		LowStockThreshold:  p.LowStockThreshold,
+		OutOfStockBehavior: p.OutOfStockBehavior,
+		ImageURL:           p.ImageURL,
+		Status:             p.Status,
+	}
+}
+
+// csvRow returns the record's values in productColumns order
+func (rec productRecord) csvRow() []string {
+	return []string{
+		rec.SKU, rec.Name, rec.Description, rec.Category,
+		strconv.FormatFloat(rec.Price, 'f', 2, 64), strconv.Itoa(rec.Stock),
+		strconv.Itoa(rec.LowStockThreshold), rec.OutOfStockBehavior, rec.ImageURL, rec.Status,
+	}
+}
+
+// importRow is a parsed row before validation; absent fields keep the existing value on update
+type importRow struct {
+	Row    int
+	Fields map[string]string
+}
+
+// importRowError is a row-level problem reported back to the admin
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// importRowResult is what a valid row will do (or did)
+type importRowResult struct {
+	Row       int    `json:"row"`
+	Action    string `json:"action"`
+	ProductID int    `json:"product_id,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ExportProducts handles GET /api/products/export?format=csv|json - all live products
+func (pc *ProductController) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		respondWithError(w, http.StatusBadRequest, "format must be csv or json", nil)
+		return
+	}
+
+	products, err := models.GetProductsForExport(pc.DB)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch products", err)
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "json" {
+		records := make([]productRecord, 0, len(products))
+		for _, p := range products {
+			records = append(records, newProductRecord(p))
+		}
+		respondWithJSON(w, http.StatusOK, records)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	cw.Write(productColumns)
+	for _, p := range products {
+		cw.Write(newProductRecord(p).csvRow())
+	}
+	cw.Flush()
+}
+
+// ImportProducts handles POST /api/products/import?format=csv|json&dry_run=true.
+// Rows are matched to existing products by SKU, or by name when they have no SKU, and
+// created or updated. The import is all-or-nothing: any invalid row rejects the batch.
+// With dry_run=true nothing is written and the per-row outcome is reported.
+func (pc *ProductController) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(r.Header.Get("Content-Type"), "csv") {
+			format = "csv"
+		}
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []importRow
+	var err error
+	switch format {
+	case "csv":
+		rows, err = parseProductCSV(body)
+	case "json":
+		rows, err = parseProductJSON(body)
+	default:
+		respondWithError(w, http.StatusBadRequest, "format must be csv or json", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid import file", err)
+		return
+	}
+	if len(rows) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Import file has no rows", nil)
+		return
+	}
+	if len(rows) > maxImportRows {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Import is limited to %d rows", maxImportRows), nil)
+		return
+	}
+
+	items, results, rowErrors, err := pc.prepareProductImport(rows)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check import rows", err)
+		return
+	}
+
+	created, updated := 0, 0
+	for _, item := range items {
+		if item.Action == models.ImportCreate {
+			created++
+		} else {
+			updated++
+		}
+	}
+	response := map[string]interface{}{
+		"dry_run": dryRun,
+		"format":  format,
+		"total":   len(rows),
+		"created": created,
+		"updated": updated,
+		"valid":   len(rowErrors) == 0,
+		"rows":    results,
+		"errors":  rowErrors,
+	}
+	if dryRun {
+		respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+	if len(rowErrors) > 0 {
+		response["success"] = false
+		response["error"] = "Import rejected: fix the listed rows and try again"
+		respondWithJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := models.ApplyProductImport(pc.DB, items); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to import products", err)
+		return
+	}
+
+	var createdIDs, updatedIDs []int
+	for i, item := range items {
+		results[i].ProductID = item.Product.ID
+		if item.Action == models.ImportCreate {
+			createdIDs = append(createdIDs, item.Product.ID)
+		} else {
+			updatedIDs = append(updatedIDs, item.Product.ID)
+		}
+	}
+	changes := map[string]interface{}{
+		"action":  "imported",
+		"format":  format,
+		"rows":    len(items),
+		"created": createdIDs,
+		"updated": updatedIDs,
+	}
+	go models.CreateBatchLogEntry(pc.DB, getAdminIDFromContext(r), "IMPORT", changes)
+	invalidateProductMatcher()
+	syncProductAvailability(pc.DB, 0)
+
+	response["success"] = true
+	response["message"] = fmt.Sprintf("Imported %d product(s): %d created, %d updated", len(items), created, updated)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// prepareProductImport matches and validates every row; items and results are only for valid rows
+func (pc *ProductController) prepareProductImport(rows []importRow) ([]models.ImportItem, []importRowResult, []importRowError, error) {
+	items := []models.ImportItem{}
+	results := []importRowResult{}
+	rowErrors := []importRowError{}
+	categories := map[string]*models.Category{}
+	seenKeys := map[string]int{}  // sku/name key -> row
+	seenProducts := map[int]int{} // matched product ID -> row
+	seenSKUs := map[string]int{}  // resulting SKU -> row
+
+	for _, row := range rows {
+		fail := func(msg string) {
+			rowErrors = append(rowErrors, importRowError{Row: row.Row, Error: msg})
+		}
+
+		sku := strings.TrimSpace(row.Fields["sku"])
+		name := strings.TrimSpace(row.Fields["name"])
+		if sku == "" && name == "" {
+			fail("sku or name is required")
+			continue
+		}
+		key := "name:" + strings.ToLower(name)
+		if sku != "" {
+			key = "sku:" + strings.ToLower(sku)
+		}
+		if first, dup := seenKeys[key]; dup {
+			fail(fmt.Sprintf("duplicate of row %d", first))
+			continue
+		}
+		seenKeys[key] = row.Row
+
+		existing, err := models.FindProductForImport(pc.DB, sku, name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		item := models.ImportItem{Row: row.Row, Action: models.ImportCreate}
+		if existing != nil {
+			if first, dup := seenProducts[existing.ID]; dup {
+				fail(fmt.Sprintf("updates the same product as row %d", first))
+				continue
+			}
+			seenProducts[existing.ID] = row.Row
+			item.Action = models.ImportUpdate
+			item.Product = *existing
+		} else {
+			item.Product = models.Product{
+				LowStockThreshold:  models.DefaultLowStockThreshold,
+				OutOfStockBehavior: models.OutOfStockNone,
+				Status:             "draft",
+			}
+		}
+
+		if err := applyImportFields(&item.Product, row.Fields); err != nil {
+			fail(err.Error())
+			continue
+		}
+
+		// Category by name (cached for the batch)
+		if categoryName, ok := row.Fields["category"]; ok || existing == nil {
+			lookup := strings.ToLower(strings.TrimSpace(categoryName))
+			category, cached := categories[lookup]
+			if !cached && lookup != "" {
+				if category, err = models.GetCategoryByName(pc.DB, lookup); err != nil {
+					return nil, nil, nil, err
+				}
+				categories[lookup] = category
+			}
+			if lookup == "" {
+				fail("category is required")
+				continue
+			}
+			if category == nil {
+				fail(fmt.Sprintf("unknown category %q", strings.TrimSpace(categoryName)))
+				continue
+			}
+			item.Product.CategoryID, item.Product.Category = category.ID, category.Name
+		}
+
+		if err := item.Product.Validate(); err != nil {
+			fail(err.Error())
+			continue
+		}
+		if item.Product.Status == "" {
+			item.Product.Status = "draft"
+		}
+
+		if s := strings.ToLower(item.Product.SKU); s != "" {
+			if first, dup := seenSKUs[s]; dup {
+				fail(fmt.Sprintf("SKU %s is also used by row %d", item.Product.SKU, first))
+				continue
+			}
+			seenSKUs[s] = row.Row
+			taken, err := models.SKUTaken(pc.DB, item.Product.SKU, item.Product.ID)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if taken {
+				fail(fmt.Sprintf("SKU %s is already used by another product", item.Product.SKU))
+				continue
+			}
+		}
+
+		items = append(items, item)
+		results = append(results, importRowResult{
+			Row:       row.Row,
+			Action:    item.Action,
+			ProductID: item.Product.ID,
+			SKU:       item.Product.SKU,
+			Name:      item.Product.Name,
+		})
+	}
+	return items, results, rowErrors, nil
+}
+
+// applyImportFields copies the fields present in a row onto the product
+func applyImportFields(p *models.Product, fields map[string]string) error {
+	for field, value := range fields {
+		value = strings.TrimSpace(value)
+		switch field {
+		case "sku":
+			// A blank cell keeps the SKU of a product matched by name
+			if value != "" {
+				p.SKU = value
+			}
+		case "name":
+			p.Name = value
+		case "description":
+			p.Description = value
+		case "image_url":
+			p.ImageURL = value
+		case "status":
+			p.Status = strings.ToLower(value)
+		case "out_of_stock_behavior":
+			p.OutOfStockBehavior = strings.ToLower(value)
+		case "price":
+			price, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+			if err != nil {
+				return fmt.Errorf("price %q is not a number", value)
+			}
+			p.Price = price
+		case "stock", "low_stock_threshold":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s %q is not a whole number", field, value)
+			}
+			if field == "stock" {
+				p.Stock = n
+			} else {
+				p.LowStockThreshold = n
+			}
+		}
+	}
+	return nil
+}
+
+// parseProductCSV reads a CSV with a header row naming productColumns (other columns are ignored)
+func parseProductCSV(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[int]string{}
+	known := map[string]bool{}
+	for _, c := range productColumns {
+		known[c] = true
+	}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")))
+		if known[h] {
+			columns[i] = h
+		}
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("CSV header has none of the columns " + strings.Join(productColumns, ", "))
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]string{}
+		blank := true
+		for i, value := range record {
+			if name, ok := columns[i]; ok {
+				fields[name] = value
+				if strings.TrimSpace(value) != "" {
+					blank = false
+				}
+			}
+		}
+		if blank {
+			continue
+		}
+		rows = append(rows, importRow{Row: line, Fields: fields})
+	}
+	return rows, nil
+}
+
+// parseProductJSON reads an array of product objects, or {"products": [...]} as in the API
+func parseProductJSON(r io.Reader) ([]importRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]interface{}
+	if err := decodeJSONNumbers(data, &objects); err != nil {
+		var wrapped struct {
+			Products []map[string]interface{} `json:"products"`
+		}
+		if decodeJSONNumbers(data, &wrapped) != nil {
+			return nil, err
+		}
+		objects = wrapped.Products
+	}
+
+	known := map[string]bool{}
+	for _, c := range productColumns {
+		known[c] = true
+	}
+	rows := make([]importRow, 0, len(objects))
+	for i, obj := range objects {
+		fields := map[string]string{}
+		for key, value := range obj {
+			if !known[key] || value == nil {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				fields[key] = v
+			case json.Number:
+				fields[key] = v.String()
+			default:
+				fields[key] = fmt.Sprint(v) // reported by validation if it is not usable
+			}
+		}
+		rows = append(rows, importRow{Row: i + 1, Fields: fields})
+	}
+	return rows, nil
+}
+
+// decodeJSONNumbers decodes keeping numbers as json.Number so they round-trip exactly
+func decodeJSONNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+)
+
+// productMatcherTTL is how long the matcher is reused before being rebuilt from the DB.
+// Admin product/alias changes invalidate it immediately; the TTL covers direct DB edits.
+const productMatcherTTL = 5 * time.Minute
+
+// productMatcherLimit caps how many active products are loaded into the matcher
+const productMatcherLimit = 500
+
+// maxSuggestions is the number of "Did you mean…?" quick replies offered
+const maxSuggestions = 5
+
+// matchTerm is a searchable name (product name, localized name or alias) for one product
+type matchTerm struct {
+	text   string   // normalized full term
+	tokens []string // normalized words of the term
+}
+
+// matchEntry is one active product with all the names it can be recognised by
+type matchEntry struct {
+	ProductID  int
+	Name       string
+	CategoryID int
+	Category   string
+	Price      float64
+	terms      []matchTerm
+	windows    []models.AvailabilityWindow // checked at match time; empty = all day
+}
+
+// ProductMatch is a candidate product found in a customer message
+type ProductMatch struct {
+	ProductID  int
+	Name       string
+	CategoryID int
+	Category   string
+	Price      float64
+	Score      float64 // 0..1, 1 = exact name/alias found in message
+}
+
+// ProductMatcher recognises products from the live catalog in free text
+type ProductMatcher struct {
+	entries []matchEntry
+	builtAt time.Time
+}
+
+var (
+	productMatcher      *ProductMatcher
+	productMatcherMutex sync.Mutex
+)
+
+// getProductMatcher returns the cached matcher, rebuilding it if stale or invalidated
+func getProductMatcher() *ProductMatcher {
+	productMatcherMutex.Lock()
+	defer productMatcherMutex.Unlock()
+
+	if productMatcher != nil && time.Since(productMatcher.builtAt) < productMatcherTTL {
+		return productMatcher
+	}
+
+	m, err := buildProductMatcher()
+	if err != nil {
+		log.Printf("⚠️ Failed to build product matcher: %v", err)
+		if productMatcher != nil {
+			// Keep serving the previous catalog rather than matching nothing
+			return productMatcher
+		}
+		return &ProductMatcher{}
+	}
+	productMatcher = m
+	log.Printf("🔎 Product matcher built with %d products", len(m.entries))
+	return productMatcher
+}
+
+// invalidateProductMatcher forces a rebuild on next use (call after catalog changes)
+func invalidateProductMatcher() {
+	productMatcherMutex.Lock()
+	defer productMatcherMutex.Unlock()
+	productMatcher = nil
+}
+
+// buildProductMatcher loads active products and their aliases from the DB
+func buildProductMatcher() (*ProductMatcher, error) {
+	if configs.DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	products, err := models.GetActiveProducts(configs.DB, productMatcherLimit, 0, 0, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := models.GetActiveProductAliases(configs.DB)
+	if err != nil {
+		// Aliases are optional (table may not be migrated yet); match on names only
+		log.Printf("⚠️ Could not load product aliases: %v", err)
+		aliases = map[int][]string{}
+	}
+	windows, err := models.GetAllAvailabilityWindows(configs.DB)
+	if err != nil {
+		log.Printf("⚠️ Could not load product availability windows: %v", err)
+		windows = map[int][]models.AvailabilityWindow{}
+	}
+
+	m := &ProductMatcher{builtAt: time.Now()}
+	for _, p := range products {
+		if p.IsSoldOut() {
+			continue // listed in the carousel with a badge, but cannot be ordered
+		}
+		entry := matchEntry{ProductID: p.ID, Name: p.Name, CategoryID: p.CategoryID, Category: p.Category, Price: p.Price, windows: windows[p.ID]}
+		names := append([]string{p.Name}, aliases[p.ID]...)
+		for _, localized := range p.Names {
+			names = append(names, localized)
+		}
+		for _, name := range names {
+			text := normalizeMatchText(name)
+			if text == "" {
+				continue
+			}
+			entry.terms = append(entry.terms, matchTerm{text: text, tokens: strings.Fields(text)})
+		}
+		m.entries = append(m.entries, entry)
+	}
+	return m, nil
+}
+
+// Match returns candidate products for a message, best first
+func (m *ProductMatcher) Match(message string) []ProductMatch {
+	msg := normalizeMatchText(message)
+	if msg == "" {
+		return nil
+	}
+	msgTokens := strings.Fields(msg)
+	now := time.Now().In(shopLocation())
+
+	var matches []ProductMatch
+	for _, e := range m.entries {
+		if !models.AvailableAt(e.windows, now) {
+			continue // outside its availability window (e.g. breakfast items in the afternoon)
+		}
+		best := 0.0
+		for _, t := range e.terms {
+			if s := scoreTerm(msg, msgTokens, t); s > best {
+				best = s
+			}
+		}
+		if best >= 0.5 {
+			matches = append(matches, ProductMatch{ProductID: e.ProductID, Name: e.Name, CategoryID: e.CategoryID, Category: e.Category, Price: e.Price, Score: best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// Resolve picks a single product when the match is unambiguous.
+// Returns (match, nil) for a clear winner, or (nil, suggestions) when the customer should choose.
+func (m *ProductMatcher) Resolve(message string) (*ProductMatch, []ProductMatch) {
+	matches := m.Match(message)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	top := matches[0]
+	// A confident match that clearly beats the runner-up is taken directly
+	if top.Score >= 0.75 && (len(matches) == 1 || top.Score-matches[1].Score >= 0.2) {
+		return &top, nil
+	}
+
+	// Otherwise offer the close candidates
+	var suggestions []ProductMatch
+	for _, c := range matches {
+		if top.Score-c.Score > 0.25 || len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, c)
+	}
+	return nil, suggestions
+}
+
+// CategoryOf returns the category of an active product by exact name ("" if unknown)
+func (m *ProductMatcher) CategoryOf(name string) string {
+	for _, e := range m.entries {
+		if strings.EqualFold(e.Name, name) {
+			return e.Category
+		}
+	}
+	return ""
+}
+
+// scoreTerm scores how well a product term appears in a message (0..1)
+func scoreTerm(msg string, msgTokens []string, t matchTerm) float64 {
+	// Whole name/alias appears as words ("tart" is not found in "start")
+	if containsTerm(msg, t.text) {
+		return 1.0
+	}
+
+	// Otherwise compare word by word, tolerating typos
+	total := 0.0
+	for _, tt := range t.tokens {
+		best := 0.0
+		for _, mt := range msgTokens {
+			if s := tokenSimilarity(tt, mt); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(t.tokens))
+}
+
+// containsTerm reports whether a normalized term appears in a normalized message as whole
+// words. Burmese and Thai are written without spaces between words, so terms in those
+// scripts match anywhere, and their letters also end a Latin word ("cakeပေး").
+func containsTerm(msg, term string) bool {
+	if strings.IndexFunc(term, isUnspacedScript) >= 0 {
+		return strings.Contains(msg, term)
+	}
+	for offset := 0; offset < len(msg); {
+		i := strings.Index(msg[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before, _ := utf8.DecodeLastRuneInString(msg[:start])
+		after, _ := utf8.DecodeRuneInString(msg[end:])
+		if (start == 0 || isTermBoundary(before)) && (end == len(msg) || isTermBoundary(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(msg[start:])
+		offset = start + size
+	}
+	return false
+}
+
+// isUnspacedScript reports whether r belongs to a script written without spaces between words
+func isUnspacedScript(r rune) bool {
+	return unicode.In(r, unicode.Myanmar, unicode.Thai)
+}
+
+// isTermBoundary reports whether r can separate a Latin word from its neighbours
+func isTermBoundary(r rune) bool {
+	return r == ' ' || isUnspacedScript(r)
+}
+
+// tokenSimilarity compares two words, returning 0 if they are too different to count as a typo
+func tokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1.0
+	}
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	maxLen := la
+	if lb > maxLen {
+		maxLen = lb
+	}
+	// Short words must match exactly ("pie" vs "tie" is not a typo)
+	if maxLen <= 3 {
+		return 0
+	}
+	// Typos rarely hit the first letter, while different words often differ there ("start" vs "tart")
+	ra, _ := utf8.DecodeRuneInString(a)
+	rb, _ := utf8.DecodeRuneInString(b)
+	if ra != rb {
+		return 0
+	}
+	// Allow plurals/prefixes like "croissants" or "choco"
+	if la >= 4 && lb >= 4 && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+		return 0.9
+	}
+
+	// One edit for medium words, two for longer ones
+	allowed := 1
+	if maxLen >= 7 {
+		allowed = 2
+	}
+	d := levenshtein(a, b)
+	if d > allowed {
+		return 0
+	}
+	return 1.0 - float64(d)/float64(maxLen)
+}
+
+// levenshtein computes the edit distance between two strings (rune-aware)
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// normalizeMatchText lowercases text and turns punctuation into spaces
+func normalizeMatchText(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Map(func(r rune) rune {
+		// Keep letters, digits and combining marks (Burmese vowels/tones are marks)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// askProductSuggestions sends a "Did you mean…?" prompt with one quick reply per candidate
+func askProductSuggestions(userID string, suggestions []ProductMatch) {
+	state := GetUserState(userID)
+
+	var quickReplies []QuickReply
+	for _, s := range suggestions {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       truncateTitle(s.Name, 20),
+			Payload:     fmt.Sprintf("ORDER_PRODUCT_%d", s.ProductID),
+		})
+	}
+	quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "button.menu"), Payload: "MENU_ORDER_PRODUCTS"})
+
+	SendQuickReplies(userID, tr(state, "match.did_you_mean"), quickReplies)
+}
+
+// truncateTitle shortens a button title to Messenger's character limit
+func truncateTitle(s string, limit int) string {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s
+	}
+	return string(r[:limit-1]) + "…"
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bakeflow/models"
+
+	"github.com/gorilla/mux"
+)
+
+// pathIDs parses the numeric mux vars named in keys
+func pathIDs(r *http.Request, keys ...string) ([]int, error) {
+	vars := mux.Vars(r)
+	ids := make([]int, len(keys))
+	for i, key := range keys {
+		id, err := strconv.Atoi(vars[key])
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// GetProductOptions handles GET /api/products/:id/options - option groups with their choices
+func (pc *ProductController) GetProductOptions(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	groups, err := models.GetProductOptionGroups(pc.DB, ids[0])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch options", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id": ids[0],
+		"groups":     groups,
+		"count":      len(groups),
+	})
+}
+
+// CreateProductOptionGroup handles POST /api/products/:id/option-groups
+func (pc *ProductController) CreateProductOptionGroup(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	group := models.ProductOptionGroup{Required: true}
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	group.ProductID = ids[0]
+	if err := group.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, group.ProductID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	if err := models.CreateProductOptionGroup(pc.DB, &group); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to create option group", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action": "option_group_added",
+		"group":  group,
+	}
+	go models.CreateLogEntry(pc.DB, group.ProductID, getAdminIDFromContext(r), "OPTION_GROUP_ADD", changes)
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Option group created successfully",
+		"group":   group,
+	})
+}
+
+// UpdateProductOptionGroup handles PUT /api/products/:id/option-groups/:groupId
+func (pc *ProductController) UpdateProductOptionGroup(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "groupId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product or group ID", err)
+		return
+	}
+
+	existing, err := models.GetProductOptionGroup(pc.DB, ids[0], ids[1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch option group", err)
+		return
+	}
+	if existing == nil {
+		respondWithError(w, http.StatusNotFound, "Option group not found", nil)
+		return
+	}
+
+	group := *existing
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	group.ID, group.ProductID, group.Kind = existing.ID, existing.ProductID, existing.Kind
+	if err := group.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if _, err := models.UpdateProductOptionGroup(pc.DB, &group); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update option group", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action": "option_group_updated",
+		"old":    existing,
+		"new":    group,
+	}
+	go models.CreateLogEntry(pc.DB, group.ProductID, getAdminIDFromContext(r), "OPTION_GROUP_UPDATE", changes)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Option group updated successfully",
+		"group":   group,
+	})
+}
+
+// DeleteProductOptionGroup handles DELETE /api/products/:id/option-groups/:groupId
+func (pc *ProductController) DeleteProductOptionGroup(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "groupId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product or group ID", err)
+		return
+	}
+
+	deleted, err := models.DeleteProductOptionGroup(pc.DB, ids[0], ids[1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete option group", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Option group not found", nil)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action":   "option_group_removed",
+		"group_id": ids[1],
+	}
+	go models.CreateLogEntry(pc.DB, ids[0], getAdminIDFromContext(r), "OPTION_GROUP_DELETE", changes)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Option group deleted successfully",
+	})
+}
+
+// CreateProductOption handles POST /api/products/:id/option-groups/:groupId/options
+func (pc *ProductController) CreateProductOption(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "groupId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product or group ID", err)
+		return
+	}
+
+	group, err := models.GetProductOptionGroup(pc.DB, ids[0], ids[1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch option group", err)
+		return
+	}
+	if group == nil {
+		respondWithError(w, http.StatusNotFound, "Option group not found", nil)
+		return
+	}
+	if group.Kind != models.OptionGroupChoice {
+		respondWithError(w, http.StatusBadRequest, "Inscription groups have no options", nil)
+		return
+	}
+
+	option := models.ProductOption{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	option.GroupID = group.ID
+	if err := option.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := models.CreateProductOption(pc.DB, &option); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create option", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action": "option_added",
+		"group":  group.Name,
+		"option": option,
+	}
+	go models.CreateLogEntry(pc.DB, group.ProductID, getAdminIDFromContext(r), "OPTION_ADD", changes)
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Option created successfully",
+		"option":  option,
+	})
+}
+
+// UpdateProductOption handles PUT /api/products/:id/option-groups/:groupId/options/:optionId
+// The body replaces the option; send "stock": null to stop tracking its stock
+func (pc *ProductController) UpdateProductOption(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "groupId", "optionId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product, group or option ID", err)
+		return
+	}
+
+	group, err := models.GetProductOptionGroup(pc.DB, ids[0], ids[1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch option group", err)
+		return
+	}
+	if group == nil {
+		respondWithError(w, http.StatusNotFound, "Option group not found", nil)
+		return
+	}
+
+	option := models.ProductOption{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	option.ID, option.GroupID = ids[2], group.ID
+	if err := option.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	found, err := models.UpdateProductOption(pc.DB, &option)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update option", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Option not found", nil)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action": "option_updated",
+		"group":  group.Name,
+		"option": option,
+	}
+	go models.CreateLogEntry(pc.DB, group.ProductID, getAdminIDFromContext(r), "OPTION_UPDATE", changes)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Option updated successfully",
+		"option":  option,
+	})
+}
+
+// DeleteProductOption handles DELETE /api/products/:id/option-groups/:groupId/options/:optionId
+func (pc *ProductController) DeleteProductOption(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id", "groupId", "optionId")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product, group or option ID", err)
+		return
+	}
+
+	group, err := models.GetProductOptionGroup(pc.DB, ids[0], ids[1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch option group", err)
+		return
+	}
+	if group == nil {
+		respondWithError(w, http.StatusNotFound, "Option group not found", nil)
+		return
+	}
+
+	deleted, err := models.DeleteProductOption(pc.DB, group.ID, ids[2])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete option", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Option not found", nil)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action":    "option_removed",
+		"group":     group.Name,
+		"option_id": ids[2],
+	}
+	go models.CreateLogEntry(pc.DB, group.ProductID, getAdminIDFromContext(r), "OPTION_DELETE", changes)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Option deleted successfully",
+	})
+}
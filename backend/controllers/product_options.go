@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// maxOptionReplies leaves room for Skip/Back/Cancel within Messenger's 13 quick replies
+const maxOptionReplies = 10
+
+// startProductOptions begins customizing a product chosen from the catalog:
+// each option group is asked in turn, then the quantity (unless already known, 0 = ask)
+func startProductOptions(userID string, p *models.Product, quantity int) {
+	state := GetUserState(userID)
+	state.CurrentProduct = p.Name
+	state.CurrentEmoji = categoryEmoji(p.CategoryID)
+	state.CurrentQuantity = quantity
+	state.CurrentProductID = p.ID
+	state.CurrentPrice = p.Price
+	state.CurrentOptions = nil
+	state.CurrentInscription = ""
+	state.OptionStep = 0
+	askProductOptions(userID)
+}
+
+// hasOptionGroups reports whether a catalog product must be customized before ordering
+func hasOptionGroups(productID int) bool {
+	if configs.DB == nil || productID == 0 {
+		return false
+	}
+	groups, err := models.GetProductOptionGroups(configs.DB, productID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load options for product %d: %v", productID, err)
+		return false
+	}
+	return len(groups) > 0
+}
+
+// customizeNextQueuedItem starts the option flow for the next parsed item waiting in
+// OptionQueue; returns false when there is none left
+func customizeNextQueuedItem(userID string) bool {
+	state := GetUserState(userID)
+	for len(state.OptionQueue) > 0 {
+		item := state.OptionQueue[0]
+		state.OptionQueue = state.OptionQueue[1:]
+
+		p := orderableProduct(userID, item)
+		if p == nil {
+			continue
+		}
+		startProductOptions(userID, p, item.Quantity)
+		return true
+	}
+	return false
+}
+
+// orderableProduct reloads the catalog product of a parsed item and tells the customer when
+// it can no longer be ordered (deleted, unpublished, sold out or outside its hours)
+func orderableProduct(userID string, item CartItem) *models.Product {
+	state := GetUserState(userID)
+	p, err := models.GetProductByID(configs.DB, item.ProductID)
+	if err != nil || p == nil || p.IsSoldOut() || p.AutoHidden {
+		SendMessage(userID, tr(state, "product.sold_out", i18n.Args{"product": item.Product}))
+		return nil
+	}
+	if p.Status != "active" {
+		SendMessage(userID, tr(state, "product.unavailable_now", i18n.Args{"product": item.Product}))
+		return nil
+	}
+	if windows, err := models.GetAvailabilityWindows(configs.DB, p.ID); err == nil && !models.AvailableAt(windows, time.Now().In(shopLocation())) {
+		SendMessage(userID, tr(state, "product.unavailable_now", i18n.Args{"product": item.Product}))
+		return nil
+	}
+	return p
+}
+
+// askProductOptions asks the next unanswered option group, or the quantity when all are done
+func askProductOptions(userID string) {
+	state := GetUserState(userID)
+
+	var groups []models.ProductOptionGroup
+	if configs.DB != nil && state.CurrentProductID != 0 {
+		var err error
+		groups, err = models.GetProductOptionGroups(configs.DB, state.CurrentProductID)
+		if err != nil {
+			log.Printf("⚠️ Failed to load options for product %d: %v", state.CurrentProductID, err)
+			groups = nil
+		}
+	}
+
+	for state.OptionStep < len(groups) {
+		g := groups[state.OptionStep]
+		if g.Kind == models.OptionGroupInscription {
+			askInscription(userID, g)
+			return
+		}
+
+		var available []models.ProductOption
+		for _, o := range g.Options {
+			if o.Available() {
+				available = append(available, o)
+			}
+		}
+		if len(available) == 0 {
+			if g.Required {
+				// e.g. every size sold out: the product cannot be ordered
+				SendMessage(userID, tr(state, "product.sold_out", i18n.Args{"product": state.CurrentProduct}))
+				if !customizeNextQueuedItem(userID) {
+					showProducts(userID)
+				}
+				return
+			}
+			state.OptionStep++
+			continue
+		}
+		askOptionChoice(userID, g, available)
+		return
+	}
+
+	if state.CurrentQuantity > 0 {
+		// Quantity already typed in a free-text order
+		addToCart(userID)
+		return
+	}
+	state.State = "awaiting_quantity"
+	askQuantity(userID)
+}
+
+// askOptionChoice shows the choices of one group as quick replies (OPTION_<group>_<option>)
+func askOptionChoice(userID string, g models.ProductOptionGroup, available []models.ProductOption) {
+	state := GetUserState(userID)
+	state.State = "awaiting_option"
+
+	if len(available) > maxOptionReplies {
+		available = available[:maxOptionReplies]
+	}
+	var quickReplies []QuickReply
+	for _, o := range available {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       o.Label(),
+			Payload:     fmt.Sprintf("OPTION_%d_%d", g.ID, o.ID),
+		})
+	}
+	if !g.Required {
+		quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "button.skip"), Payload: fmt.Sprintf("OPTION_%d_0", g.ID)})
+	}
+	quickReplies = append(quickReplies,
+		QuickReply{ContentType: "text", Title: tr(state, "button.back"), Payload: "GO_BACK"},
+		QuickReply{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
+	)
+
+	SendQuickReplies(userID, tr(state, "option.ask", i18n.Args{
+		"emoji":   state.CurrentEmoji,
+		"product": state.CurrentProduct,
+		"group":   g.Name,
+	}), quickReplies)
+}
+
+// askInscription asks for the free-text message written on the product
+func askInscription(userID string, g models.ProductOptionGroup) {
+	state := GetUserState(userID)
+	state.State = "awaiting_inscription"
+
+	var quickReplies []QuickReply
+	if !g.Required {
+		quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "option.no_message"), Payload: "INSCRIPTION_SKIP"})
+	}
+	quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"})
+
+	SendQuickReplies(userID, tr(state, "option.ask_inscription", i18n.Args{
+		"emoji":   state.CurrentEmoji,
+		"product": state.CurrentProduct,
+		"max":     g.MaxLength,
+	}), quickReplies)
+}
+
+// currentOptionGroup returns the group being asked, or nil if the flow moved on
+func currentOptionGroup(state *UserState) *models.ProductOptionGroup {
+	if configs.DB == nil || state.CurrentProductID == 0 {
+		return nil
+	}
+	groups, err := models.GetProductOptionGroups(configs.DB, state.CurrentProductID)
+	if err != nil || state.OptionStep >= len(groups) {
+		return nil
+	}
+	return &groups[state.OptionStep]
+}
+
+// handleOptionPostback records a choice from OPTION_<groupID>_<optionID> (option 0 = skip)
+func handleOptionPostback(userID, payload string) {
+	state := GetUserState(userID)
+	if state.State != "awaiting_option" {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(payload, "OPTION_"), "_")
+	if len(parts) != 2 {
+		return
+	}
+	groupID, err1 := strconv.Atoi(parts[0])
+	optionID, err2 := strconv.Atoi(parts[1])
+	g := currentOptionGroup(state)
+	if err1 != nil || err2 != nil || g == nil || g.ID != groupID {
+		// Stale button from an earlier question
+		askProductOptions(userID)
+		return
+	}
+
+	if optionID == 0 {
+		if g.Required {
+			askProductOptions(userID)
+			return
+		}
+	} else {
+		var chosen *models.ProductOption
+		for i := range g.Options {
+			if g.Options[i].ID == optionID && g.Options[i].Available() {
+				chosen = &g.Options[i]
+			}
+		}
+		if chosen == nil {
+			SendMessage(userID, tr(state, "option.unavailable"))
+			askProductOptions(userID)
+			return
+		}
+		state.CurrentOptions = append(state.CurrentOptions, models.OrderItemOption{
+			GroupID:    g.ID,
+			Group:      g.Name,
+			OptionID:   chosen.ID,
+			Option:     chosen.Name,
+			PriceDelta: chosen.PriceDelta,
+		})
+		state.CurrentPrice += chosen.PriceDelta
+	}
+
+	state.OptionStep++
+	askProductOptions(userID)
+}
+
+// handleInscriptionText stores the typed message (or asks again if it does not fit)
+func handleInscriptionText(userID, text string) {
+	state := GetUserState(userID)
+	g := currentOptionGroup(state)
+	if g == nil || g.Kind != models.OptionGroupInscription {
+		askProductOptions(userID)
+		return
+	}
+
+	inscription, err := g.ValidateInscription(text)
+	if err != nil {
+		SendMessage(userID, tr(state, "option.inscription_too_long", i18n.Args{"max": g.MaxLength}))
+		askInscription(userID, *g)
+		return
+	}
+	state.CurrentInscription = inscription
+	state.OptionStep++
+	askProductOptions(userID)
+}
+
+// skipInscription handles INSCRIPTION_SKIP for optional messages
+func skipInscription(userID string) {
+	state := GetUserState(userID)
+	if state.State != "awaiting_inscription" {
+		return
+	}
+	if g := currentOptionGroup(state); g != nil && g.Required {
+		askInscription(userID, *g)
+		return
+	}
+	state.OptionStep++
+	askProductOptions(userID)
+}
+
+// optionSummary joins chosen option names, e.g. `8", Chocolate`
+func optionSummary(options []models.OrderItemOption) string {
+	names := make([]string, 0, len(options))
+	for _, o := range options {
+		names = append(names, o.Option)
+	}
+	return strings.Join(names, ", ")
+}
+
+// cartItemLabel is the product name with its chosen options and inscription for chat display
+func cartItemLabel(state *UserState, item CartItem) string {
+	label := item.Product
+	if len(item.Options) > 0 {
+		label += " (" + optionSummary(item.Options) + ")"
+	}
+	if item.Inscription != "" {
+		label += "\n   " + tr(state, "cart.inscription", i18n.Args{"text": item.Inscription})
+	}
+	return label
+}
+
+// currentItemLabel is cartItemLabel for the product still being customized
+func currentItemLabel(state *UserState) string {
+	return cartItemLabel(state, CartItem{Product: state.CurrentProduct, Options: state.CurrentOptions, Inscription: state.CurrentInscription})
+}
+
+// clearCurrentItem forgets the product being customized
+func clearCurrentItem(state *UserState) {
+	state.CurrentProduct = ""
+	state.CurrentEmoji = ""
+	state.CurrentQuantity = 0
+	state.CurrentProductID = 0
+	state.CurrentPrice = 0
+	state.CurrentOptions = nil
+	state.CurrentInscription = ""
+	state.OptionStep = 0
+}
+
+// dropUnavailableItems removes cart items whose chosen options sold out and returns them
+func dropUnavailableItems(state *UserState) []CartItem {
+	if configs.DB == nil {
+		return nil
+	}
+	var kept, dropped []CartItem
+	for _, item := range state.Cart {
+		ok, err := models.OptionsAvailable(configs.DB, item.Options, item.Quantity)
+		if err != nil {
+			log.Printf("⚠️ Failed to check options for %s: %v", item.Product, err)
+			ok = true // let the order transaction decide
+		}
+		if ok {
+			kept = append(kept, item)
+		} else {
+			dropped = append(dropped, item)
+		}
+	}
+	state.Cart = kept
+	return dropped
+}
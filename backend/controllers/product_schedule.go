@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+)
+
+// productScheduleInterval is how often publish_at/unpublish_at are checked
+const productScheduleInterval = time.Minute
+
+// StartProductScheduler publishes and unpublishes products at their scheduled times.
+// Availability windows need no job: they are checked whenever the catalog is shown.
+func StartProductScheduler() {
+	go func() {
+		for {
+			applyProductSchedule()
+			time.Sleep(productScheduleInterval)
+		}
+	}()
+	log.Printf("✅ Product scheduler running every %v", productScheduleInterval)
+}
+
+// applyProductSchedule runs one scheduling pass and refreshes the chat catalog on change
+func applyProductSchedule() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic recovered in product scheduler: %v", r)
+		}
+	}()
+	if configs.DB == nil {
+		return
+	}
+
+	changed, err := models.ApplyProductSchedule(configs.DB)
+	if len(changed) > 0 {
+		log.Printf("🗓️ Scheduled status change for products %v", changed)
+		invalidateProductMatcher()
+		// A published product may be out of stock already
+		syncProductAvailability(configs.DB, 0)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to apply product schedule: %v", err)
+	}
+}
+
+// GetProductAvailability handles GET /api/products/:id/availability - weekly availability windows
+func (pc *ProductController) GetProductAvailability(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	product, err := models.GetProductByID(pc.DB, ids[0])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	windows, err := models.GetAvailabilityWindows(pc.DB, product.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch availability", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id":    product.ID,
+		"windows":       windows,
+		"available_now": models.AvailableAt(windows, time.Now().In(shopLocation())),
+		"publish_at":    product.PublishAt,
+		"unpublish_at":  product.UnpublishAt,
+		"timezone":      shopLocation().String(),
+	})
+}
+
+// UpdateProductAvailability handles PUT /api/products/:id/availability - replace the windows.
+// Body: {"windows": [{"weekday": 1, "start_time": "06:00", "end_time": "11:00"}]}; an empty
+// list makes the product available whenever the shop is open.
+func (pc *ProductController) UpdateProductAvailability(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	id := ids[0]
+
+	var req struct {
+		Windows []models.AvailabilityWindow `json:"windows"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	for i := range req.Windows {
+		if err := req.Windows[i].Validate(); err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Window %d: %v", i+1, err), nil)
+			return
+		}
+	}
+
+	product, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	oldWindows, err := models.GetAvailabilityWindows(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch availability", err)
+		return
+	}
+
+	if err := models.SetAvailabilityWindows(pc.DB, id, req.Windows); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to save availability", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action":      "availability_updated",
+		"old_windows": oldWindows,
+		"new_windows": req.Windows,
+	}
+	go models.CreateLogEntry(pc.DB, id, getAdminIDFromContext(r), "AVAILABILITY_UPDATE", changes)
+	invalidateProductMatcher()
+
+	windows, err := models.GetAvailabilityWindows(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch availability", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success":       true,
+		"message":       "Availability saved successfully",
+		"windows":       windows,
+		"available_now": models.AvailableAt(windows, time.Now().In(shopLocation())),
+	})
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/models"
+
+	"github.com/lib/pq"
+)
+
+// productFieldChange is one changed field in a product log diff
+type productFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// productETag is the version of a product for If-Match; it changes with every update
+func productETag(p *models.Product) string {
+	return `"` + strconv.FormatInt(p.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+// ifMatch reports whether an If-Match header (absent, "*" or a list of ETags) accepts the version
+func ifMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlTimestamp formats a TIMESTAMP column value read back from the DB so it compares exactly,
+// independent of the session timezone
+func sqlTimestamp(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05.999999")
+}
+
+// loadProductForUpdate loads the product being changed and checks the client's If-Match header
+func (pc *ProductController) loadProductForUpdate(w http.ResponseWriter, r *http.Request, id int) (*models.Product, bool) {
+	product, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return nil, false
+	}
+	if product == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return nil, false
+	}
+	if !ifMatch(r.Header.Get("If-Match"), productETag(product)) {
+		respondProductConflict(w, product)
+		return nil, false
+	}
+	return product, true
+}
+
+// respondProductConflict reports a lost update with the current product so the client can merge
+func respondProductConflict(w http.ResponseWriter, current *models.Product) {
+	w.Header().Set("ETag", productETag(current))
+	respondWithJSON(w, http.StatusConflict, map[string]interface{}{
+		"success": false,
+		"error":   "Product was changed by someone else; reload it and try again",
+		"product": current,
+	})
+}
+
+// respondUpdateConflict handles an update that matched no row: the product was changed or
+// deleted between reading and writing it
+func (pc *ProductController) respondUpdateConflict(w http.ResponseWriter, id int) {
+	current, err := models.GetProductByID(pc.DB, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch product", err)
+		return
+	}
+	if current == nil {
+		respondWithError(w, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+	respondProductConflict(w, current)
+}
+
+// productFieldValues returns the admin-editable fields of a product by JSON name,
+// which is also the column name
+func productFieldValues(p *models.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"sku":                   p.SKU,
+		"name":                  p.Name,
+		"description":           p.Description,
+		"category_id":           p.CategoryID,
+		"category":              p.Category,
+		"price":                 p.Price,
+		"stock":                 p.Stock,
+		"low_stock_threshold":   p.LowStockThreshold,
+		"out_of_stock_behavior": p.OutOfStockBehavior,
+		"image_url":             p.ImageURL,
+		"status":                p.Status,
+		"publish_at":            p.PublishAt,
+		"unpublish_at":          p.UnpublishAt,
+		"names":                 p.Names,
+		"tags":                  p.Tags,
+	}
+}
+
+// productDiff lists the editable fields that differ between two versions of a product
+func productDiff(oldProduct, newProduct *models.Product) map[string]productFieldChange {
+	oldValues, newValues := productFieldValues(oldProduct), productFieldValues(newProduct)
+	diff := map[string]productFieldChange{}
+	for field, oldValue := range oldValues {
+		newValue := newValues[field]
+		if oldTime, ok := oldValue.(*time.Time); ok {
+			newTime := newValue.(*time.Time)
+			if (oldTime == nil) == (newTime == nil) && (oldTime == nil || oldTime.Equal(*newTime)) {
+				continue
+			}
+		} else if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		diff[field] = productFieldChange{Old: oldValue, New: newValue}
+	}
+	return diff
+}
+
+// PatchProduct handles PATCH /api/products/:id - change only the supplied fields.
+// Send If-Match with the ETag from GET (or "updated_at" in the body) to get a 409 instead of
+// overwriting someone else's change. The log entry records a field-level diff.
+func (pc *ProductController) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	ids, err := pathIDs(r, "id")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	id := ids[0]
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	editable := productFieldValues(&models.Product{})
+	for field := range fields {
+		if _, ok := editable[field]; !ok && field != "updated_at" {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown or read-only field %q", field), nil)
+			return
+		}
+	}
+
+	oldProduct, ok := pc.loadProductForUpdate(w, r, id)
+	if !ok {
+		return
+	}
+	product := *oldProduct
+	product.Names, product.Tags = nil, nil // decoding would merge into the shared map and slice
+	if _, ok := fields["category_id"]; !ok {
+		if _, ok := fields["category"]; ok {
+			product.CategoryID = 0 // resolve the new category by name
+		}
+	}
+	if err := json.Unmarshal(data, &product); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+	if !product.UpdatedAt.Equal(oldProduct.UpdatedAt) {
+		respondProductConflict(w, oldProduct)
+		return
+	}
+	if product.Names == nil {
+		product.Names = oldProduct.Names
+	}
+	if product.Tags == nil {
+		product.Tags = oldProduct.Tags
+	}
+	product.ID = id
+
+	_, categoryID := fields["category_id"]
+	_, category := fields["category"]
+	if (categoryID || category) && !pc.resolveProductCategory(w, &product) {
+		return
+	}
+	if err := product.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if !pc.checkSKUAvailable(w, product.SKU, id) {
+		return
+	}
+
+	diff := productDiff(oldProduct, &product)
+	if len(diff) == 0 {
+		w.Header().Set("ETag", productETag(oldProduct))
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "No changes",
+			"product": oldProduct,
+		})
+		return
+	}
+
+	// Only the changed columns are written, and only if nobody updated the product meanwhile
+	changed := make([]string, 0, len(diff))
+	for field := range diff {
+		changed = append(changed, field)
+	}
+	sort.Strings(changed)
+	var set []string
+	var args []interface{}
+	for _, field := range changed {
+		switch value := diff[field].New.(type) {
+		case map[string]string:
+			args = append(args, models.ProductNamesJSON(value))
+		case []string:
+			args = append(args, pq.Array(value))
+		default:
+			args = append(args, value)
+		}
+		placeholder := fmt.Sprintf("$%d", len(args))
+		if field == "sku" {
+			placeholder = "NULLIF(" + placeholder + ", '')"
+		}
+		set = append(set, field+" = "+placeholder)
+	}
+	if _, ok := diff["status"]; ok {
+		set = append(set, "auto_hidden = FALSE") // an admin status change takes over from the system
+	}
+	args = append(args, id, sqlTimestamp(oldProduct.UpdatedAt))
+	query := fmt.Sprintf(`
+		UPDATE products SET %s
+		WHERE id = $%d AND deleted_at IS NULL AND updated_at = $%d::timestamp
+		RETURNING updated_at, auto_hidden
+	`, strings.Join(set, ", "), len(args)-1, len(args))
+	err = pc.DB.QueryRow(query, args...).Scan(&product.UpdatedAt, &product.AutoHidden)
+	if err == sql.ErrNoRows {
+		pc.respondUpdateConflict(w, id)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update product", err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"action": "patched",
+		"diff":   diff,
+		"old":    oldProduct,
+		"new":    product,
+	}
+	go models.CreateLogEntry(pc.DB, id, getAdminIDFromContext(r), "UPDATE", changes)
+	invalidateProductMatcher()
+	if oldProduct.ImageURL != product.ImageURL {
+		pc.deleteUnusedImage(oldProduct.ImageURL)
+	}
+	if syncProductAvailability(pc.DB, id) {
+		if p, err := models.GetProductByID(pc.DB, id); err == nil && p != nil {
+			product = *p
+		}
+	}
+
+	w.Header().Set("ETag", productETag(&product))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Product updated successfully",
+		"changed": changed,
+		"product": product,
+	})
+}
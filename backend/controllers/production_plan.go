@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+)
+
+// productionLine is the total quantity of one product to bake
+type productionLine struct {
+	ProductID int    `json:"product_id,omitempty"` // 0 for items not linked to the catalog
+	Product   string `json:"product"`
+	Quantity  int    `json:"quantity"`
+	Orders    int    `json:"orders"`
+}
+
+// productionSlot groups the lines due in one time slot (ASAP orders have no start)
+type productionSlot struct {
+	Label    string           `json:"label"`
+	Start    *time.Time       `json:"start,omitempty"`
+	End      *time.Time       `json:"end,omitempty"`
+	Orders   int              `json:"orders"`
+	Products []productionLine `json:"products"`
+}
+
+// productionPlan is the kitchen's to-do list for a day or a single slot
+type productionPlan struct {
+	Date        string           `json:"date"`
+	Timezone    string           `json:"timezone"`
+	Slot        string           `json:"slot,omitempty"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Orders      int              `json:"orders"`
+	Products    []productionLine `json:"products"`
+	Slots       []productionSlot `json:"slots"`
+	// Ingredients needed for the products that have a recipe
+	Ingredients []models.IngredientNeed `json:"ingredients"`
+}
+
+// lineAggregator sums quantities per product and counts distinct orders. Items are grouped
+// by product ID, or by name for items not linked to the catalog.
+type lineAggregator struct {
+	lines  map[string]*productionLine
+	orders map[string]map[int]bool
+}
+
+func newLineAggregator() *lineAggregator {
+	return &lineAggregator{lines: map[string]*productionLine{}, orders: map[string]map[int]bool{}}
+}
+
+func (a *lineAggregator) add(it models.ProductionItem) {
+	key := "name:" + strings.ToLower(strings.TrimSpace(it.Product))
+	if it.ProductID != 0 {
+		key = "id:" + strconv.Itoa(it.ProductID)
+	}
+	l, ok := a.lines[key]
+	if !ok {
+		l = &productionLine{ProductID: it.ProductID, Product: it.Product}
+		a.lines[key] = l
+		a.orders[key] = map[int]bool{}
+	}
+	l.Quantity += it.Quantity
+	if !a.orders[key][it.OrderID] {
+		a.orders[key][it.OrderID] = true
+		l.Orders++
+	}
+}
+
+// result returns the lines, largest quantity first
+func (a *lineAggregator) result() []productionLine {
+	lines := make([]productionLine, 0, len(a.lines))
+	for _, l := range a.lines {
+		lines = append(lines, *l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Quantity != lines[j].Quantity {
+			return lines[i].Quantity > lines[j].Quantity
+		}
+		return lines[i].Product < lines[j].Product
+	})
+	return lines
+}
+
+// buildProductionPlan aggregates order items by product, overall and per slot.
+// slot ("HH:MM", optional) restricts the plan to orders whose slot starts then.
+func buildProductionPlan(items []models.ProductionItem, loc *time.Location, slot string) productionPlan {
+	plan := productionPlan{Timezone: loc.String(), Slot: slot, GeneratedAt: time.Now().In(loc)}
+
+	total := newLineAggregator()
+	orders := map[int]bool{}
+	var slots []*productionSlot
+	slotAgg := map[string]*lineAggregator{}
+	slotOrders := map[string]map[int]bool{}
+	bySlot := map[string]*productionSlot{}
+
+	for _, it := range items {
+		key, label := "asap", "ASAP"
+		if it.SlotStart != nil {
+			start := it.SlotStart.In(loc)
+			key = start.Format(time.RFC3339)
+			label = start.Format("15:04")
+			if it.SlotEnd != nil {
+				label += "-" + it.SlotEnd.In(loc).Format("15:04")
+			}
+			if slot != "" && start.Format("15:04") != slot {
+				continue
+			}
+		} else if slot != "" {
+			continue
+		}
+
+		s, ok := bySlot[key]
+		if !ok {
+			s = &productionSlot{Label: label}
+			if it.SlotStart != nil {
+				start := it.SlotStart.In(loc)
+				s.Start = &start
+				if it.SlotEnd != nil {
+					end := it.SlotEnd.In(loc)
+					s.End = &end
+				}
+			}
+			bySlot[key] = s
+			slots = append(slots, s)
+			slotAgg[key] = newLineAggregator()
+			slotOrders[key] = map[int]bool{}
+		}
+		slotAgg[key].add(it)
+		slotOrders[key][it.OrderID] = true
+		total.add(it)
+		orders[it.OrderID] = true
+	}
+
+	plan.Orders = len(orders)
+	plan.Products = total.result()
+	plan.Slots = []productionSlot{}
+	for key, s := range bySlot {
+		s.Products = slotAgg[key].result()
+		s.Orders = len(slotOrders[key])
+	}
+	for _, s := range slots {
+		plan.Slots = append(plan.Slots, *s)
+	}
+	return plan
+}
+
+// AdminGetProductionPlan returns quantities to bake for pending/preparing orders
+// GET /api/admin/production-plan?date=YYYY-MM-DD[&slot=HH:MM][&format=json|csv|html]
+func AdminGetProductionPlan(w http.ResponseWriter, r *http.Request) {
+	loc := shopLocation()
+	q := r.URL.Query()
+
+	date := q.Get("date")
+	if date == "" {
+		date = time.Now().In(loc).Format("2006-01-02")
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	slot := q.Get("slot")
+	if slot != "" {
+		if _, err := time.Parse("15:04", slot); err != nil {
+			http.Error(w, "slot must be HH:MM", http.StatusBadRequest)
+			return
+		}
+	}
+
+	items, err := models.GetProductionItems(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("❌ Error building production plan: %v", err)
+		http.Error(w, "Error building production plan", http.StatusInternalServerError)
+		return
+	}
+	plan := buildProductionPlan(items, loc, slot)
+	plan.Date = date
+
+	byID, byName := map[int]int{}, map[string]int{}
+	for _, l := range plan.Products {
+		if l.ProductID != 0 {
+			byID[l.ProductID] += l.Quantity
+		} else {
+			byName[l.Product] += l.Quantity
+		}
+	}
+	plan.Ingredients, err = models.GetIngredientNeeds(configs.DB, byID, byName)
+	if err != nil {
+		log.Printf("⚠️ Could not compute ingredient needs: %v", err)
+		plan.Ingredients = []models.IngredientNeed{}
+	}
+
+	filename := "production-plan-" + date
+	switch q.Get("format") {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		writeProductionPlanCSV(w, plan)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := productionPlanTemplate.Execute(w, plan); err != nil {
+			log.Printf("❌ Error rendering production plan: %v", err)
+		}
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plan)
+	default:
+		http.Error(w, "format must be json, csv or html", http.StatusBadRequest)
+	}
+}
+
+// writeProductionPlanCSV writes one row per slot and product, followed by the day totals
+func writeProductionPlanCSV(w http.ResponseWriter, plan productionPlan) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"slot", "product", "quantity", "orders"})
+	for _, s := range plan.Slots {
+		for _, l := range s.Products {
+			cw.Write([]string{s.Label, l.Product, strconv.Itoa(l.Quantity), strconv.Itoa(l.Orders)})
+		}
+	}
+	for _, l := range plan.Products {
+		cw.Write([]string{"TOTAL", l.Product, strconv.Itoa(l.Quantity), strconv.Itoa(l.Orders)})
+	}
+	if len(plan.Ingredients) > 0 {
+		cw.Write([]string{})
+		cw.Write([]string{"ingredient", "unit", "required", "in_stock", "shortfall"})
+		for _, n := range plan.Ingredients {
+			cw.Write([]string{n.Name, n.Unit, formatAmount(n.Required), formatAmount(n.Stock), formatAmount(n.Shortfall)})
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("❌ Error writing production plan CSV: %v", err)
+	}
+}
+
+// formatAmount prints an ingredient quantity without trailing zeros
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// productionPlanTemplate is the printable kitchen sheet
+var productionPlanTemplate = template.Must(template.New("production-plan").Funcs(template.FuncMap{"amount": formatAmount}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Production Plan {{.Date}}</title>
+<style>
+  body { font-family: Arial, sans-serif; margin: 24px; color: #222; }
+  h1 { margin-bottom: 4px; }
+  .meta { color: #666; margin-bottom: 24px; }
+  table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+  th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: left; }
+  th { background: #f3f3f3; }
+  td.qty { text-align: right; font-weight: bold; width: 80px; }
+  td.check { width: 40px; }
+  td.short { color: #c00; font-weight: bold; }
+  .slot { page-break-inside: avoid; }
+  @media print { .no-print { display: none; } body { margin: 0; } }
+</style>
+</head>
+<body>
+<button class="no-print" onclick="window.print()">🖨️ Print</button>
+<h1>🍰 Production Plan — {{.Date}}{{if .Slot}} ({{.Slot}}){{end}}</h1>
+<div class="meta">{{.Orders}} orders · pending &amp; preparing · {{.Timezone}} · generated {{.GeneratedAt.Format "2006-01-02 15:04"}}</div>
+
+<h2>Totals</h2>
+{{if .Products}}
+<table>
+  <tr><th>Product</th><th>Qty</th><th>Orders</th><th>✓</th></tr>
+  {{range .Products}}<tr><td>{{.Product}}</td><td class="qty">{{.Quantity}}</td><td>{{.Orders}}</td><td class="check"></td></tr>
+  {{end}}
+</table>
+{{else}}
+<p>Nothing to bake.</p>
+{{end}}
+
+{{if .Ingredients}}
+<h2>🧂 Ingredients</h2>
+<table>
+  <tr><th>Ingredient</th><th>Needed</th><th>In stock</th><th>Short</th></tr>
+  {{range .Ingredients}}<tr><td>{{.Name}}</td><td class="qty">{{amount .Required}} {{.Unit}}</td><td>{{amount .Stock}} {{.Unit}}</td><td{{if .Shortfall}} class="short"{{end}}>{{if .Shortfall}}{{amount .Shortfall}} {{.Unit}}{{end}}</td></tr>
+  {{end}}
+</table>
+{{end}}
+
+{{range .Slots}}
+<div class="slot">
+<h2>🕒 {{.Label}} <small>({{.Orders}} orders)</small></h2>
+<table>
+  <tr><th>Product</th><th>Qty</th><th>✓</th></tr>
+  {{range .Products}}<tr><td>{{.Product}}</td><td class="qty">{{.Quantity}}</td><td class="check"></td></tr>
+  {{end}}
+</table>
+</div>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bakeflow/models"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRatingCommentLength caps the comment a customer can add to a rating (in characters)
+const maxRatingCommentLength = 1000
+
+// ratingCommentWindow is how long after asking for a rating comment a typed message is
+// taken as the comment
+const ratingCommentWindow = 10 * time.Minute
+
+// AdminGetRatings lists ratings with their order, newest first
+// GET /api/admin/ratings?from=&to=&min_stars=&max_stars=&follow_up=open|resolved&limit=50&offset=0[&timezone=]
+func AdminGetRatings(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := models.RatingFilter{Range: rng, FollowUp: q.Get("follow_up"), Limit: 50}
+	if filter.FollowUp != "" && filter.FollowUp != models.FollowUpOpen && filter.FollowUp != models.FollowUpResolved {
+		http.Error(w, "follow_up must be open or resolved", http.StatusBadRequest)
+		return
+	}
+	for name, dst := range map[string]*int{"min_stars": &filter.MinStars, "max_stars": &filter.MaxStars} {
+		if v := q.Get(name); v != "" {
+			stars, err := strconv.Atoi(v)
+			if err != nil || stars < 1 || stars > 5 {
+				http.Error(w, name+" must be between 1 and 5", http.StatusBadRequest)
+				return
+			}
+			*dst = stars
+		}
+	}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 && l <= 200 {
+		filter.Limit = l
+	}
+	if o, err := strconv.Atoi(q.Get("offset")); err == nil && o > 0 {
+		filter.Offset = o
+	}
+
+	ratings, total, err := models.ListRatings(filter)
+	if err != nil {
+		log.Printf("❌ Error loading ratings: %v", err)
+		http.Error(w, "Error loading ratings", http.StatusInternalServerError)
+		return
+	}
+	writeSalesReport(w, rng, map[string]interface{}{
+		"ratings": ratings,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// AdminGetRatingsReport returns the average rating overall, per period and per product
+// GET /api/admin/reports/ratings?from=&to=&interval=day|week|month[&timezone=]
+func AdminGetRatingsReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = models.IntervalWeek
+	}
+	if interval != models.IntervalDay && interval != models.IntervalWeek && interval != models.IntervalMonth {
+		http.Error(w, "interval must be day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := models.GetRatingSummary(rng)
+	if err != nil {
+		log.Printf("❌ Error building rating summary: %v", err)
+		http.Error(w, "Error building ratings report", http.StatusInternalServerError)
+		return
+	}
+	periods, err := models.GetRatingsByPeriod(rng, interval)
+	if err != nil {
+		log.Printf("❌ Error building ratings by %s: %v", interval, err)
+		http.Error(w, "Error building ratings report", http.StatusInternalServerError)
+		return
+	}
+	products, err := models.GetProductRatings(rng)
+	if err != nil {
+		log.Printf("❌ Error building product ratings: %v", err)
+		http.Error(w, "Error building ratings report", http.StatusInternalServerError)
+		return
+	}
+
+	writeSalesReport(w, rng, map[string]interface{}{
+		"interval": interval,
+		"summary":  summary,
+		"periods":  periods,
+		"products": products,
+	})
+}
+
+// AdminUpdateRatingFollowUp flags a rating for follow-up, resolves it or clears the flag
+// PUT /api/admin/ratings/{id}/follow-up {"status": "open"|"resolved"|"", "note": "..."}
+func AdminUpdateRatingFollowUp(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid rating ID", http.StatusBadRequest)
+		return
+	}
+	var requestBody struct {
+		Status string  `json:"status"`
+		Note   *string `json:"note"` // omitted = keep the current note
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.Status != "" && requestBody.Status != models.FollowUpOpen && requestBody.Status != models.FollowUpResolved {
+		http.Error(w, "status must be open, resolved or empty", http.StatusBadRequest)
+		return
+	}
+
+	found, err := models.SetRatingFollowUp(id, requestBody.Status, requestBody.Note)
+	if err != nil {
+		log.Printf("❌ Error updating rating follow-up: %v", err)
+		http.Error(w, "Error updating rating", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Rating not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("✅ Rating #%d follow-up set to %q", id, requestBody.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "id": id, "follow_up": requestBody.Status})
+}
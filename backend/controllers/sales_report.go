@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bakeflow/models"
+)
+
+// salesReportRange reads the report period shared by all sales reports:
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD (inclusive, default the last 30 days) and an optional
+// ?timezone= (IANA name, default the shop timezone) the dates and buckets are in
+func salesReportRange(w http.ResponseWriter, r *http.Request) (models.SalesRange, bool) {
+	loc := shopLocation()
+	if name := r.URL.Query().Get("timezone"); name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			http.Error(w, "timezone must be an IANA name such as Asia/Yangon", http.StatusBadRequest)
+			return models.SalesRange{}, false
+		}
+	}
+	from, to, err := reportDateRange(r, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return models.SalesRange{}, false
+	}
+	return models.SalesRange{From: from, To: to.AddDate(0, 0, 1), Location: loc}, true
+}
+
+// writeSalesReport sends a report with its period
+func writeSalesReport(w http.ResponseWriter, rng models.SalesRange, report map[string]interface{}) {
+	report["from"] = rng.From.Format("2006-01-02")
+	report["to"] = rng.To.AddDate(0, 0, -1).Format("2006-01-02")
+	report["timezone"] = rng.Location.String()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// AdminGetSalesReport returns revenue, order counts and average order value per period
+// GET /api/admin/reports/sales?from=&to=&interval=day|week|month[&timezone=]
+func AdminGetSalesReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if interval != models.IntervalDay && interval != models.IntervalWeek && interval != models.IntervalMonth {
+		http.Error(w, "interval must be day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := models.GetSalesSummary(rng)
+	if err != nil {
+		log.Printf("❌ Error building sales summary: %v", err)
+		http.Error(w, "Error building sales report", http.StatusInternalServerError)
+		return
+	}
+	periods, err := models.GetSalesByPeriod(rng, interval)
+	if err != nil {
+		log.Printf("❌ Error building sales by %s: %v", interval, err)
+		http.Error(w, "Error building sales report", http.StatusInternalServerError)
+		return
+	}
+
+	writeSalesReport(w, rng, map[string]interface{}{
+		"interval": interval,
+		"summary":  summary,
+		"periods":  periods,
+	})
+}
+
+// AdminGetDeliverySplitReport returns pickup vs delivery orders and revenue
+// GET /api/admin/reports/delivery-split?from=&to=[&timezone=]
+func AdminGetDeliverySplitReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	split, err := models.GetDeliverySplit(rng)
+	if err != nil {
+		log.Printf("❌ Error building delivery split: %v", err)
+		http.Error(w, "Error building delivery split", http.StatusInternalServerError)
+		return
+	}
+	writeSalesReport(w, rng, map[string]interface{}{"split": split})
+}
+
+// AdminGetTopProductsReport returns the best sellers by revenue or quantity
+// GET /api/admin/reports/top-products?from=&to=&sort=revenue|quantity&limit=10[&timezone=]
+func AdminGetTopProductsReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = models.TopByRevenue
+	}
+	if sortBy != models.TopByRevenue && sortBy != models.TopByQuantity {
+		http.Error(w, "sort must be revenue or quantity", http.StatusBadRequest)
+		return
+	}
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	products, err := models.GetTopProducts(rng, sortBy, limit)
+	if err != nil {
+		log.Printf("❌ Error building top products: %v", err)
+		http.Error(w, "Error building top products", http.StatusInternalServerError)
+		return
+	}
+	writeSalesReport(w, rng, map[string]interface{}{
+		"sort":     sortBy,
+		"products": products,
+	})
+}
+
+// AdminGetHourlySalesReport returns orders and revenue by hour of day
+// GET /api/admin/reports/hourly?from=&to=[&timezone=]
+func AdminGetHourlySalesReport(w http.ResponseWriter, r *http.Request) {
+	rng, ok := salesReportRange(w, r)
+	if !ok {
+		return
+	}
+	hours, err := models.GetSalesByHour(rng)
+	if err != nil {
+		log.Printf("❌ Error building hourly sales: %v", err)
+		http.Error(w, "Error building hourly sales", http.StatusInternalServerError)
+		return
+	}
+	writeSalesReport(w, rng, map[string]interface{}{"hours": hours})
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/models"
+	"bakeflow/notify"
+)
+
+// defaultStockAlertInterval is how often stock levels are checked when STOCK_ALERT_INTERVAL is unset
+const defaultStockAlertInterval = 5 * time.Minute
+
+// staffEmailSender delivers stock alert emails; replaceable for other providers
+var staffEmailSender notify.EmailSender
+
+// StartStockAlertChecker periodically notifies staff about products and ingredients that
+// dropped below their reorder threshold. Recipients come from STAFF_PSIDS (Messenger) and
+// STAFF_EMAILS (comma separated). Each item is alerted once until it is restocked.
+func StartStockAlertChecker() {
+	interval := defaultStockAlertInterval
+	if v := os.Getenv("STOCK_ALERT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Printf("⚠️ Invalid STOCK_ALERT_INTERVAL %q, using %v", v, interval)
+		} else {
+			interval = d
+		}
+	}
+	if staffEmailSender == nil {
+		staffEmailSender = notify.NewEmailSenderFromEnv()
+	}
+
+	go func() {
+		for {
+			// Catch stock changed outside the admin API (imports, direct DB edits)
+			syncProductAvailability(configs.DB, 0)
+			checkStockAlerts()
+			time.Sleep(interval)
+		}
+	}()
+	log.Printf("✅ Stock alert checker running every %v", interval)
+}
+
+// envList splits a comma separated environment variable
+func envList(name string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// checkStockAlerts sends one alert covering every item that newly crossed its threshold
+func checkStockAlerts() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic recovered in stock alert checker: %v", r)
+		}
+	}()
+	if configs.DB == nil {
+		return
+	}
+
+	if _, err := models.ClearRecoveredStockAlerts(configs.DB); err != nil {
+		log.Printf("❌ Failed to clear recovered stock alerts: %v", err)
+		return
+	}
+	items, err := models.GetLowStockAlertItems(configs.DB)
+	if err != nil {
+		log.Printf("❌ Failed to check stock levels: %v", err)
+		return
+	}
+	alerted, err := models.GetAlertedStockItems(configs.DB)
+	if err != nil {
+		log.Printf("❌ Failed to load stock alerts: %v", err)
+		return
+	}
+
+	var fresh []models.StockAlertItem
+	for _, item := range items {
+		if !alerted[item.Key()] {
+			fresh = append(fresh, item)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	if !sendStockAlert(fresh) {
+		return // retried on the next run
+	}
+	for _, item := range fresh {
+		if err := models.MarkStockAlerted(configs.DB, item); err != nil {
+			log.Printf("❌ Failed to record stock alert for %s: %v", item.Key(), err)
+		}
+	}
+}
+
+// sendStockAlert delivers the alert to every configured channel.
+// Returns true if at least one channel succeeded or none is configured.
+func sendStockAlert(items []models.StockAlertItem) bool {
+	subject := fmt.Sprintf("BakeFlow: %d item(s) low on stock", len(items))
+	var lines []string
+	for _, item := range items {
+		icon := "📦"
+		if item.Kind == models.StockAlertIngredient {
+			icon = "🧂"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s %s left (reorder at %s)",
+			icon, item.Name, formatAmount(item.Stock), item.Unit, formatAmount(item.Threshold)))
+	}
+	body := "⚠️ Low stock\n\n" + strings.Join(lines, "\n")
+	for _, line := range lines {
+		log.Printf("⚠️ Low stock alert: %s", line)
+	}
+
+	psids := envList("STAFF_PSIDS")
+	emails := envList("STAFF_EMAILS")
+	if len(psids) == 0 && len(emails) == 0 {
+		log.Println("ℹ️ No STAFF_PSIDS or STAFF_EMAILS configured; stock alert logged only")
+		return true
+	}
+
+	delivered := false
+	for _, psid := range psids {
+		// Staff must have messaged the page recently for Messenger to accept the message
+		if err := SendMessage(psid, body); err != nil {
+			log.Printf("⚠️ Failed to send stock alert to staff %s: %v", psid, err)
+		} else {
+			delivered = true
+		}
+	}
+	if len(emails) > 0 && staffEmailSender != nil {
+		if err := staffEmailSender.Send(emails, subject, body); err != nil {
+			log.Printf("⚠️ Failed to email stock alert: %v", err)
+		} else {
+			delivered = true
+		}
+	}
+	return delivered
+}
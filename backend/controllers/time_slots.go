@@ -0,0 +1,559 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+	"bakeflow/models"
+)
+
+// maxSlotQuickReplies is how many slot buttons fit next to the Back/Later buttons
+const maxSlotQuickReplies = 10
+
+// maxDateQuickReplies is how many dates are offered (Messenger allows 13 quick replies)
+const maxDateQuickReplies = 10
+
+// timeSlot is a bookable pickup/delivery window
+type timeSlot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int       `json:"capacity"`  // 0 = unlimited
+	Remaining int       `json:"remaining"` // -1 when unlimited
+}
+
+// slotPlanner computes which slots a cart can be booked into
+type slotPlanner struct {
+	settings models.SlotSettings
+	schedule *shopSchedule
+	now      time.Time
+}
+
+// newSlotPlanner loads slot settings and the shop schedule.
+// Returns nil if either is unavailable, in which case only ASAP ordering is offered.
+func newSlotPlanner() *slotPlanner {
+	schedule := getShopSchedule()
+	if schedule == nil {
+		return nil
+	}
+	settings, err := models.GetSlotSettings()
+	if err != nil {
+		log.Printf("⚠️ Failed to load slot settings: %v", err)
+		return nil
+	}
+	return &slotPlanner{settings: settings, schedule: schedule, now: time.Now().In(schedule.loc)}
+}
+
+// leadTime is the minimum notice for a set of categories (the longest one wins)
+func (p *slotPlanner) leadTime(categories []string) time.Duration {
+	minutes := p.settings.DefaultLeadMinutes
+	for _, c := range categories {
+		for category, lead := range p.settings.CategoryLeadTimes {
+			if strings.EqualFold(category, c) && lead > minutes {
+				minutes = lead
+			}
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// asapAllowed reports whether the cart can be prepared right away: the shop is open
+// and no item needs more notice than the default lead time
+func (p *slotPlanner) asapAllowed(categories []string) bool {
+	open, _ := p.schedule.isOpenAt(p.now)
+	return open && p.leadTime(categories) <= time.Duration(p.settings.DefaultLeadMinutes)*time.Minute
+}
+
+// daySlots returns every slot of a day with its booking count, ignoring lead time
+func (p *slotPlanner) daySlots(day time.Time) ([]timeSlot, error) {
+	open, closeAt, ok := p.schedule.hoursOn(day)
+	if !ok {
+		return nil, nil
+	}
+	counts, err := models.CountSlotBookings(open, closeAt)
+	if err != nil {
+		return nil, err
+	}
+
+	length := time.Duration(p.settings.LengthMinutes) * time.Minute
+	var slots []timeSlot
+	for start := open; !start.Add(length).After(closeAt); start = start.Add(length) {
+		slot := timeSlot{Start: start, End: start.Add(length), Capacity: p.settings.Capacity, Remaining: -1}
+		if p.settings.Capacity > 0 {
+			slot.Remaining = max(p.settings.Capacity-counts[start.Unix()], 0)
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// availableSlots returns the slots on a day that the cart can still be booked into
+func (p *slotPlanner) availableSlots(day time.Time, categories []string) ([]timeSlot, error) {
+	day = day.In(p.schedule.loc)
+	if sameDay(day, p.now) && p.settings.SameDayCutoff != "" {
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, p.schedule.loc)
+		if !p.now.Before(atClock(midnight, p.settings.SameDayCutoff)) {
+			return nil, nil
+		}
+	}
+
+	slots, err := p.daySlots(day)
+	if err != nil {
+		return nil, err
+	}
+	earliest := p.now.Add(p.leadTime(categories))
+	var available []timeSlot
+	for _, s := range slots {
+		if s.Start.Before(earliest) || s.Start.Before(p.schedule.closedUntil) || s.Remaining == 0 {
+			continue
+		}
+		available = append(available, s)
+	}
+	return available, nil
+}
+
+// availableDates returns the upcoming days that have at least one bookable slot
+func (p *slotPlanner) availableDates(categories []string) []time.Time {
+	var dates []time.Time
+	today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.schedule.loc)
+	for i := 0; i < p.settings.BookingDays; i++ {
+		day := today.AddDate(0, 0, i)
+		slots, err := p.availableSlots(day, categories)
+		if err != nil {
+			log.Printf("⚠️ Failed to load slots for %s: %v", day.Format("2006-01-02"), err)
+			continue
+		}
+		if len(slots) > 0 {
+			dates = append(dates, day)
+		}
+	}
+	return dates
+}
+
+// findSlot returns the available slot starting at start, if the cart can still book it
+func (p *slotPlanner) findSlot(start time.Time, categories []string) (*timeSlot, error) {
+	slots, err := p.availableSlots(start, categories)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range slots {
+		if s.Start.Equal(start) {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// cartCategories looks up the product categories of the cart items
+func cartCategories(cart []CartItem) []string {
+	m := getProductMatcher()
+	var categories []string
+	for _, item := range cart {
+		if c := m.CategoryOf(item.Product); c != "" {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+// formatSlotDay names a day for buttons and messages ("Today", "Tomorrow" or "Monday, Oct 19")
+func formatSlotDay(state *UserState, day, now time.Time) string {
+	if sameDay(day, now) {
+		return tr(state, "slot.today")
+	}
+	if sameDay(day, now.AddDate(0, 0, 1)) {
+		return tr(state, "slot.tomorrow")
+	}
+	return tr(state, "weekday."+strconv.Itoa(int(day.Weekday()))) + ", " + day.Format(tr(state, "format.date"))
+}
+
+// formatSlotRange renders a slot as "Tomorrow, 10:00 AM - 11:00 AM"
+func formatSlotRange(state *UserState, start, end time.Time) string {
+	loc := shopLocation()
+	start, end = start.In(loc), end.In(loc)
+	layout := tr(state, "format.time")
+	return tr(state, "slot.range", i18n.Args{
+		"day":   formatSlotDay(state, start, time.Now().In(loc)),
+		"start": start.Format(layout),
+		"end":   end.Format(layout),
+	})
+}
+
+// orderTimeText describes when the current order will be ready
+func orderTimeText(state *UserState) string {
+	if state.SlotStart.IsZero() {
+		return tr(state, "slot.asap_text")
+	}
+	return formatSlotRange(state, state.SlotStart, state.SlotEnd)
+}
+
+// askOrderTime asks whether the order is wanted right away or for a later date
+func askOrderTime(userID string) {
+	state := GetUserState(userID)
+	state.State = "awaiting_order_date"
+	state.SlotChosen = false
+	state.SlotStart, state.SlotEnd = time.Time{}, time.Time{}
+
+	planner := newSlotPlanner()
+	if planner == nil {
+		// Scheduling unavailable: fall back to the immediate order flow
+		state.SlotChosen = true
+		state.State = "confirming"
+		showOrderSummary(userID)
+		return
+	}
+
+	categories := cartCategories(state.Cart)
+	var quickReplies []QuickReply
+	if planner.asapAllowed(categories) {
+		quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "slot.asap"), Payload: "SLOT_ASAP"})
+	}
+	for _, day := range planner.availableDates(categories) {
+		if len(quickReplies) == maxDateQuickReplies {
+			break
+		}
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       truncateTitle(formatSlotDay(state, day, planner.now), 20),
+			Payload:     "SLOT_DATE_" + day.Format("2006-01-02"),
+		})
+	}
+
+	if len(quickReplies) == 0 {
+		SendQuickReplies(userID, tr(state, "slot.none_available"), backCancelQuickReplies(state))
+		return
+	}
+	quickReplies = append(quickReplies, backCancelQuickReplies(state)...)
+	SendQuickReplies(userID, tr(state, "slot.ask_date"), quickReplies)
+}
+
+// askOrderSlot shows the available slots on a date, starting at offset
+func askOrderSlot(userID string, date string, offset int) {
+	state := GetUserState(userID)
+
+	planner := newSlotPlanner()
+	if planner == nil {
+		askOrderTime(userID)
+		return
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, planner.schedule.loc)
+	if err != nil {
+		askOrderTime(userID)
+		return
+	}
+	slots, err := planner.availableSlots(day, cartCategories(state.Cart))
+	if err != nil {
+		log.Printf("⚠️ Failed to load slots for %s: %v", date, err)
+	}
+	if len(slots) == 0 {
+		SendMessage(userID, tr(state, "slot.full"))
+		askOrderTime(userID)
+		return
+	}
+	if offset >= len(slots) {
+		offset = 0
+	}
+	state.State = "awaiting_slot"
+
+	layout := tr(state, "format.time")
+	var quickReplies []QuickReply
+	end := offset + maxSlotQuickReplies
+	if end > len(slots) {
+		end = len(slots)
+	}
+	for _, s := range slots[offset:end] {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       truncateTitle(s.Start.Format(layout)+"-"+s.End.Format(layout), 20),
+			Payload:     fmt.Sprintf("SLOT_TIME_%d", s.Start.Unix()),
+		})
+	}
+	if end < len(slots) {
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       tr(state, "slot.more"),
+			Payload:     fmt.Sprintf("SLOT_MORE_%s_%d", date, end),
+		})
+	}
+	quickReplies = append(quickReplies, QuickReply{ContentType: "text", Title: tr(state, "button.back"), Payload: "GO_BACK"})
+
+	SendQuickReplies(userID, tr(state, "slot.ask_time", i18n.Args{"day": formatSlotDay(state, day, planner.now)}), quickReplies)
+}
+
+// selectOrderSlot books the chosen slot into the conversation (capacity is re-checked at confirmation)
+func selectOrderSlot(userID string, start time.Time) {
+	state := GetUserState(userID)
+
+	planner := newSlotPlanner()
+	if planner == nil {
+		askOrderTime(userID)
+		return
+	}
+	slot, err := planner.findSlot(start.In(planner.schedule.loc), cartCategories(state.Cart))
+	if err != nil {
+		log.Printf("⚠️ Failed to check slot: %v", err)
+	}
+	if slot == nil {
+		SendMessage(userID, tr(state, "slot.full"))
+		askOrderTime(userID)
+		return
+	}
+
+	state.SlotStart, state.SlotEnd = slot.Start, slot.End
+	state.SlotChosen = true
+	state.State = "confirming"
+	showOrderSummary(userID)
+}
+
+// continueToSummary asks for a time slot unless one was already chosen, then shows the summary
+func continueToSummary(userID string) {
+	state := GetUserState(userID)
+	if !state.SlotChosen {
+		askOrderTime(userID)
+		return
+	}
+	state.State = "confirming"
+	showOrderSummary(userID)
+}
+
+// handleSlotPostback handles SLOT_* payloads; returns false if the payload is not a slot action
+func handleSlotPostback(userID, payload string) bool {
+	state := GetUserState(userID)
+
+	switch {
+	case payload == "SLOT_ASAP":
+		state.SlotStart, state.SlotEnd = time.Time{}, time.Time{}
+		state.SlotChosen = true
+		state.State = "confirming"
+		showOrderSummary(userID)
+
+	case strings.HasPrefix(payload, "SLOT_DATE_"):
+		askOrderSlot(userID, strings.TrimPrefix(payload, "SLOT_DATE_"), 0)
+
+	case strings.HasPrefix(payload, "SLOT_MORE_"):
+		parts := strings.SplitN(strings.TrimPrefix(payload, "SLOT_MORE_"), "_", 2)
+		offset := 0
+		if len(parts) == 2 {
+			offset, _ = strconv.Atoi(parts[1])
+		}
+		askOrderSlot(userID, parts[0], offset)
+
+	case strings.HasPrefix(payload, "SLOT_TIME_"):
+		unix, err := strconv.ParseInt(strings.TrimPrefix(payload, "SLOT_TIME_"), 10, 64)
+		if err != nil {
+			askOrderTime(userID)
+			return true
+		}
+		selectOrderSlot(userID, time.Unix(unix, 0))
+
+	default:
+		return false
+	}
+	return true
+}
+
+// GetChatSlots lists bookable dates, or the slots of one date, for the webview
+// GET /api/chat/slots?product_ids=1,2[&date=YYYY-MM-DD]
+func GetChatSlots(w http.ResponseWriter, r *http.Request) {
+	planner := newSlotPlanner()
+	if planner == nil {
+		http.Error(w, "scheduling unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var ids []int
+	if v := r.URL.Query().Get("product_ids"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				http.Error(w, "invalid product_ids", http.StatusBadRequest)
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+	categories := productCategories(ids)
+
+	w.Header().Set("Content-Type", "application/json")
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		dates := []string{}
+		for _, d := range planner.availableDates(categories) {
+			dates = append(dates, d.Format("2006-01-02"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"timezone":       planner.schedule.loc.String(),
+			"asap_available": planner.asapAllowed(categories),
+			"dates":          dates,
+		})
+		return
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", date, planner.schedule.loc)
+	if err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	slots, err := planner.availableSlots(day, categories)
+	if err != nil {
+		log.Printf("❌ Failed to load slots: %v", err)
+		http.Error(w, "failed to load slots", http.StatusInternalServerError)
+		return
+	}
+	if slots == nil {
+		slots = []timeSlot{}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"timezone": planner.schedule.loc.String(),
+		"date":     date,
+		"slots":    slots,
+	})
+}
+
+// productCategories looks up the categories of products by ID
+func productCategories(ids []int) []string {
+	var categories []string
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		p, err := models.GetProductByID(configs.DB, id)
+		if err != nil {
+			log.Printf("⚠️ Failed to load product %d: %v", id, err)
+			continue
+		}
+		if p != nil {
+			categories = append(categories, p.Category)
+		}
+	}
+	return categories
+}
+
+// slotOrdersView is one slot of the admin schedule with the orders booked into it
+type slotOrdersView struct {
+	Start    time.Time      `json:"start"`
+	End      time.Time      `json:"end"`
+	Capacity int            `json:"capacity"`
+	Booked   int            `json:"booked"`
+	Orders   []models.Order `json:"orders"`
+}
+
+// AdminGetSlotOrders lists every slot of a day with its scheduled orders
+// GET /api/admin/slots?date=YYYY-MM-DD (defaults to today, shop time)
+func AdminGetSlotOrders(w http.ResponseWriter, r *http.Request) {
+	planner := newSlotPlanner()
+	if planner == nil {
+		http.Error(w, "Error loading slot settings", http.StatusInternalServerError)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = planner.now.Format("2006-01-02")
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, planner.schedule.loc)
+	if err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	slots, err := planner.daySlots(day)
+	if err != nil {
+		log.Printf("❌ Error loading slots: %v", err)
+		http.Error(w, "Error loading slots", http.StatusInternalServerError)
+		return
+	}
+	orders, err := models.GetOrdersBySlotRange(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("❌ Error loading scheduled orders: %v", err)
+		http.Error(w, "Error loading scheduled orders", http.StatusInternalServerError)
+		return
+	}
+
+	views := []*slotOrdersView{}
+	byStart := map[int64]*slotOrdersView{}
+	for _, s := range slots {
+		v := &slotOrdersView{Start: s.Start, End: s.End, Capacity: s.Capacity, Orders: []models.Order{}}
+		views = append(views, v)
+		byStart[s.Start.Unix()] = v
+	}
+	for _, o := range orders {
+		v, ok := byStart[o.SlotStart.Unix()]
+		if !ok {
+			// Booked before the slot settings or hours changed: show it as its own slot
+			v = &slotOrdersView{Start: o.SlotStart.In(planner.schedule.loc), Orders: []models.Order{}}
+			if o.SlotEnd != nil {
+				v.End = o.SlotEnd.In(planner.schedule.loc)
+			}
+			views = append(views, v)
+			byStart[o.SlotStart.Unix()] = v
+		}
+		v.Orders = append(v.Orders, o)
+		v.Booked++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"date":     date,
+		"timezone": planner.schedule.loc.String(),
+		"slots":    views,
+		"total":    len(orders),
+	})
+}
+
+// AdminGetSlotSettings returns the slot configuration
+func AdminGetSlotSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := models.GetSlotSettings()
+	if err != nil {
+		log.Printf("❌ Error loading slot settings: %v", err)
+		http.Error(w, "Error loading slot settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// AdminUpdateSlotSettings updates the slot configuration; omitted fields keep their values
+// and category_lead_times, when given, replaces the whole map
+func AdminUpdateSlotSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := models.GetSlotSettings()
+	if err != nil {
+		log.Printf("❌ Error loading slot settings: %v", err)
+		http.Error(w, "Error loading slot settings", http.StatusInternalServerError)
+		return
+	}
+	currentLeadTimes := settings.CategoryLeadTimes
+	settings.CategoryLeadTimes = nil
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if settings.CategoryLeadTimes == nil {
+		settings.CategoryLeadTimes = currentLeadTimes
+	}
+	if err := settings.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := models.SaveSlotSettings(settings); err != nil {
+		log.Printf("❌ Error saving slot settings: %v", err)
+		http.Error(w, "Error saving slot settings", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("✅ Slot settings updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -1,25 +1,53 @@
 package controllers
 
-import "sync"
+import (
+	"sync"
+	"time"
+
+	"bakeflow/models"
+)
 
 // CartItem represents a single item in the shopping cart
 type CartItem struct {
 	Product      string
 	ProductEmoji string
 	Quantity     int
+	ProductID    int                      // catalog product ID; 0 for legacy hardcoded products
+	UnitPrice    float64                  // price including option deltas; 0 = look up in ProductCatalog
+	Options      []models.OrderItemOption // chosen variants
+	Inscription  string                   // custom message on the product
 }
 
 // UserState tracks the conversation state for each user
 type UserState struct {
-	State           string     // language_selection, greeting, awaiting_product, awaiting_quantity, awaiting_name, awaiting_delivery_type, awaiting_address, confirming
-	Language        string     // "en" or "my" (Myanmar/Burmese)
-	CurrentProduct  string     // Temporarily stores product being added
-	CurrentEmoji    string     // Temporarily stores emoji for current product
-	CurrentQuantity int        // Temporarily stores quantity for current product
-	Cart            []CartItem // Shopping cart with multiple items
-	CustomerName    string
-	DeliveryType    string // "pickup" or "delivery"
-	Address         string
+	State              string  // language_selection, greeting, awaiting_category, awaiting_product, awaiting_option, awaiting_inscription, awaiting_quantity, awaiting_name, awaiting_delivery_type, awaiting_address, awaiting_order_date, awaiting_slot, confirming
+	Language           string  // language code with a catalog in i18n/locales (en, my, th)
+	CurrentCategoryID  int     // category being browsed (0 = all)
+	ProductPage        int     // carousel page being browsed
+	SearchQuery        string  // product search being browsed instead of a category ("" = none)
+	CurrentProduct     string  // Temporarily stores product being added
+	CurrentEmoji       string  // Temporarily stores emoji for current product
+	CurrentQuantity    int     // Temporarily stores quantity for current product
+	CurrentProductID   int     // catalog ID of the product being customized
+	CurrentPrice       float64 // unit price so far (base price + chosen option deltas)
+	CurrentOptions     []models.OrderItemOption
+	CurrentInscription string
+	OptionStep         int        // index of the option group being asked
+	Cart               []CartItem // Shopping cart with multiple items
+	PendingCart        []CartItem // Items parsed from a free-text order, awaiting confirmation
+	OptionQueue        []CartItem // Parsed items with options, customized one at a time after confirmation
+	PreferredDelivery  string     // "pickup"/"delivery" mentioned in a free-text order (applied after name)
+	CustomerName       string
+	DeliveryType       string // "pickup" or "delivery"
+	Address            string
+	SlotChosen         bool      // customer picked ASAP or a time slot
+	SlotStart          time.Time // scheduled slot; zero = as soon as possible
+	SlotEnd            time.Time
+	SessionID          string    // funnel analytics ID of this conversation
+	LastActivity       time.Time // last message or postback from the customer
+	CartReminded       bool      // abandoned cart reminder sent in this conversation
+	RatingID           int       // rating waiting for a comment
+	RatingAskedAt      time.Time // when the rating comment was asked
 }
 
 // Product represents a bakery product with image
@@ -97,6 +125,27 @@ var (
 	StateMutex sync.RWMutex
 )
 
+// userLocks serialize each customer's webhook events with background jobs (the cart
+// reminder) that read the same conversation state. StateMutex only guards the map.
+var (
+	userLocks      = make(map[string]*sync.Mutex)
+	userLocksMutex sync.Mutex
+)
+
+// lockUser locks the customer's conversation state; call the returned func to unlock
+func lockUser(userID string) func() {
+	userLocksMutex.Lock()
+	mu := userLocks[userID]
+	if mu == nil {
+		mu = &sync.Mutex{}
+		userLocks[userID] = mu
+	}
+	userLocksMutex.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
 // QuickReply represents a quick reply button
 type QuickReply struct {
 	ContentType string `json:"content_type"`
@@ -114,7 +163,7 @@ type Element struct {
 	Title    string   `json:"title"`
 	ImageURL string   `json:"image_url"`
 	Subtitle string   `json:"subtitle"`
-	Buttons  []Button `json:"buttons"`
+	Buttons  []Button `json:"buttons,omitempty"`
 }
 
 type Button struct {
@@ -166,15 +215,36 @@ type Postback struct {
 
 // Helper functions for state management
 func GetUserState(userID string) *UserState {
+	StateMutex.RLock()
+	state := UserStates[userID]
+	StateMutex.RUnlock()
+	if state != nil {
+		return state
+	}
+
+	// New conversation: returning customers skip language selection.
+	// Looked up outside the lock so a slow DB does not block other users.
+	fresh := &UserState{State: "language_selection", SessionID: newSessionID()}
+	if lang := savedLanguage(userID); lang != "" {
+		fresh.Language = lang
+		fresh.State = "greeting"
+	}
+
 	StateMutex.Lock()
 	defer StateMutex.Unlock()
-
 	if UserStates[userID] == nil {
-		UserStates[userID] = &UserState{State: "language_selection"}
+		UserStates[userID] = fresh
 	}
 	return UserStates[userID]
 }
 
+// peekUserState returns the user's conversation state without starting a new one (nil if none)
+func peekUserState(userID string) *UserState {
+	StateMutex.RLock()
+	defer StateMutex.RUnlock()
+	return UserStates[userID]
+}
+
 func ResetUserState(userID string) {
 	StateMutex.Lock()
 	defer StateMutex.Unlock()
@@ -2,18 +2,28 @@ package controllers
 
 import (
 	"fmt"
-	"strconv"
+	"log"
 	"strings"
+	"time"
+	"bakeflow/i18n"
 	"bakeflow/models"
 	"bakeflow/configs"
 )
 
-// getProductElements returns product carousel elements from the database
-func getProductElements() []Element {
-	products, err := models.GetActiveProducts(configs.DB, 10, 0, "", "")
+// productsPerPage leaves room for the "See more" and "Other categories" cards in a 10-card carousel
+const productsPerPage = 8
+
+// getProductElements returns the carousel elements of the current category (or search) and page from the database
+func getProductElements(state *UserState) []Element {
+	// One extra row tells whether there is a next page
+	products, err := models.GetActiveProducts(configs.DB, productsPerPage+1, state.ProductPage*productsPerPage, state.CurrentCategoryID, state.SearchQuery, time.Now().In(shopLocation()))
 	if err != nil {
 		return []Element{}
 	}
+	hasMore := len(products) > productsPerPage
+	if hasMore {
+		products = products[:productsPerPage]
+	}
 	var elements []Element
 	for _, p := range products {
 		price := fmt.Sprintf("$%.2f", p.Price)
@@ -21,28 +31,34 @@ func getProductElements() []Element {
 		if img == "" {
 			img = "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=300&h=200&fit=crop"
 		}
-		emoji := "🍰"
-		switch strings.ToLower(p.Category) {
-		case "cakes":
-			emoji = "🎂"
-		case "cupcakes":
-			emoji = "🧁"
-		case "coffee":
-			emoji = "☕"
-		case "bread":
-			emoji = "🍞"
-		case "muffins":
-			emoji = "🧁"
-		case "tarts":
-			emoji = "🥧"
-		case "pastries":
-			emoji = "🥐"
-		}
-		elements = append(elements, Element{
-			Title:    emoji + " " + p.Name,
+		emoji := categoryEmoji(p.CategoryID)
+		element := Element{
+			Title:    emoji + " " + p.DisplayName(state.Language),
 			ImageURL: img,
 			Subtitle: fmt.Sprintf("%s • %s", p.Description, price),
-			Buttons:  []Button{{Type: "postback", Title: "🛒 Order", Payload: fmt.Sprintf("ORDER_PRODUCT_%d", p.ID)}},
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "button.order"), Payload: fmt.Sprintf("ORDER_PRODUCT_%d", p.ID)}},
+		}
+		if p.IsSoldOut() {
+			// Listed with a badge but not orderable
+			element.Subtitle = fmt.Sprintf("%s • %s", tr(state, "product.sold_out_badge"), price)
+			element.Buttons = nil
+		}
+		elements = append(elements, element)
+	}
+	if hasMore {
+		elements = append(elements, Element{
+			Title:    tr(state, "product.more_title"),
+			ImageURL: "https://images.unsplash.com/photo-1555507036-ab1f4038808a?w=300&h=200&fit=crop",
+			Subtitle: tr(state, "product.more_subtitle", i18n.Args{"page": state.ProductPage + 2}),
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "button.see_more"), Payload: fmt.Sprintf("PRODUCTS_PAGE_%d", state.ProductPage+1)}},
+		})
+	}
+	if (state.CurrentCategoryID != 0 || state.SearchQuery != "") && len(elements) > 0 {
+		elements = append(elements, Element{
+			Title:    tr(state, "category.other_title"),
+			ImageURL: "https://images.unsplash.com/photo-1509440159596-0249088772ff?w=300&h=200&fit=crop",
+			Subtitle: tr(state, "category.other_subtitle"),
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "button.categories"), Payload: "SHOW_CATEGORIES"}},
 		})
 	}
 	return elements
@@ -51,81 +67,30 @@ func getProductElements() []Element {
 // showAbout displays company information and help instructions in user's language
 func showAbout(userID string) {
 	state := GetUserState(userID)
-	var aboutMsg, helpMsg string
-
-	if state.Language == "my" {
-		aboutMsg = "🏪 ကျွန်ုပ်တို့အကြောင်း\n\n" +
-			"BakeFlow သည် လတ်ဆတ်သော မုန့်များကို နေ့စဉ် ဖုတ်လုပ်သော မုန့်ဆိုင်ဖြစ်ပါသည်။\n\n" +
-			"🎂 ကျွန်ုပ်တို့၏ အထူးမုန့်များ:\n" +
-			"• ချောကလက် ကိတ်မုန့်\n" +
-			"• ဗနီလာ ကိတ်မုန့်\n" +
-			"• ဆော့ဘီ ကိတ်မုန့်\n" +
-			"• ချိစ်ကိတ်မုန့်\n" +
-			"• နီမုန့်\n" +
-			"• ချောကလက် ကွတ်ကီး\n" +
-			"• ဗာတာကွတ်ကီး\n" +
-			"• အာလုမွန့်\n\n" +
-			"📍 တည်နေရာ: ရန်ကုန်မြို့\n" +
-			"⏰ ဖွင့်ချိန်: နံနက် 8:00 - ညနေ 8:00\n" +
-			"📞 ဆက်သွယ်ရန်: +95 9 XXX XXX XXX"
-
-		helpMsg = "\n\n❓ အသုံးပြုနည်း\n\n" +
-			"သဘာဝဘာသာစကားဖြင့် ရိုက်နိုင်ပါတယ်:\n\n" +
-			"• \"မီနူး\" သို့မဟုတ် \"မုန့်များ\"\n" +
-			"• \"ချောကလက်ကိတ်မုန့်လိုချင်တယ်\"\n" +
-			"• \"နှစ်ခု\" သို့မဟုတ် \"၂\"\n" +
-			"• \"ပို့ပေးပါ\" သို့မဟုတ် \"ကိုယ်တိုင်ယူမယ်\"\n" +
-			"• \"ပယ်ဖျက်\" သို့မဟုတ် \"အစကနေစမယ်\"\n\n" +
-			"🛒 အော်ဒါမှာရန် 'မီနူး' လို့ရိုက်ပါ!"
-	} else {
-		aboutMsg = "🏪 About Us\n\n" +
-			"BakeFlow is your neighborhood bakery, baking fresh daily!\n\n" +
-			"🎂 Our Specialties:\n" +
-			"• Chocolate Cake\n" +
-			"• Vanilla Cake\n" +
-			"• Strawberry Cake\n" +
-			"• Cheesecake\n" +
-			"• Red Velvet Cake\n" +
-			"• Chocolate Cookies\n" +
-			"• Butter Cookies\n" +
-			"• Almond Croissant\n\n" +
-			"📍 Location: Yangon, Myanmar\n" +
-			"⏰ Hours: 8:00 AM - 8:00 PM\n" +
-			"📞 Contact: +95 9 XXX XXX XXX"
-
-		helpMsg = "\n\n❓ How to Use\n\n" +
-			"You can type naturally:\n\n" +
-			"• \"menu\" or \"show products\"\n" +
-			"• \"I want chocolate cake\"\n" +
-			"• \"two\" or \"2\"\n" +
-			"• \"delivery please\" or \"pickup\"\n" +
-			"• \"cancel\" or \"start over\"\n\n" +
-			"🛒 Type 'menu' to start ordering!"
-	}
-
-	SendMessage(userID, aboutMsg+helpMsg)
+	SendMessage(userID, tr(state, "about.text", i18n.Args{"hours": formatBusinessHours(state)})+tr(state, "about.help"))
 }
 
 // showLanguageSelection shows language choice at the beginning
 func showLanguageSelection(userID string) {
 	state := GetUserState(userID)
 	state.State = "language_selection"
-
-	welcomeMsg := "Hi there! 👋 မင်္ဂလာပါ! 👋\n\n" +
-		"I'm BakeFlow Bot, your virtual bakery assistant (Beta). " +
-		"I'm still learning, so I might not have all the answers yet, but I'll try to assist you the best I can! 🍰\n\n" +
-		"ကျွန်တော် BakeFlow Bot ပါ၊ သင့်ရဲ့ မုန့်ဆိုင် အကူအညီပေး စက်ရုပ်ပါ (စမ်းသပ်ဗားရှင်း)。 " +
-		"ကျွန်တော် ယခုတော့ သင်ယူနေဆဲဖြစ်တဲ့အတွက် အားလုံးကို မဖြေနိုင်သေးပေမယ့် တတ်နိုင်သမျှ အကောင်းဆုံး ကူညီပေးပါမယ်နော်! 🍰\n\n" +
-		"Please select your language to get started.\n" +
-		"စတင်ဖို့ ဘာသာစကားကို ရွေးချယ်ပါ။"
-
-	SendMessage(userID, welcomeMsg)
-
-	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "🇬🇧 English", Payload: "LANG_EN"},
-		{ContentType: "text", Title: "🇲🇲 မြန်မာ", Payload: "LANG_MY"},
+	trackFunnelStep(userID, models.FunnelLanguage)
+
+	// Language is not known yet, so greet in every available language
+	var intros, prompts []string
+	var quickReplies []QuickReply
+	for _, lang := range i18n.Languages() {
+		intros = append(intros, i18n.T(lang, "language.welcome"))
+		prompts = append(prompts, i18n.T(lang, "language.prompt"))
+		quickReplies = append(quickReplies, QuickReply{
+			ContentType: "text",
+			Title:       i18n.T(lang, "language.button"),
+			Payload:     languagePayload(lang),
+		})
 	}
-	SendQuickReplies(userID, "Choose your language / ဘာသာစကား ရွေးပါ:", quickReplies)
+
+	SendMessage(userID, strings.Join(intros, "\n\n"))
+	SendQuickReplies(userID, strings.Join(prompts, " / "), quickReplies)
 }
 
 // startOrderingFlow begins the ordering process with welcome message and simple menu
@@ -134,78 +99,46 @@ func startOrderingFlow(userID string) {
 	state.State = "main_menu"
 
 	// Send welcome message with simple button menu
-	if state.Language == "my" {
-		SendMessage(userID, "🍰 BakeFlow မှ ကြိုဆိုပါတယ်!")
-		showMainMenuSimple(userID)
-	} else {
-		SendMessage(userID, "🍰 Welcome to BakeFlow!")
-		showMainMenuSimple(userID)
-	}
+	SendMessage(userID, tr(state, "welcome.title"))
+	showMainMenuSimple(userID)
 }
 
 // showMainMenu displays main menu as cards (like your screenshot)
 func showMainMenu(userID string) {
 	state := GetUserState(userID)
 
-	var elements []Element
-
-	if state.Language == "my" {
-		elements = []Element{
-			{
-				Title:    "🛒 အော်ဒါမှာမယ်",
-				Subtitle: "ကျွန်ုပ်တို့၏ လတ်ဆတ်သော မုန့်များကို ကြည့်ရှုပါ",
-				ImageURL: "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "လုပ်ဆောင်မည်", Payload: "MENU_ORDER_PRODUCTS"}},
-			},
-			{
-				Title:    "ℹ️ အကြောင်းနှင့်အကူအညီ",
-				Subtitle: "ကျွန်ုပ်တို့အကြောင်းနှင့် အသုံးပြုနည်း",
-				ImageURL: "https://images.unsplash.com/photo-1556910103-1c02745aae4d?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "ဖတ်ရှုမည်", Payload: "MENU_ABOUT"}},
-			},
-			{
-				Title:    "🌐 ဘာသာပြောင်းမယ်",
-				Subtitle: "English သို့ ပြောင်းလဲရန်",
-				ImageURL: "https://images.unsplash.com/photo-1523050854058-8df90110c9f1?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "ပြောင်းမည်", Payload: "MENU_CHANGE_LANG"}},
-			},
-		}
-	} else {
-		elements = []Element{
-			{
-				Title:    "� Order Now",
-				Subtitle: "Browse our fresh baked goods",
-				ImageURL: "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "Start Order", Payload: "MENU_ORDER_PRODUCTS"}},
-			},
-			{
-				Title:    "ℹ️ About & Help",
-				Subtitle: "Learn about us and how to order",
-				ImageURL: "https://images.unsplash.com/photo-1556910103-1c02745aae4d?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "Learn More", Payload: "MENU_ABOUT"}},
-			},
-			{
-				Title:    "🌐 Change Language",
-				Subtitle: "Switch to Myanmar language",
-				ImageURL: "https://images.unsplash.com/photo-1523050854058-8df90110c9f1?w=300&h=200&fit=crop",
-				Buttons:  []Button{{Type: "postback", Title: "Switch", Payload: "MENU_CHANGE_LANG"}},
-			},
-		}
+	elements := []Element{
+		{
+			Title:    tr(state, "main_menu.order.title"),
+			Subtitle: tr(state, "main_menu.order.subtitle"),
+			ImageURL: "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=300&h=200&fit=crop",
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "main_menu.order.button"), Payload: "MENU_ORDER_PRODUCTS"}},
+		},
+		{
+			Title:    tr(state, "main_menu.about.title"),
+			Subtitle: tr(state, "main_menu.about.subtitle"),
+			ImageURL: "https://images.unsplash.com/photo-1556910103-1c02745aae4d?w=300&h=200&fit=crop",
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "main_menu.about.button"), Payload: "MENU_ABOUT"}},
+		},
+		{
+			Title:    tr(state, "main_menu.language.title"),
+			Subtitle: tr(state, "main_menu.language.subtitle"),
+			ImageURL: "https://images.unsplash.com/photo-1523050854058-8df90110c9f1?w=300&h=200&fit=crop",
+			Buttons:  []Button{{Type: "postback", Title: tr(state, "main_menu.language.button"), Payload: "MENU_CHANGE_LANG"}},
+		},
 	}
 
 	SendGenericTemplate(userID, elements)
 }
 
-// showProducts displays the product catalog
+// showProducts displays the product catalog, starting with the category picker
 func showProducts(userID string) {
 	// Check business hours before showing products
 	if !checkBusinessHours(userID) {
 		return
 	}
 
-	state := GetUserState(userID)
-	state.State = "awaiting_product"
-	SendGenericTemplate(userID, getProductElements())
+	showCategories(userID)
 }
 
 // askQuantity asks how many items the user wants
@@ -218,23 +151,25 @@ func askQuantity(userID string) {
 		{ContentType: "text", Title: "3", Payload: "QTY_3"},
 		{ContentType: "text", Title: "4", Payload: "QTY_4"},
 		{ContentType: "text", Title: "5", Payload: "QTY_5"},
-		{ContentType: "text", Title: "⬅️ Back", Payload: "GO_BACK"},
-		{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.back"), Payload: "GO_BACK"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 	}
-	SendQuickReplies(userID, fmt.Sprintf("How many %s %s would you like?", state.CurrentEmoji, state.CurrentProduct), quickReplies)
+	trackFunnelStep(userID, models.FunnelQuantity)
+	SendQuickReplies(userID, tr(state, "quantity.ask", i18n.Args{"emoji": state.CurrentEmoji, "product": currentItemLabel(state)}), quickReplies)
 }
 
 // askName asks for the customer's name
 func askName(userID string) {
 	state := GetUserState(userID)
 	state.State = "awaiting_name"
+	trackFunnelStep(userID, models.FunnelName)
 
 	// Send a message with quick reply options to go back
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "⬅️ Back to Cart", Payload: "GO_BACK"},
-		{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.back_to_cart"), Payload: "GO_BACK"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 	}
-	SendQuickReplies(userID, "Great! What's your name?", quickReplies)
+	SendQuickReplies(userID, tr(state, "name.ask_great"), quickReplies)
 }
 
 // addToCart adds the current product to the cart
@@ -246,13 +181,21 @@ func addToCart(userID string) {
 		Product:      state.CurrentProduct,
 		ProductEmoji: state.CurrentEmoji,
 		Quantity:     state.CurrentQuantity,
+		ProductID:    state.CurrentProductID,
+		UnitPrice:    state.CurrentPrice,
+		Options:      state.CurrentOptions,
+		Inscription:  state.CurrentInscription,
 	}
 	state.Cart = append(state.Cart, cartItem)
+	trackFunnelStep(userID, models.FunnelCart)
 
 	// Clear current product
-	state.CurrentProduct = ""
-	state.CurrentEmoji = ""
-	state.CurrentQuantity = 0
+	clearCurrentItem(state)
+
+	// Customize the next item of a free-text order, if any
+	if customizeNextQueuedItem(userID) {
+		return
+	}
 
 	// Ask if they want to add more
 	askAddMore(userID)
@@ -270,13 +213,17 @@ func askAddMore(userID string) {
 
 	// Show what was just added
 	lastItem := state.Cart[len(state.Cart)-1]
-	message := fmt.Sprintf("✅ %d× %s %s added\n\nCart: %d items",
-		lastItem.Quantity, lastItem.ProductEmoji, lastItem.Product, totalItems)
+	message := tr(state, "cart.added", i18n.Args{
+		"quantity": lastItem.Quantity,
+		"emoji":    lastItem.ProductEmoji,
+		"product":  cartItemLabel(state, lastItem),
+		"count":    totalItems,
+	})
 
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "Add More", Payload: "ADD_MORE_ITEMS"},
-		{ContentType: "text", Title: fmt.Sprintf("Checkout (%d)", totalItems), Payload: "CHECKOUT"},
-		{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.add_more"), Payload: "ADD_MORE_ITEMS"},
+		{ContentType: "text", Title: tr(state, "button.checkout", i18n.Args{"count": totalItems}), Payload: "CHECKOUT"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 	}
 
 	state.State = "awaiting_cart_decision"
@@ -288,21 +235,21 @@ func showCart(userID string) {
 	state := GetUserState(userID)
 
 	if len(state.Cart) == 0 {
-		SendMessage(userID, "🛒 Your cart is empty!\n\nLet's start ordering!")
+		SendMessage(userID, tr(state, "cart.empty"))
 		startOrderingFlow(userID)
 		return
 	}
 
 	// Build cart display
-	cartDisplay := "🛒 **Your Cart:**\n\n"
+	cartDisplay := tr(state, "cart.title") + "\n\n"
 	totalItems := 0
 
 	for _, item := range state.Cart {
-		cartDisplay += fmt.Sprintf("• %d× %s %s\n", item.Quantity, item.ProductEmoji, item.Product)
+		cartDisplay += fmt.Sprintf("• %d× %s %s\n", item.Quantity, item.ProductEmoji, cartItemLabel(state, item))
 		totalItems += item.Quantity
 	}
 
-	cartDisplay += fmt.Sprintf("\n**Total Items:** %d", totalItems)
+	cartDisplay += "\n" + tr(state, "cart.total_items", i18n.Args{"count": totalItems})
 
 	SendMessage(userID, cartDisplay)
 }
@@ -310,81 +257,74 @@ func showCart(userID string) {
 // showOrderSummary displays the order summary and asks for confirmation
 func showOrderSummary(userID string) {
 	state := GetUserState(userID)
+	trackFunnelStep(userID, models.FunnelConfirm)
 
 	deliveryIcon := "🏠"
 	if state.DeliveryType == "delivery" {
 		deliveryIcon = "🚚"
 	}
 
+	// Calculate totals
+	subtotal, deliveryFee, totalAmount, err := calculateOrderTotals(state.Cart, state.DeliveryType, state.Address)
+	if err != nil {
+		log.Printf("❌ Error pricing order summary for %s: %v", userID, err)
+		SendMessage(userID, tr(state, "order.error"))
+		ResetUserState(userID)
+		return
+	}
+
 	// Build cart items display with pricing
 	cartDisplay := ""
 	totalItems := 0
 	for _, item := range state.Cart {
-		itemPrice := 0.00
-		if product, exists := ProductCatalog[item.Product]; exists {
-			priceStr := strings.ReplaceAll(product.Price, "$", "")
-			if price, err := strconv.ParseFloat(priceStr, 64); err == nil {
-				itemPrice = price * float64(item.Quantity)
-			}
-		}
-		cartDisplay += fmt.Sprintf("• %d× %s %s - $%.2f\n", item.Quantity, item.ProductEmoji, item.Product, itemPrice)
+		price, _ := unitPrice(item)
+		cartDisplay += fmt.Sprintf("• %d× %s %s - $%.2f\n", item.Quantity, item.ProductEmoji, cartItemLabel(state, item), price*float64(item.Quantity))
 		totalItems += item.Quantity
 	}
 
-	// Calculate totals
-	subtotal, deliveryFee, totalAmount := calculateOrderTotals(state.Cart, state.DeliveryType, state.Address)
-
 	// Pricing breakdown
-	pricingInfo := fmt.Sprintf(
-		"\n💰 **Pricing:**\n"+
-			"Subtotal: $%.2f\n"+
-			"Delivery Fee: $%.2f\n"+
-			"━━━━━━━━━━━━\n"+
-			"**Total: $%.2f**",
-		subtotal,
-		deliveryFee,
-		totalAmount,
-	)
-
-	summary := fmt.Sprintf(
-		"📋 **Order Summary**\n\n"+
-			"🛒 **Your Items:**\n"+
-			"%s"+
-			"%s\n\n"+
-			"👤 **Customer:** %s\n"+
-			"%s **%s**\n"+
-			"📍 **Address:** %s\n\n"+
-			"Everything look good?",
-		cartDisplay,
-		pricingInfo,
-		state.CustomerName,
-		deliveryIcon, strings.Title(state.DeliveryType),
-		state.Address,
-	)
+	pricingInfo := "\n" + pricingBreakdown(state, subtotal, deliveryFee, totalAmount)
+
+	summary := tr(state, "summary.text", i18n.Args{
+		"items":         cartDisplay,
+		"pricing":       pricingInfo,
+		"name":          state.CustomerName,
+		"delivery_icon": deliveryIcon,
+		"delivery_type": tr(state, "delivery_type."+state.DeliveryType),
+		"address":       state.Address,
+		"when":          orderTimeText(state),
+	})
 
 	quickReplies := []QuickReply{
-		{ContentType: "text", Title: "✅ Confirm Order", Payload: "CONFIRM_ORDER"},
-		{ContentType: "text", Title: "❌ Cancel", Payload: "CANCEL_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.confirm_order"), Payload: "CONFIRM_ORDER"},
+		{ContentType: "text", Title: tr(state, "button.cancel"), Payload: "CANCEL_ORDER"},
 	}
 	SendQuickReplies(userID, summary, quickReplies)
 }
 
 // showMenu displays the product menu as text then shows product cards
 func showMenu(userID string) {
-	menu := "🍰 **BakeFlow Menu**\n\n" +
-		"🎂 **Cakes**\n" +
-		"  • Chocolate Cake - $25\n" +
-		"  • Vanilla Cake - $24\n" +
-		"  • Red Velvet Cake - $28\n\n" +
-		"🥐 **Pastries**\n" +
-		"  • Croissant - $4.50\n" +
-		"  • Cinnamon Roll - $5\n\n" +
-		"🧁 **Others**\n" +
-		"  • Chocolate Cupcake - $3.50\n" +
-		"  • Fresh Bread - $6\n" +
-		"  • Coffee - $5\n\n" +
-		"👇 Click the buttons below to order!"
+	state := GetUserState(userID)
+	menu := tr(state, "menu.text")
 
 	SendMessage(userID, menu)
 	showProducts(userID)
 }
+
+// pricingBreakdown formats subtotal, delivery fee and total for summaries and receipts
+func pricingBreakdown(state *UserState, subtotal, deliveryFee, total float64) string {
+	return tr(state, "pricing.text", i18n.Args{
+		"subtotal":     fmt.Sprintf("%.2f", subtotal),
+		"delivery_fee": fmt.Sprintf("%.2f", deliveryFee),
+		"total":        fmt.Sprintf("%.2f", total),
+	})
+}
+
+// sendOrderCancelled resets the conversation and tells the customer the order was cancelled
+func sendOrderCancelled(userID string) {
+	state := GetUserState(userID)
+	ResetUserState(userID)
+	SendMessage(userID, tr(state, "order.cancelled"))
+	SendMessage(userID, "━━━━━━━━━━━━━━━━━")
+	SendMessage(userID, tr(state, "order.start_fresh"))
+}
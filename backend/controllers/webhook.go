@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 // UI helper functions moved to `ui_helpers.go`.
@@ -169,27 +170,7 @@ func ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
 
 		// Process each messaging event
 		for _, event := range entry.Messaging {
-			senderID := event.Sender.ID
-
-			// Check if this is a quick reply (button click from quick reply)
-			if event.Message.QuickReply != nil && event.Message.QuickReply.Payload != "" {
-				log.Printf("⚡ Quick Reply from %s: %s", senderID, event.Message.QuickReply.Payload)
-				handlePostback(senderID, event.Message.QuickReply.Payload)
-				continue
-			}
-
-			// Check if this is a message event (text input)
-			if event.Message.Text != "" {
-				log.Printf("📨 Message from %s: %s", senderID, event.Message.Text)
-				handleMessage(senderID, strings.TrimSpace(event.Message.Text))
-				continue
-			}
-
-			// Check for postback (button clicks from structured messages)
-			if event.Postback.Payload != "" {
-				log.Printf("🔘 Postback from %s: %s", senderID, event.Postback.Payload)
-				handlePostback(senderID, event.Postback.Payload)
-			}
+			handleMessagingEvent(event)
 		}
 	}
 
@@ -199,5 +180,33 @@ func ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("EVENT_RECEIVED"))
 }
 
+// handleMessagingEvent handles one message or postback while holding the customer's lock,
+// so the cart reminder never sees their state half-updated
+func handleMessagingEvent(event Messaging) {
+	senderID := event.Sender.ID
+	defer lockUser(senderID)()
+	GetUserState(senderID).LastActivity = time.Now()
+
+	// Check if this is a quick reply (button click from quick reply)
+	if event.Message.QuickReply != nil && event.Message.QuickReply.Payload != "" {
+		log.Printf("⚡ Quick Reply from %s: %s", senderID, event.Message.QuickReply.Payload)
+		handlePostback(senderID, event.Message.QuickReply.Payload)
+		return
+	}
+
+	// Check if this is a message event (text input)
+	if event.Message.Text != "" {
+		log.Printf("📨 Message from %s: %s", senderID, event.Message.Text)
+		handleMessage(senderID, strings.TrimSpace(event.Message.Text))
+		return
+	}
+
+	// Check for postback (button clicks from structured messages)
+	if event.Postback.Payload != "" {
+		log.Printf("🔘 Postback from %s: %s", senderID, event.Postback.Payload)
+		handlePostback(senderID, event.Postback.Payload)
+	}
+}
+
 // The rest of the webhook logic (message/postback handlers and helpers)
 // has been moved to `flow.go` for clarity and easier maintenance.
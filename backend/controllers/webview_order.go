@@ -17,17 +17,14 @@ func ShowWebviewOrderForm(userID string) {
 	// TODO: Replace with your ngrok URL for testing or production domain
 	webviewURL := fmt.Sprintf("https://consuelo-subcardinal-nonfallaciously.ngrok-free.dev/order-form.html?user_id=%s", userID)
 
-	msg := "🍰 Order from our mini shop!"
-	if state.Language == "my" {
-		msg = "🍰 ကျွန်ုပ်တို့၏ စတိုးအသေးမှ မှာယူပါ!"
-	}
+	msg := tr(state, "webview.prompt")
 
 	// Create button that opens webview INSIDE Messenger
 	// Using full height as per Facebook documentation
 	buttons := []Button{
 		{
 			Type:                "web_url",
-			Title:               "🛒 Open Menu",
+			Title:               tr(state, "webview.button"),
 			URL:                 webviewURL,
 			MessengerExtensions: true,
 			WebviewHeightRatio:  "full",
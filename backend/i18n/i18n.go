@@ -0,0 +1,212 @@
+// Package i18n holds the customer-facing message catalog.
+//
+// Messages live in one JSON file per locale under locales/ (en.json, my.json, th.json).
+// Each key maps either to a string or, for count-dependent text, to an object of
+// plural forms ({"one": "...", "other": "..."}). Placeholders use {name} syntax.
+// Adding a language only requires dropping a new <code>.json file next to the others.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used when a user has not picked a language or a key is missing
+const DefaultLanguage = "en"
+
+// Args are the placeholder values for a message, e.g. Args{"name": "Mya"}
+type Args map[string]interface{}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// message is a single catalog entry: plain text or plural forms
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// UnmarshalJSON accepts either "text" or {"one": "...", "other": "..."}
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.plural); err != nil {
+		return err
+	}
+	if _, ok := m.plural["other"]; !ok {
+		return fmt.Errorf("plural message is missing the \"other\" form")
+	}
+	return nil
+}
+
+var (
+	catalogs  map[string]map[string]message
+	loadErr   error
+	loadOnce  sync.Once
+	languages []string
+)
+
+// Load parses the embedded locale files. It is safe to call more than once.
+func Load() error {
+	loadOnce.Do(func() {
+		catalogs, loadErr = loadCatalogs()
+		for lang := range catalogs {
+			languages = append(languages, lang)
+		}
+		// Default language first, then the rest alphabetically
+		sort.Slice(languages, func(i, j int) bool {
+			if languages[i] == DefaultLanguage || languages[j] == DefaultLanguage {
+				return languages[i] == DefaultLanguage
+			}
+			return languages[i] < languages[j]
+		})
+	})
+	return loadErr
+}
+
+func loadCatalogs() (map[string]map[string]message, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]map[string]message{}
+	for _, e := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var msgs map[string]message
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", e.Name(), err)
+		}
+		result[strings.TrimSuffix(e.Name(), ".json")] = msgs
+	}
+	if _, ok := result[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("default locale %s.json not found", DefaultLanguage)
+	}
+	return result, nil
+}
+
+// Languages returns the available language codes, default language first
+func Languages() []string {
+	Load()
+	return languages
+}
+
+// IsSupported reports whether a language code has a catalog
+func IsSupported(lang string) bool {
+	Load()
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Has reports whether key exists in the default catalog
+func Has(key string) bool {
+	Load()
+	_, ok := lookup(DefaultLanguage, key)
+	return ok
+}
+
+// MissingKeys compares every locale against the default one and returns the
+// keys each locale lacks (only locales with gaps are included)
+func MissingKeys() map[string][]string {
+	Load()
+	missing := map[string][]string{}
+	base := catalogs[DefaultLanguage]
+	for lang, msgs := range catalogs {
+		if lang == DefaultLanguage {
+			continue
+		}
+		for key, baseMsg := range base {
+			msg, ok := msgs[key]
+			if !ok {
+				missing[lang] = append(missing[lang], key)
+				continue
+			}
+			// A plural message must provide the forms its language needs
+			if baseMsg.plural != nil && msg.plural == nil && msg.text == "" {
+				missing[lang] = append(missing[lang], key)
+			}
+		}
+		sort.Strings(missing[lang])
+	}
+	return missing
+}
+
+// T returns the message for key in lang, falling back to the default language
+// and finally to the key itself so a missing string is visible but not fatal
+func T(lang, key string, args ...Args) string {
+	Load()
+
+	msg, ok := lookup(lang, key)
+	if !ok {
+		msg, ok = lookup(DefaultLanguage, key)
+		lang = DefaultLanguage
+	}
+	if !ok {
+		return key
+	}
+
+	var a Args
+	if len(args) > 0 {
+		a = args[0]
+	}
+
+	text := msg.text
+	if msg.plural != nil {
+		text = msg.plural["other"]
+		if count, ok := countArg(a); ok {
+			if form, ok := msg.plural[pluralCategory(lang, count)]; ok {
+				text = form
+			}
+		}
+	}
+	return interpolate(text, a)
+}
+
+func lookup(lang, key string) (message, bool) {
+	msgs, ok := catalogs[lang]
+	if !ok {
+		return message{}, false
+	}
+	msg, ok := msgs[key]
+	return msg, ok
+}
+
+// countArg extracts the "count" placeholder used to choose a plural form
+func countArg(a Args) (int, bool) {
+	switch v := a["count"].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// Format fills {name} placeholders in text that did not come from the catalog
+// (e.g. admin-edited templates)
+func Format(text string, args Args) string {
+	return interpolate(text, args)
+}
+
+// interpolate replaces {name} placeholders with their values
+func interpolate(text string, a Args) string {
+	if len(a) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, len(a)*2)
+	for k, v := range a {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,17 @@
+package i18n
+
+// pluralCategory returns the CLDR plural category for n in a language.
+// Only the categories our locales use are implemented.
+func pluralCategory(lang string, n int) string {
+	switch lang {
+	case "my", "th":
+		// Burmese and Thai do not inflect for number
+		return "other"
+	default:
+		// English-style: 1 is singular, everything else plural
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
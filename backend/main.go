@@ -3,10 +3,12 @@ package main
 import (
 	"bakeflow/configs"
 	"bakeflow/controllers"
+	"bakeflow/i18n"
 	"bakeflow/routes"
 	"log"
 	"net/http"
 	"os"
+	_ "time/tzdata" // shop timezone must resolve even on hosts without zoneinfo
 
 	"github.com/joho/godotenv"
 )
@@ -31,9 +33,30 @@ func main() {
 		log.Println("WARNING: PAGE_ACCESS_TOKEN is not set")
 	}
 
+	// Load the customer-facing message catalog
+	if err := i18n.Load(); err != nil {
+		log.Fatalf("❌ Failed to load message catalog: %v", err)
+	}
+	for lang, keys := range i18n.MissingKeys() {
+		log.Printf("⚠️  Locale %s is missing %d message(s), English will be used: %v", lang, len(keys), keys)
+	}
+	log.Printf("✅ Message catalog loaded: %v", i18n.Languages())
+
 	// Connect to database
 	configs.ConnectDB()
 
+	// Notify staff when products or ingredients run low
+	controllers.StartStockAlertChecker()
+
+	// Remind customers who left items in their cart
+	controllers.StartCartReminder()
+
+	// Permanently remove products deleted more than PRODUCT_PURGE_DAYS ago
+	controllers.StartProductPurger()
+
+	// Publish and unpublish products at their scheduled times
+	controllers.StartProductScheduler()
+
 	// Setup Facebook Messenger Persistent Menu
 	log.Println("⚙️  Setting up Facebook Messenger features...")
 	controllers.SetupPersistentMenu()
@@ -0,0 +1,18 @@
+-- Migration: Add product aliases for chat product matching
+-- Description: Admin-defined synonyms (English + Burmese) used by the Messenger bot
+--              to recognise products typed in free text
+
+CREATE TABLE IF NOT EXISTS product_aliases (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    alias VARCHAR(255) NOT NULL,
+    language VARCHAR(10) NOT NULL DEFAULT 'en',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(product_id, alias)
+);
+
+-- Index for faster lookups when building the matcher
+CREATE INDEX IF NOT EXISTS idx_product_aliases_product_id ON product_aliases(product_id);
+
+COMMENT ON TABLE product_aliases IS 'Alternative names/synonyms for products, matched against customer chat messages';
+COMMENT ON COLUMN product_aliases.language IS 'en or my (Myanmar/Burmese)';
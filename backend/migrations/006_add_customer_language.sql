@@ -0,0 +1,30 @@
+-- Migration: Persist customer language and editable status notifications
+-- Description: Remembers each Messenger customer's chosen language so notifications sent
+--              outside the conversation (status updates) use it, and lets admins override
+--              the built-in status notification text per language
+
+-- Customers known by their Messenger PSID
+CREATE TABLE IF NOT EXISTS customers (
+    sender_id TEXT PRIMARY KEY,
+    language VARCHAR(10) NOT NULL DEFAULT 'en',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Language the order was placed in (fallback when no customer record exists)
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS language VARCHAR(10);
+
+-- Admin overrides for status notifications; missing rows use the message catalog
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    template TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(status, language)
+);
+
+COMMENT ON TABLE customers IS 'Messenger customers and their preferred language';
+COMMENT ON COLUMN orders.language IS 'Customer language when the order was placed (en, my, th)';
+COMMENT ON TABLE notification_templates IS 'Per-status, per-language notification text edited by admins; supports {id} and {status} placeholders';
@@ -0,0 +1,44 @@
+-- Migration: Configurable business hours, holidays and closures
+-- Description: Weekly opening hours, closure date ranges (holidays) and shop-wide
+--              settings such as the shop timezone and temporary "closed" overrides
+
+-- Shop-wide key/value settings (timezone, closed_until, ...)
+CREATE TABLE IF NOT EXISTS shop_settings (
+    key VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Regular opening hours, one row per weekday (0 = Sunday ... 6 = Saturday)
+CREATE TABLE IF NOT EXISTS business_hours (
+    weekday SMALLINT PRIMARY KEY CHECK (weekday BETWEEN 0 AND 6),
+    open_time TIME NOT NULL,
+    close_time TIME NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (open_time < close_time)
+);
+
+-- Holidays and other full-day closures (inclusive date range, shop timezone)
+CREATE TABLE IF NOT EXISTS business_closures (
+    id SERIAL PRIMARY KEY,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (start_date <= end_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_business_closures_end_date ON business_closures(end_date);
+
+-- Default: open every day 8:00 AM - 8:00 PM, Yangon time
+INSERT INTO business_hours (weekday, open_time, close_time)
+SELECT d, '08:00', '20:00' FROM generate_series(0, 6) AS d
+ON CONFLICT (weekday) DO NOTHING;
+
+INSERT INTO shop_settings (key, value) VALUES ('timezone', 'Asia/Yangon')
+ON CONFLICT (key) DO NOTHING;
+
+COMMENT ON TABLE shop_settings IS 'Shop-wide settings as key/value pairs';
+COMMENT ON TABLE business_hours IS 'Weekly opening hours in the shop timezone; weekday 0 = Sunday';
+COMMENT ON TABLE business_closures IS 'Holidays and closures covering whole days from start_date to end_date';
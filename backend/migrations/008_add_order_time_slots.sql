@@ -0,0 +1,29 @@
+-- Migration: Scheduled pre-orders with pickup/delivery time slots
+-- Description: Stores the chosen time slot on orders and the per-category lead times
+--              used to decide which slots a cart can be booked into
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS slot_start TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS slot_end TIMESTAMPTZ;
+
+-- Index for counting bookings per slot and the admin slot view
+CREATE INDEX IF NOT EXISTS idx_orders_slot_start ON orders(slot_start);
+
+-- Minimum notice needed per product category (e.g. custom cakes need a day)
+CREATE TABLE IF NOT EXISTS slot_lead_times (
+    category VARCHAR(100) PRIMARY KEY,
+    lead_minutes INTEGER NOT NULL CHECK (lead_minutes >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Default slot settings (see models.SlotSettings)
+INSERT INTO shop_settings (key, value) VALUES
+('slot_length_minutes', '60'),
+('slot_capacity', '10'),
+('slot_default_lead_minutes', '30'),
+('slot_booking_days', '7')
+ON CONFLICT (key) DO NOTHING;
+
+COMMENT ON COLUMN orders.slot_start IS 'Start of the scheduled pickup/delivery slot; NULL = as soon as possible';
+COMMENT ON COLUMN orders.slot_end IS 'End of the scheduled pickup/delivery slot';
+COMMENT ON TABLE slot_lead_times IS 'Minimum minutes between ordering and the slot start, per product category';
@@ -0,0 +1,45 @@
+-- Migration: Ingredient inventory and product recipes
+-- Description: Tracks ingredient stock (flour, butter, cream...) with units, the quantity of
+--              each ingredient used per product unit, and deducts ingredients when an order
+--              moves to 'preparing'
+
+CREATE TABLE IF NOT EXISTS ingredients (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    unit VARCHAR(20) NOT NULL,
+    stock DECIMAL(12, 3) NOT NULL DEFAULT 0,
+    low_stock_threshold DECIMAL(12, 3) NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Ingredient quantity needed for ONE unit of a product
+CREATE TABLE IF NOT EXISTS product_recipes (
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id) ON DELETE RESTRICT,
+    quantity DECIMAL(12, 3) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (product_id, ingredient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_recipes_ingredient_id ON product_recipes(ingredient_id);
+
+-- Every stock change (order deduction, restock, manual correction)
+CREATE TABLE IF NOT EXISTS ingredient_movements (
+    id SERIAL PRIMARY KEY,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id) ON DELETE CASCADE,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    change DECIMAL(12, 3) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_movements_ingredient_id ON ingredient_movements(ingredient_id);
+
+-- Guards against deducting an order's ingredients twice
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS ingredients_deducted_at TIMESTAMP;
+
+COMMENT ON TABLE ingredients IS 'Raw ingredient inventory; stock may go negative if the kitchen used more than was recorded';
+COMMENT ON COLUMN ingredients.unit IS 'g, kg, ml, l or pcs';
+COMMENT ON TABLE product_recipes IS 'Ingredient quantity (in the ingredient unit) per product unit';
+COMMENT ON COLUMN ingredient_movements.reason IS 'order, restock or adjustment';
+COMMENT ON COLUMN orders.ingredients_deducted_at IS 'When the recipe ingredients were deducted from stock (on preparing)';
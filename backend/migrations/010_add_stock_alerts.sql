@@ -0,0 +1,19 @@
+-- Migration: Per-product reorder thresholds and staff stock alerts
+-- Description: Replaces the fixed "< 10" low-stock rule with a threshold per product and
+--              remembers which items staff were already alerted about
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS low_stock_threshold INTEGER NOT NULL DEFAULT 10 CHECK (low_stock_threshold >= 0);
+
+-- One row per item currently below its threshold that staff were notified about.
+-- Rows are removed once the item is restocked, so the next drop alerts again.
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('product', 'ingredient')),
+    item_id INTEGER NOT NULL,
+    stock DECIMAL(12, 3) NOT NULL,
+    alerted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, item_id)
+);
+
+COMMENT ON COLUMN products.low_stock_threshold IS 'Reorder point: the product is low on stock when stock < threshold (0 disables alerts)';
+COMMENT ON TABLE stock_alerts IS 'Suppresses repeated low-stock alerts until the item recovers';
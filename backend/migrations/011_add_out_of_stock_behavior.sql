@@ -0,0 +1,11 @@
+-- Migration: Automatic product deactivation when out of stock
+-- Description: Per-product choice of what happens at zero stock: nothing, hide the product
+--              (and show it again on restock) or keep it listed with a "sold out" badge
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS out_of_stock_behavior VARCHAR(20) NOT NULL DEFAULT 'none'
+        CHECK (out_of_stock_behavior IN ('none', 'hide', 'badge')),
+    ADD COLUMN IF NOT EXISTS auto_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN products.out_of_stock_behavior IS 'none, hide (auto-deactivate at zero stock, reactivate on restock) or badge (show as sold out)';
+COMMENT ON COLUMN products.auto_hidden IS 'TRUE when the system (not an admin) deactivated the product for being out of stock';
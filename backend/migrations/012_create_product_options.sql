@@ -0,0 +1,40 @@
+-- Migration: Product variants and customization options
+-- Description: Option groups per product (size, flavour...) with priced choices and optional
+--              per-choice stock, free-text inscription groups, and the chosen options on order items
+
+CREATE TABLE IF NOT EXISTS product_option_groups (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'choice' CHECK (kind IN ('choice', 'inscription')),
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    max_length INTEGER NOT NULL DEFAULT 0 CHECK (max_length >= 0),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_option_groups_product_id ON product_option_groups(product_id);
+
+CREATE TABLE IF NOT EXISTS product_options (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES product_option_groups(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    price_delta DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    stock INTEGER CHECK (stock >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_options_group_id ON product_options(group_id);
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS options JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS inscription TEXT;
+
+COMMENT ON TABLE product_option_groups IS 'Customization steps for a product: a single choice (size, flavour) or a free-text inscription';
+COMMENT ON COLUMN product_option_groups.max_length IS 'Maximum inscription length in characters (inscription groups only)';
+COMMENT ON COLUMN product_options.price_delta IS 'Added to the product price when chosen (may be negative)';
+COMMENT ON COLUMN product_options.stock IS 'Per-variant stock; NULL = not tracked';
+COMMENT ON COLUMN order_items.options IS 'Chosen options: [{"group_id", "group", "option_id", "option", "price_delta"}]';
+COMMENT ON COLUMN order_items.inscription IS 'Custom message written on the product';
@@ -0,0 +1,57 @@
+-- Migration: Categories as first-class entities
+-- Description: Categories get their own table (localized names, emoji, menu order, active flag)
+--              and products reference them by ID. products.category is kept as the
+--              category's name so existing filters and slot lead times keep working.
+
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    names JSONB NOT NULL DEFAULT '{}',
+    emoji VARCHAR(16) NOT NULL DEFAULT '🍰',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name ON categories(LOWER(name));
+
+DROP TRIGGER IF EXISTS update_categories_updated_at ON categories;
+CREATE TRIGGER update_categories_updated_at
+    BEFORE UPDATE ON categories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Categories the bot and admin form used to hardcode
+INSERT INTO categories (name, names, emoji, sort_order) VALUES
+('Cakes', '{"my": "ကိတ်မုန့်", "th": "เค้ก"}', '🎂', 1),
+('Cupcakes', '{"my": "ကပ်ကိတ်", "th": "คัพเค้ก"}', '🧁', 2),
+('Muffins', '{"my": "မာဖင်", "th": "มัฟฟิน"}', '🧁', 3),
+('Tarts', '{"my": "တာ့တ်", "th": "ทาร์ต"}', '🥧', 4),
+('Cookies', '{"my": "ကွတ်ကီး", "th": "คุกกี้"}', '🍪', 5),
+('Pastries', '{"my": "ပေါင်မုန့်ချို", "th": "เพสทรี"}', '🥐', 6),
+('Breads', '{"my": "ပေါင်မုန့်", "th": "ขนมปัง"}', '🍞', 7),
+('Coffee', '{"my": "ကော်ဖီ", "th": "กาแฟ"}', '☕', 8)
+ON CONFLICT DO NOTHING;
+
+-- Any other category already used by a product
+INSERT INTO categories (name, sort_order)
+SELECT MIN(p.category), 100 FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM categories c WHERE LOWER(c.name) = LOWER(p.category))
+GROUP BY LOWER(p.category)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT;
+
+UPDATE products p SET category_id = c.id, category = c.name
+FROM categories c
+WHERE p.category_id IS NULL AND LOWER(c.name) = LOWER(p.category);
+
+ALTER TABLE products ALTER COLUMN category_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+
+COMMENT ON COLUMN categories.names IS 'Localized names by language code, e.g. {"my": "ကိတ်မုန့်", "th": "เค้ก"}; name is the fallback';
+COMMENT ON COLUMN categories.sort_order IS 'Position in the Messenger category picker (lowest first)';
+COMMENT ON COLUMN products.category IS 'Name of the category_id category, kept in sync on rename';
@@ -0,0 +1,16 @@
+-- Migration: Product SKUs and batch audit entries
+-- Description: Optional stock-keeping unit per product (used to match rows in bulk imports)
+--              and product_logs entries that cover a whole import batch instead of one product
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+-- SKUs are unique among live products, ignoring case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products(LOWER(sku))
+    WHERE sku IS NOT NULL AND deleted_at IS NULL;
+
+-- Batch entries (e.g. IMPORT) are not tied to a single product
+ALTER TABLE product_logs ALTER COLUMN product_id DROP NOT NULL;
+
+COMMENT ON COLUMN products.sku IS 'Optional stock-keeping unit, unique among non-deleted products';
+COMMENT ON COLUMN product_logs.product_id IS 'Product the entry is about; NULL for batch entries such as imports';
@@ -0,0 +1,27 @@
+-- Migration: Scheduled publishing and time-limited product availability
+-- Description: publish_at/unpublish_at let the scheduler activate and deactivate products at a
+--              given moment; availability windows limit a product to certain weekdays and times
+--              of day in the shop timezone (e.g. breakfast pastries until 11:00)
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_products_publish_at ON products(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_products_unpublish_at ON products(unpublish_at) WHERE unpublish_at IS NOT NULL;
+
+COMMENT ON COLUMN products.publish_at IS 'When the scheduler activates the product (cleared once applied)';
+COMMENT ON COLUMN products.unpublish_at IS 'When the scheduler deactivates the product (cleared once applied)';
+
+-- A product with windows is only offered inside one of them; without windows it is offered all day
+CREATE TABLE IF NOT EXISTS product_availability_windows (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 = Sunday ... 6 = Saturday
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_availability_windows_product ON product_availability_windows(product_id, weekday);
@@ -0,0 +1,49 @@
+-- Migration: Full-text and multilingual product search
+-- Description: Products get localized names and search tags. A trigger keeps a lowercased
+--              search_text (for substring and typo-tolerant trigram matching, which also works
+--              for Burmese and Thai text without spaces between words) and a weighted
+--              search_vector (for ranked full-text matching) up to date.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS names JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS search_text TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+COMMENT ON COLUMN products.names IS 'Product name by language code, e.g. {"my": "...", "th": "..."}';
+COMMENT ON COLUMN products.tags IS 'Lowercase search keywords (e.g. chocolate, birthday, vegan)';
+COMMENT ON COLUMN products.search_text IS 'Maintained by trigger: lowercased searchable text';
+COMMENT ON COLUMN products.search_vector IS 'Maintained by trigger: weighted full-text document';
+
+CREATE OR REPLACE FUNCTION update_product_search()
+RETURNS TRIGGER AS $$
+DECLARE
+    localized TEXT;
+    tag_text TEXT;
+BEGIN
+    localized := COALESCE((SELECT string_agg(value, ' ') FROM jsonb_each_text(NEW.names)), '');
+    tag_text := array_to_string(NEW.tags, ' ');
+    NEW.search_text := LOWER(concat_ws(' ', NEW.name, localized, tag_text, NEW.sku, NEW.category, NEW.description));
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', concat_ws(' ', NEW.name, localized, NEW.sku)), 'A') ||
+        setweight(to_tsvector('simple', concat_ws(' ', tag_text, NEW.category)), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.description, '')), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_products_search ON products;
+CREATE TRIGGER update_products_search
+    BEFORE INSERT OR UPDATE OF name, names, tags, sku, category, description ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION update_product_search();
+
+-- Fill in existing products without touching updated_at (ETags stay valid)
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+UPDATE products SET names = names;
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_search_text ON products USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,40 @@
+-- Migration: Daily product analytics
+-- Description: Views and purchases per product per day (shop timezone) for trend charts,
+--              next to the lifetime counters in product_analytics. Order items remember the
+--              catalog product they were ordered as, so purchases survive product renames.
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS product_id INTEGER REFERENCES products(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
+
+COMMENT ON COLUMN order_items.product_id IS 'Catalog product ordered; NULL for legacy items or purged products';
+
+-- Link existing items by name, as recipes and the production plan do
+UPDATE order_items oi
+SET product_id = p.id
+FROM products p
+WHERE oi.product_id IS NULL AND p.deleted_at IS NULL AND LOWER(p.name) = LOWER(TRIM(oi.product));
+
+-- Purchases were never counted; start the lifetime counters from past orders
+INSERT INTO product_analytics (product_id, purchases, last_purchased_at)
+SELECT oi.product_id, SUM(oi.quantity), MAX(o.created_at)
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+WHERE oi.product_id IS NOT NULL
+GROUP BY oi.product_id
+ON CONFLICT (product_id) DO UPDATE SET
+    purchases = GREATEST(product_analytics.purchases, EXCLUDED.purchases),
+    last_purchased_at = EXCLUDED.last_purchased_at;
+
+CREATE TABLE IF NOT EXISTS product_analytics_daily (
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    day DATE NOT NULL, -- in the shop timezone
+    views INTEGER NOT NULL DEFAULT 0,
+    purchases INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (product_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_analytics_daily_day ON product_analytics_daily(day);
+
+COMMENT ON TABLE product_analytics_daily IS 'Product views (card taps, detail requests) and purchased quantity per shop day';
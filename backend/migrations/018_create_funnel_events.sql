@@ -0,0 +1,19 @@
+-- Migration: Messenger conversion funnel
+-- Description: Records the ordering steps each bot conversation reaches, so the funnel report
+--              can show where customers drop off and how long each step takes
+
+-- One row per step entered; a step shown again (back, re-prompt) adds another row and
+-- reports use the first time a session reached it
+CREATE TABLE IF NOT EXISTS funnel_events (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(32) NOT NULL,
+    sender_id VARCHAR(255) NOT NULL,
+    step VARCHAR(30) NOT NULL CHECK (step IN ('language', 'carousel', 'quantity', 'cart', 'name', 'address', 'confirm', 'ordered')),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_funnel_events_session ON funnel_events(session_id, step, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_funnel_events_occurred_at ON funnel_events(occurred_at);
+
+COMMENT ON TABLE funnel_events IS 'Messenger ordering steps reached per conversation (session ends when the bot state resets)';
+COMMENT ON COLUMN funnel_events.session_id IS 'Random ID of one bot conversation, from the first message until the order or a reset';
@@ -0,0 +1,21 @@
+-- Migration: Abandoned cart reminders
+-- Description: Remembers the reminders sent to Messenger customers who left items in their
+--              cart, and whether they came back to checkout and ordered
+
+CREATE TABLE IF NOT EXISTS cart_reminders (
+    id SERIAL PRIMARY KEY,
+    session_id VARCHAR(32) NOT NULL UNIQUE, -- one reminder per conversation (see funnel_events)
+    sender_id VARCHAR(255) NOT NULL,
+    items INTEGER NOT NULL DEFAULT 0,
+    cart_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resumed_at TIMESTAMPTZ,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    converted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_reminders_sent_at ON cart_reminders(sent_at);
+
+COMMENT ON TABLE cart_reminders IS 'Abandoned cart reminders and whether they led back to checkout and an order';
+COMMENT ON COLUMN cart_reminders.resumed_at IS 'When the customer tapped Resume checkout';
+COMMENT ON COLUMN cart_reminders.order_id IS 'Order placed in the same conversation after the reminder';
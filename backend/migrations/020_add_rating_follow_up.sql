@@ -0,0 +1,32 @@
+-- Migration: One rating per order and follow-up of low ratings
+-- Description: Orders could be rated repeatedly and were never linked to their rating.
+--              Keeps each order's latest rating, links it through orders.rating_id and lets
+--              staff track the follow-up of unhappy customers.
+
+-- Keep the latest rating of orders rated more than once
+DELETE FROM ratings r
+USING ratings newer
+WHERE newer.order_id = r.order_id AND newer.id > r.id;
+
+DROP INDEX IF EXISTS idx_ratings_order_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ratings_order_id_unique ON ratings(order_id);
+
+UPDATE orders o
+SET rating_id = r.id
+FROM ratings r
+WHERE r.order_id = o.id AND o.rating_id IS DISTINCT FROM r.id;
+
+ALTER TABLE ratings
+    ADD COLUMN IF NOT EXISTS follow_up VARCHAR(20) CHECK (follow_up IN ('open', 'resolved')),
+    ADD COLUMN IF NOT EXISTS follow_up_note TEXT,
+    ADD COLUMN IF NOT EXISTS flagged_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMPTZ;
+
+-- Low ratings (1-2 stars) are flagged for follow-up when they are given
+UPDATE ratings SET follow_up = 'open', flagged_at = NOW() WHERE stars <= 2 AND follow_up IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ratings_created_at ON ratings(created_at);
+CREATE INDEX IF NOT EXISTS idx_ratings_follow_up ON ratings(follow_up) WHERE follow_up IS NOT NULL;
+
+COMMENT ON COLUMN ratings.follow_up IS 'NULL = no follow-up needed, open = staff should contact the customer, resolved = done';
+COMMENT ON COLUMN ratings.follow_up_note IS 'Staff notes about the follow-up';
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+)
+
+// BusinessHours is the regular opening time for one weekday, in the shop timezone
+type BusinessHours struct {
+	Weekday   int    `json:"weekday"`    // 0 = Sunday ... 6 = Saturday
+	OpenTime  string `json:"open_time"`  // HH:MM
+	CloseTime string `json:"close_time"` // HH:MM
+	IsClosed  bool   `json:"is_closed"`
+}
+
+// BusinessClosure is a holiday or other closure covering whole days
+type BusinessClosure struct {
+	ID        int       `json:"id"`
+	StartDate string    `json:"start_date"` // YYYY-MM-DD
+	EndDate   string    `json:"end_date"`   // YYYY-MM-DD, inclusive
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate validates opening hours. A closed day needs no times: unless valid ones are
+// given, they are cleared and SaveBusinessHours keeps the stored ones.
+func (h *BusinessHours) Validate() error {
+	if h.Weekday < 0 || h.Weekday > 6 {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	err := validateOpeningTimes(h.OpenTime, h.CloseTime)
+	if h.IsClosed {
+		if err != nil {
+			h.OpenTime, h.CloseTime = "", ""
+		}
+		return nil
+	}
+	return err
+}
+
+// validateOpeningTimes checks that both times are HH:MM and open is before close
+func validateOpeningTimes(openTime, closeTime string) error {
+	openAt, err := time.Parse("15:04", openTime)
+	if err != nil {
+		return errors.New("open_time must be HH:MM")
+	}
+	closeAt, err := time.Parse("15:04", closeTime)
+	if err != nil {
+		return errors.New("close_time must be HH:MM")
+	}
+	if !openAt.Before(closeAt) {
+		return errors.New("open_time must be before close_time")
+	}
+	return nil
+}
+
+// Validate validates closure data
+func (c *BusinessClosure) Validate() error {
+	c.Reason = strings.TrimSpace(c.Reason)
+	if c.EndDate == "" {
+		c.EndDate = c.StartDate
+	}
+	start, err := time.Parse("2006-01-02", c.StartDate)
+	if err != nil {
+		return errors.New("start_date must be YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", c.EndDate)
+	if err != nil {
+		return errors.New("end_date must be YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return errors.New("end_date must not be before start_date")
+	}
+	if len(c.Reason) > 255 {
+		return errors.New("reason must be less than 255 characters")
+	}
+	return nil
+}
+
+// GetBusinessHours returns the weekly opening hours ordered by weekday
+func GetBusinessHours() ([]BusinessHours, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`
+		SELECT weekday, to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI'), is_closed
+		FROM business_hours
+		ORDER BY weekday
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []BusinessHours{}
+	for rows.Next() {
+		var h BusinessHours
+		if err := rows.Scan(&h.Weekday, &h.OpenTime, &h.CloseTime, &h.IsClosed); err != nil {
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
+
+// SaveBusinessHours upserts opening hours for the given weekdays in one transaction. Blank
+// times (closed days) keep the stored ones, or default to 08:00-20:00 for a new weekday.
+func SaveBusinessHours(hours []BusinessHours) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	tx, err := configs.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, h := range hours {
+		_, err := tx.Exec(`
+			INSERT INTO business_hours (weekday, open_time, close_time, is_closed)
+			VALUES ($1,
+			        COALESCE(NULLIF($2, '')::time, (SELECT open_time FROM business_hours WHERE weekday = $1), '08:00'),
+			        COALESCE(NULLIF($3, '')::time, (SELECT close_time FROM business_hours WHERE weekday = $1), '20:00'),
+			        $4)
+			ON CONFLICT (weekday) DO UPDATE
+			SET open_time = EXCLUDED.open_time, close_time = EXCLUDED.close_time,
+			    is_closed = EXCLUDED.is_closed, updated_at = NOW()
+		`, h.Weekday, h.OpenTime, h.CloseTime, h.IsClosed)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBusinessClosures returns closures that end on or after the given date (YYYY-MM-DD)
+func GetBusinessClosures(fromDate string) ([]BusinessClosure, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`
+		SELECT id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'),
+		       COALESCE(reason, ''), created_at
+		FROM business_closures
+		WHERE end_date >= $1
+		ORDER BY start_date
+	`, fromDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	closures := []BusinessClosure{}
+	for rows.Next() {
+		var c BusinessClosure
+		if err := rows.Scan(&c.ID, &c.StartDate, &c.EndDate, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
+
+// CreateBusinessClosure inserts a new closure
+func CreateBusinessClosure(c *BusinessClosure) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	return configs.DB.QueryRow(`
+		INSERT INTO business_closures (start_date, end_date, reason)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id, created_at
+	`, c.StartDate, c.EndDate, c.Reason).Scan(&c.ID, &c.CreatedAt)
+}
+
+// DeleteBusinessClosure removes a closure; returns false if it did not exist
+func DeleteBusinessClosure(id int) (bool, error) {
+	if configs.DB == nil {
+		return false, sql.ErrConnDone
+	}
+
+	res, err := configs.DB.Exec(`DELETE FROM business_closures WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
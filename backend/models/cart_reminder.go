@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+
+	"bakeflow/configs"
+)
+
+// CartReminderStats sums up the abandoned cart reminders sent in a report period
+type CartReminderStats struct {
+	Sent           int     `json:"sent"`
+	Resumed        int     `json:"resumed"`   // customer tapped Resume checkout
+	Converted      int     `json:"converted"` // customer ordered afterwards
+	ConversionRate float64 `json:"conversion_rate"`
+	CartValue      float64 `json:"cart_value"` // value of the reminded carts
+	Revenue        float64 `json:"revenue"`    // totals of the orders placed after a reminder
+}
+
+// CreateCartReminder records a reminder sent for a conversation's cart. It reports false when
+// the conversation was already reminded.
+func CreateCartReminder(sessionID, senderID string, items int, cartValue float64) (bool, error) {
+	if configs.DB == nil {
+		return false, sql.ErrConnDone
+	}
+	res, err := configs.DB.Exec(`
+		INSERT INTO cart_reminders (session_id, sender_id, items, cart_value)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (session_id) DO NOTHING
+	`, sessionID, senderID, items, cartValue)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// MarkCartReminderResumed records that the customer came back through the reminder
+func MarkCartReminderResumed(sessionID string) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+	_, err := configs.DB.Exec(`
+		UPDATE cart_reminders SET resumed_at = NOW() WHERE session_id = $1 AND resumed_at IS NULL
+	`, sessionID)
+	return err
+}
+
+// MarkCartReminderConverted links the order placed in a reminded conversation to its reminder.
+// Conversations without a reminder are left alone.
+func MarkCartReminderConverted(sessionID string, orderID int) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+	_, err := configs.DB.Exec(`
+		UPDATE cart_reminders SET order_id = $2, converted_at = NOW()
+		WHERE session_id = $1 AND converted_at IS NULL
+	`, sessionID, orderID)
+	return err
+}
+
+// GetCartReminderStats sums up the reminders sent in the range
+func GetCartReminderStats(rng SalesRange) (*CartReminderStats, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	var s CartReminderStats
+	err := configs.DB.QueryRow(`
+		SELECT COUNT(*), COUNT(r.resumed_at), COUNT(r.converted_at),
+		       COALESCE(SUM(r.cart_value), 0), COALESCE(SUM(o.total_amount), 0)
+		FROM cart_reminders r
+		LEFT JOIN orders o ON o.id = r.order_id
+		WHERE r.sent_at >= $1 AND r.sent_at < $2
+	`, rng.From, rng.To).Scan(&s.Sent, &s.Resumed, &s.Converted, &s.CartValue, &s.Revenue)
+	if err != nil {
+		return nil, err
+	}
+	if s.Sent > 0 {
+		s.ConversionRate = float64(s.Converted) / float64(s.Sent)
+	}
+	return &s, nil
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultCategoryEmoji is shown for categories created without an emoji
+const DefaultCategoryEmoji = "🍰"
+
+// ErrCategoryInUse is returned when deleting a category that still has products
+var ErrCategoryInUse = errors.New("category still has products")
+
+// Category groups products in the admin catalog and the Messenger menu
+type Category struct {
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Names        map[string]string `json:"names"` // localized names by language code
+	Emoji        string            `json:"emoji"`
+	SortOrder    int               `json:"sort_order"`
+	Active       bool              `json:"active"`
+	ProductCount int               `json:"product_count"` // non-deleted products in the category
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// Validate validates category data
+func (c *Category) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Emoji = strings.TrimSpace(c.Emoji)
+	if c.Name == "" {
+		return errors.New("category name is required")
+	}
+	if len(c.Name) > 100 {
+		return errors.New("category name must be less than 100 characters")
+	}
+	if c.Emoji == "" {
+		c.Emoji = DefaultCategoryEmoji
+	}
+	if utf8.RuneCountInString(c.Emoji) > 8 {
+		return errors.New("category emoji must be a single emoji")
+	}
+	names := map[string]string{}
+	for lang, name := range c.Names {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if name = strings.TrimSpace(name); lang != "" && name != "" {
+			names[lang] = name
+		}
+	}
+	c.Names = names
+	return nil
+}
+
+// DisplayName returns the category name in a language, falling back to Name
+func (c *Category) DisplayName(lang string) string {
+	if name := c.Names[lang]; name != "" {
+		return name
+	}
+	return c.Name
+}
+
+const categoryColumns = `
+	c.id, c.name, c.names, c.emoji, c.sort_order, c.active, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.deleted_at IS NULL)
+`
+
+func scanCategory(row interface{ Scan(...interface{}) error }) (*Category, error) {
+	var c Category
+	var names []byte
+	if err := row.Scan(&c.ID, &c.Name, &names, &c.Emoji, &c.SortOrder, &c.Active, &c.CreatedAt, &c.UpdatedAt, &c.ProductCount); err != nil {
+		return nil, err
+	}
+	c.Names = map[string]string{}
+	if len(names) > 0 {
+		if err := json.Unmarshal(names, &c.Names); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// GetCategories returns categories in menu order; activeOnly skips hidden ones
+func GetCategories(db *sql.DB, activeOnly bool) ([]Category, error) {
+	rows, err := db.Query(`
+		SELECT `+categoryColumns+`
+		FROM categories c
+		WHERE $1 = FALSE OR c.active
+		ORDER BY c.sort_order, c.name
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *c)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategoryByID fetches a category; nil if it does not exist
+func GetCategoryByID(db *sql.DB, id int) (*Category, error) {
+	c, err := scanCategory(db.QueryRow(`SELECT `+categoryColumns+` FROM categories c WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// GetCategoryByName fetches a category by name, ignoring case; nil if it does not exist
+func GetCategoryByName(db *sql.DB, name string) (*Category, error) {
+	c, err := scanCategory(db.QueryRow(`SELECT `+categoryColumns+` FROM categories c WHERE LOWER(c.name) = LOWER($1)`, strings.TrimSpace(name)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// CreateCategory inserts a category
+func CreateCategory(db *sql.DB, c *Category) error {
+	names, err := json.Marshal(c.Names)
+	if err != nil {
+		return err
+	}
+	return db.QueryRow(`
+		INSERT INTO categories (name, names, emoji, sort_order, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, c.Name, names, c.Emoji, c.SortOrder, c.Active).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// UpdateCategory updates a category; a rename is copied to its products and slot lead times
+func UpdateCategory(db *sql.DB, c *Category, oldName string) error {
+	names, err := json.Marshal(c.Names)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE categories SET name = $1, names = $2, emoji = $3, sort_order = $4, active = $5
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`, c.Name, names, c.Emoji, c.SortOrder, c.Active, c.ID).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if c.Name != oldName {
+		if _, err := tx.Exec(`UPDATE products SET category = $1 WHERE category_id = $2`, c.Name, c.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			UPDATE slot_lead_times SET category = $1
+			WHERE LOWER(category) = LOWER($2) AND NOT EXISTS (SELECT 1 FROM slot_lead_times WHERE category = $1)
+		`, c.Name, oldName); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteCategory removes an empty category; ErrCategoryInUse if products still use it
+func DeleteCategory(db *sql.DB, id int) (bool, error) {
+	var inUse bool
+	if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE category_id = $1)`, id).Scan(&inUse); err != nil {
+		return false, err
+	}
+	if inUse {
+		return false, ErrCategoryInUse
+	}
+	res, err := db.Exec(`DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetMenuCategories returns active categories that have at least one active product available
+// at availableAt (shop local time; zero ignores availability windows), in menu order
+func GetMenuCategories(db *sql.DB, availableAt time.Time) ([]Category, error) {
+	weekday, clock := availabilityArgs(availableAt)
+	rows, err := db.Query(`
+		SELECT `+categoryColumns+`
+		FROM categories c
+		WHERE c.active AND EXISTS (
+			SELECT 1 FROM products p
+			WHERE p.category_id = c.id AND p.deleted_at IS NULL AND p.status = 'active'
+			  AND `+availableNowSQL("p", 1, 2)+`
+		)
+		ORDER BY c.sort_order, c.name
+	`, weekday, clock)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *c)
+	}
+	return categories, rows.Err()
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"database/sql"
+
+	"bakeflow/configs"
+)
+
+// GetCustomerLanguage returns the saved language for a Messenger customer ("" if unknown)
+func GetCustomerLanguage(senderID string) (string, error) {
+	if configs.DB == nil {
+		return "", sql.ErrConnDone
+	}
+
+	var lang string
+	err := configs.DB.QueryRow(`SELECT language FROM customers WHERE sender_id = $1`, senderID).Scan(&lang)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return lang, err
+}
+
+// SaveCustomerLanguage creates or updates a customer's preferred language
+func SaveCustomerLanguage(senderID, language string) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	_, err := configs.DB.Exec(`
+		INSERT INTO customers (sender_id, language)
+		VALUES ($1, $2)
+		ON CONFLICT (sender_id) DO UPDATE SET language = EXCLUDED.language, updated_at = NOW()
+	`, senderID, language)
+	return err
+}
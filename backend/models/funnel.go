@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"bakeflow/configs"
+
+	"github.com/lib/pq"
+)
+
+// Messenger ordering steps, in funnel order
+const (
+	FunnelLanguage = "language" // language picker shown to a new customer
+	FunnelCarousel = "carousel" // product carousel shown
+	FunnelQuantity = "quantity" // quantity asked for a chosen product
+	FunnelCart     = "cart"     // item added to the cart
+	FunnelName     = "name"     // checkout started, name asked
+	FunnelAddress  = "address"  // delivery address asked (pickup orders skip it)
+	FunnelConfirm  = "confirm"  // order summary shown for confirmation
+	FunnelOrdered  = "ordered"  // order placed
+)
+
+// FunnelSteps lists the steps in the order customers go through them
+var FunnelSteps = []string{FunnelLanguage, FunnelCarousel, FunnelQuantity, FunnelCart, FunnelName, FunnelAddress, FunnelConfirm, FunnelOrdered}
+
+// FunnelStep is how many conversations got to one step and how long they stayed there
+type FunnelStep struct {
+	Step          string   `json:"step"`
+	Sessions      int      `json:"sessions"`       // sessions that reached this step or a later one
+	Entered       int      `json:"entered"`        // sessions that were shown this step
+	Conversion    float64  `json:"conversion"`     // Sessions as a fraction of all sessions
+	DropOff       float64  `json:"drop_off"`       // fraction of Sessions that got no further
+	MedianSeconds *float64 `json:"median_seconds"` // to the next step reached; nil if nobody moved on
+}
+
+// RecordFunnelEvent stores that a conversation reached a step at the given time
+func RecordFunnelEvent(sessionID, senderID, step string, at time.Time) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+	_, err := configs.DB.Exec(`
+		INSERT INTO funnel_events (session_id, sender_id, step, occurred_at) VALUES ($1, $2, $3, $4)
+	`, sessionID, senderID, step, at)
+	return err
+}
+
+// funnelSessions selects the sessions that started in the range's $1 and $2. Only events
+// from $1 on are read; a session with an earlier event started before the range.
+const funnelSessions = `
+	SELECT session_id FROM funnel_events fe
+	WHERE fe.occurred_at >= $1 AND NOT EXISTS (
+		SELECT 1 FROM funnel_events earlier
+		WHERE earlier.session_id = fe.session_id AND earlier.occurred_at < $1
+	)
+	GROUP BY session_id
+	HAVING MIN(occurred_at) < $2`
+
+// GetFunnel returns every funnel step for the conversations started in the range.
+// A session counts towards all steps up to the furthest one it reached, so pickup orders
+// pass the address step without being shown it.
+func GetFunnel(rng SalesRange) ([]FunnelStep, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := configs.DB.Query(`
+		WITH steps AS (
+			SELECT s.step, s.pos FROM unnest($3::text[]) WITH ORDINALITY AS s(step, pos)
+		),
+		reached AS (
+			SELECT e.session_id, st.pos, MIN(e.occurred_at) AS first_at
+			FROM funnel_events e
+			JOIN steps st ON st.step = e.step
+			WHERE e.session_id IN (`+funnelSessions+`)
+			GROUP BY e.session_id, st.pos
+		),
+		furthest AS (
+			SELECT session_id, MAX(pos) AS pos FROM reached GROUP BY session_id
+		),
+		durations AS (
+			SELECT pos, EXTRACT(EPOCH FROM LEAD(first_at) OVER (PARTITION BY session_id ORDER BY pos) - first_at) AS seconds
+			FROM reached
+		)
+		SELECT st.step,
+		       (SELECT COUNT(*) FROM furthest f WHERE f.pos >= st.pos),
+		       (SELECT COUNT(*) FROM reached r WHERE r.pos = st.pos),
+		       (SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY d.seconds) FROM durations d WHERE d.pos = st.pos AND d.seconds >= 0)
+		FROM steps st
+		ORDER BY st.pos
+	`, rng.From, rng.To, pq.Array(FunnelSteps))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := []FunnelStep{}
+	for rows.Next() {
+		var s FunnelStep
+		var median sql.NullFloat64
+		if err := rows.Scan(&s.Step, &s.Sessions, &s.Entered, &median); err != nil {
+			return nil, err
+		}
+		if median.Valid {
+			s.MedianSeconds = &median.Float64
+		}
+		steps = append(steps, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range steps {
+		if steps[0].Sessions > 0 {
+			steps[i].Conversion = float64(steps[i].Sessions) / float64(steps[0].Sessions)
+		}
+		if i+1 < len(steps) && steps[i].Sessions > 0 {
+			steps[i].DropOff = 1 - float64(steps[i+1].Sessions)/float64(steps[i].Sessions)
+		}
+	}
+	return steps, nil
+}
+
+// CountAbandonedCarts counts the sessions started in the range that put something in the
+// cart, never ordered and have been quiet since idleSince
+func CountAbandonedCarts(rng SalesRange, idleSince time.Time) (int, error) {
+	if configs.DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	var count int
+	err := configs.DB.QueryRow(`
+		SELECT COUNT(*) FROM (`+funnelSessions+`
+			AND bool_or(step = $3) AND NOT bool_or(step = $4) AND MAX(occurred_at) < $5
+		) abandoned
+	`, rng.From, rng.To, FunnelCart, FunnelOrdered, idleSince).Scan(&count)
+	return count, err
+}
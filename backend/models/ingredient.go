@@ -0,0 +1,391 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// IngredientUnits are the units ingredient stock can be tracked in
+var IngredientUnits = []string{"g", "kg", "ml", "l", "pcs"}
+
+// ErrIngredientInUse is returned when deleting an ingredient a recipe still uses
+var ErrIngredientInUse = errors.New("ingredient is still used by a recipe")
+
+// Ingredient movement reasons
+const (
+	MovementOrder      = "order"
+	MovementRestock    = "restock"
+	MovementAdjustment = "adjustment"
+)
+
+// Ingredient is a raw material kept in stock (flour, butter, cream...)
+type Ingredient struct {
+	ID                int       `json:"id"`
+	Name              string    `json:"name"`
+	Unit              string    `json:"unit"`
+	Stock             float64   `json:"stock"`
+	LowStockThreshold float64   `json:"low_stock_threshold"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// RecipeItem is the quantity of one ingredient used per product unit
+type RecipeItem struct {
+	ProductID    int     `json:"product_id"`
+	IngredientID int     `json:"ingredient_id"`
+	Ingredient   string  `json:"ingredient"`
+	Unit         string  `json:"unit"`
+	Quantity     float64 `json:"quantity"`
+}
+
+// IngredientNeed is the total quantity of an ingredient required for a set of products
+type IngredientNeed struct {
+	IngredientID int     `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Unit         string  `json:"unit"`
+	Required     float64 `json:"required"`
+	Stock        float64 `json:"stock"`
+	Shortfall    float64 `json:"shortfall"`
+}
+
+// Validate validates ingredient data
+func (i *Ingredient) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	i.Unit = strings.ToLower(strings.TrimSpace(i.Unit))
+	if i.Name == "" {
+		return errors.New("ingredient name is required")
+	}
+	if len(i.Name) > 255 {
+		return errors.New("ingredient name must be less than 255 characters")
+	}
+	validUnit := false
+	for _, u := range IngredientUnits {
+		if i.Unit == u {
+			validUnit = true
+		}
+	}
+	if !validUnit {
+		return errors.New("unit must be one of g, kg, ml, l, pcs")
+	}
+	if i.LowStockThreshold < 0 {
+		return errors.New("low stock threshold cannot be negative")
+	}
+	return nil
+}
+
+// IsLowStock checks if the ingredient is below its alert threshold (0 disables alerts),
+// like products
+func (i *Ingredient) IsLowStock() bool {
+	return i.LowStockThreshold > 0 && i.Stock < i.LowStockThreshold
+}
+
+const ingredientColumns = `id, name, unit, stock, low_stock_threshold, created_at, updated_at`
+
+func scanIngredient(row interface{ Scan(...interface{}) error }, i *Ingredient) error {
+	return row.Scan(&i.ID, &i.Name, &i.Unit, &i.Stock, &i.LowStockThreshold, &i.CreatedAt, &i.UpdatedAt)
+}
+
+func queryIngredients(db *sql.DB, query string, args ...interface{}) ([]Ingredient, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ingredients := []Ingredient{}
+	for rows.Next() {
+		var i Ingredient
+		if err := scanIngredient(rows, &i); err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, i)
+	}
+	return ingredients, rows.Err()
+}
+
+// GetIngredients returns all ingredients by name
+func GetIngredients(db *sql.DB) ([]Ingredient, error) {
+	return queryIngredients(db, `SELECT `+ingredientColumns+` FROM ingredients ORDER BY name`)
+}
+
+// GetLowStockIngredients returns ingredients below their threshold, emptiest first
+func GetLowStockIngredients(db *sql.DB) ([]Ingredient, error) {
+	return queryIngredients(db, `
+		SELECT `+ingredientColumns+`
+		FROM ingredients
+		WHERE low_stock_threshold > 0 AND stock < low_stock_threshold
+		ORDER BY stock - low_stock_threshold, name
+	`)
+}
+
+// GetIngredientByID fetches one ingredient; nil if it does not exist
+func GetIngredientByID(db *sql.DB, id int) (*Ingredient, error) {
+	var i Ingredient
+	err := scanIngredient(db.QueryRow(`SELECT `+ingredientColumns+` FROM ingredients WHERE id = $1`, id), &i)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// CreateIngredient inserts a new ingredient with its opening stock
+func CreateIngredient(db *sql.DB, i *Ingredient) error {
+	return db.QueryRow(`
+		INSERT INTO ingredients (name, unit, stock, low_stock_threshold)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, i.Name, i.Unit, i.Stock, i.LowStockThreshold).Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt)
+}
+
+// UpdateIngredient updates name, unit and threshold; stock changes go through AdjustIngredientStock
+func UpdateIngredient(db *sql.DB, i *Ingredient) (bool, error) {
+	err := db.QueryRow(`
+		UPDATE ingredients
+		SET name = $1, unit = $2, low_stock_threshold = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING stock, created_at, updated_at
+	`, i.Name, i.Unit, i.LowStockThreshold, i.ID).Scan(&i.Stock, &i.CreatedAt, &i.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteIngredient removes an ingredient; returns ErrIngredientInUse while a recipe still uses it
+func DeleteIngredient(db *sql.DB, id int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM ingredients WHERE id = $1`, id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return false, ErrIngredientInUse
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// AdjustIngredientStock adds change (negative to remove) to the stock and records the movement
+func AdjustIngredientStock(db *sql.DB, id int, change float64, reason string) (*Ingredient, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var i Ingredient
+	err = scanIngredient(tx.QueryRow(`
+		UPDATE ingredients SET stock = stock + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING `+ingredientColumns, change, id), &i)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO ingredient_movements (ingredient_id, change, reason) VALUES ($1, $2, $3)`, id, change, reason); err != nil {
+		return nil, err
+	}
+	return &i, tx.Commit()
+}
+
+// GetProductRecipe returns the ingredients used per unit of a product
+func GetProductRecipe(db *sql.DB, productID int) ([]RecipeItem, error) {
+	rows, err := db.Query(`
+		SELECT pr.product_id, pr.ingredient_id, i.name, i.unit, pr.quantity
+		FROM product_recipes pr
+		JOIN ingredients i ON i.id = pr.ingredient_id
+		WHERE pr.product_id = $1
+		ORDER BY i.name
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RecipeItem{}
+	for rows.Next() {
+		var it RecipeItem
+		if err := rows.Scan(&it.ProductID, &it.IngredientID, &it.Ingredient, &it.Unit, &it.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// SaveProductRecipe replaces a product's recipe (an empty list removes it)
+func SaveProductRecipe(db *sql.DB, productID int, items []RecipeItem) error {
+	seen := map[int]bool{}
+	for _, it := range items {
+		if it.Quantity <= 0 {
+			return errors.New("recipe quantities must be greater than zero")
+		}
+		if seen[it.IngredientID] {
+			return errors.New("each ingredient may appear only once in a recipe")
+		}
+		seen[it.IngredientID] = true
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_recipes WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO product_recipes (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)
+		`, productID, it.IngredientID, it.Quantity); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetIngredientNeeds totals the ingredients required for the given product quantities,
+// matched to recipes like DeductOrderIngredients: by product ID, or by name (as stored on
+// order items) for items without one. Products without a recipe are ignored.
+func GetIngredientNeeds(db *sql.DB, byProductID map[int]int, byName map[string]int) ([]IngredientNeed, error) {
+	needs := []IngredientNeed{}
+	if len(byProductID) == 0 && len(byName) == 0 {
+		return needs, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT p.id, CASE WHEN p.deleted_at IS NULL THEN LOWER(p.name) ELSE '' END,
+		       i.id, i.name, i.unit, i.stock, pr.quantity
+		FROM product_recipes pr
+		JOIN products p ON p.id = pr.product_id
+		JOIN ingredients i ON i.id = pr.ingredient_id
+		ORDER BY i.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	qtyByName := map[string]int{}
+	for name, q := range byName {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			qtyByName[name] += q
+		}
+	}
+
+	byID := map[int]*IngredientNeed{}
+	var order []int
+	for rows.Next() {
+		var productID int
+		var product string
+		var n IngredientNeed
+		var perUnit float64
+		if err := rows.Scan(&productID, &product, &n.IngredientID, &n.Name, &n.Unit, &n.Stock, &perUnit); err != nil {
+			return nil, err
+		}
+		q := byProductID[productID] + qtyByName[product]
+		if q == 0 {
+			continue
+		}
+		need, ok := byID[n.IngredientID]
+		if !ok {
+			need = &n
+			byID[n.IngredientID] = need
+			order = append(order, n.IngredientID)
+		}
+		need.Required += perUnit * float64(q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range order {
+		n := byID[id]
+		if n.Required > n.Stock {
+			n.Shortfall = n.Required - n.Stock
+		}
+		needs = append(needs, *n)
+	}
+	return needs, nil
+}
+
+// DeductOrderIngredients subtracts the recipe ingredients of an order's items from stock.
+// Items are matched to their recipe by product ID, or by name for legacy items without one.
+// It runs at most once per order and returns the ingredients it changed (empty if it had
+// already run or no item has a recipe).
+func DeductOrderIngredients(db *sql.DB, orderID int) ([]Ingredient, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE orders SET ingredients_deducted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND ingredients_deducted_at IS NULL
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return []Ingredient{}, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT pr.ingredient_id, SUM(pr.quantity * oi.quantity)
+		FROM order_items oi
+		JOIN products p ON p.id = oi.product_id
+		                OR (oi.product_id IS NULL AND p.deleted_at IS NULL AND LOWER(p.name) = LOWER(TRIM(oi.product)))
+		JOIN product_recipes pr ON pr.product_id = p.id
+		WHERE oi.order_id = $1
+		GROUP BY pr.ingredient_id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	used := map[int]float64{}
+	for rows.Next() {
+		var id int
+		var qty float64
+		if err := rows.Scan(&id, &qty); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		used[id] = qty
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	changed := []Ingredient{}
+	for id, qty := range used {
+		var i Ingredient
+		if err := scanIngredient(tx.QueryRow(`
+			UPDATE ingredients SET stock = stock - $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING `+ingredientColumns, qty, id), &i); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO ingredient_movements (ingredient_id, order_id, change, reason) VALUES ($1, $2, $3, $4)
+		`, id, orderID, -qty, MovementOrder); err != nil {
+			return nil, err
+		}
+		changed = append(changed, i)
+	}
+	return changed, tx.Commit()
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+	"bakeflow/i18n"
+)
+
+// NotificationTemplate is an admin override for a status notification in one language
+type NotificationTemplate struct {
+	ID        int       `json:"id"`
+	Status    string    `json:"status"`
+	Language  string    `json:"language"`
+	Template  string    `json:"template"` // supports {id} and {status}
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate validates template data
+func (t *NotificationTemplate) Validate() error {
+	t.Template = strings.TrimSpace(t.Template)
+	if t.Template == "" {
+		return errors.New("template is required")
+	}
+	if len(t.Template) > 2000 {
+		return errors.New("template must be less than 2000 characters")
+	}
+	if !i18n.Has("notify.status." + t.Status) {
+		return errors.New("invalid status")
+	}
+	if !i18n.IsSupported(t.Language) {
+		return errors.New("invalid language")
+	}
+	return nil
+}
+
+// GetNotificationTemplates returns all admin-edited templates
+func GetNotificationTemplates() ([]NotificationTemplate, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`
+		SELECT id, status, language, template, updated_at
+		FROM notification_templates
+		ORDER BY status, language
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []NotificationTemplate{}
+	for rows.Next() {
+		var t NotificationTemplate
+		if err := rows.Scan(&t.ID, &t.Status, &t.Language, &t.Template, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// GetNotificationTemplate returns the override for a status and language, or nil if none
+func GetNotificationTemplate(status, language string) (*NotificationTemplate, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	var t NotificationTemplate
+	err := configs.DB.QueryRow(`
+		SELECT id, status, language, template, updated_at
+		FROM notification_templates
+		WHERE status = $1 AND language = $2
+	`, status, language).Scan(&t.ID, &t.Status, &t.Language, &t.Template, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveNotificationTemplate creates or replaces the override for a status and language
+func SaveNotificationTemplate(t *NotificationTemplate) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	return configs.DB.QueryRow(`
+		INSERT INTO notification_templates (status, language, template)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (status, language) DO UPDATE SET template = EXCLUDED.template, updated_at = NOW()
+		RETURNING id, updated_at
+	`, t.Status, t.Language, t.Template).Scan(&t.ID, &t.UpdatedAt)
+}
+
+// DeleteNotificationTemplate removes an override; returns false if there was none
+func DeleteNotificationTemplate(status, language string) (bool, error) {
+	if configs.DB == nil {
+		return false, sql.ErrConnDone
+	}
+
+	res, err := configs.DB.Exec(`DELETE FROM notification_templates WHERE status = $1 AND language = $2`, status, language)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"log"
 	"time"
 
@@ -21,27 +22,24 @@ type Order struct {
 	ReorderedFrom *int        `json:"reordered_from,omitempty"`
 	RatingID      *int        `json:"rating_id,omitempty"`
 	SenderID     string      `json:"sender_id,omitempty"`
+	Language      string      `json:"language,omitempty"` // customer language when ordered
+	SlotStart     *time.Time  `json:"slot_start,omitempty"` // scheduled pickup/delivery slot; nil = ASAP
+	SlotEnd       *time.Time  `json:"slot_end,omitempty"`
 	CreatedAt     time.Time   `json:"created_at"`
 	CompletedAt   *time.Time  `json:"completed_at,omitempty"`
 	Items         []OrderItem `json:"items,omitempty"` // For including items in responses
 }
 
 type OrderItem struct {
-	ID        int       `json:"id"`
-	OrderID   int       `json:"order_id"`
-	Product   string    `json:"product"`
-	Quantity  int       `json:"quantity"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-type Rating struct {
-	ID        int       `json:"id"`
-	OrderID   int       `json:"order_id"`
-	UserID    string    `json:"user_id"`
-	Stars     int       `json:"stars"` // 1-5
-	Comment   string    `json:"comment,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int               `json:"id"`
+	OrderID     int               `json:"order_id"`
+	Product     string            `json:"product"`
+	Quantity    int               `json:"quantity"`
+	ProductID   int               `json:"product_id,omitempty"`  // catalog product; 0 if unknown
+	Price       float64           `json:"price"`                 // unit price including option deltas
+	Options     []OrderItemOption `json:"options,omitempty"`     // chosen variants (size, flavour...)
+	Inscription string            `json:"inscription,omitempty"` // custom message on the product
+	CreatedAt   time.Time         `json:"created_at"`
 }
 
 // GetAllOrders returns all orders from the database with their items
@@ -54,7 +52,8 @@ func GetAllOrders() ([]Order, error) {
 		       COALESCE(address, '') as address,
 		       status, total_items,
 		       COALESCE(subtotal, 0), COALESCE(delivery_fee, 0), COALESCE(total_amount, 0),
-		       reordered_from, rating_id, COALESCE(sender_id, '') as sender_id, created_at, completed_at
+		       reordered_from, rating_id, COALESCE(sender_id, '') as sender_id,
+		       COALESCE(language, '') as language, slot_start, slot_end, created_at, completed_at
 		FROM orders
 		ORDER BY id DESC
 	`)
@@ -69,7 +68,7 @@ func GetAllOrders() ([]Order, error) {
 	for rows.Next() {
 		var o Order
 		err := rows.Scan(&o.ID, &o.CustomerName, &o.DeliveryType, &o.Address, &o.Status, &o.TotalItems,
-			&o.Subtotal, &o.DeliveryFee, &o.TotalAmount, &o.ReorderedFrom, &o.RatingID, &o.SenderID, &o.CreatedAt, &o.CompletedAt)
+			&o.Subtotal, &o.DeliveryFee, &o.TotalAmount, &o.ReorderedFrom, &o.RatingID, &o.SenderID, &o.Language, &o.SlotStart, &o.SlotEnd, &o.CreatedAt, &o.CompletedAt)
 		if err != nil {
 			log.Printf("❌ Scan error: %v", err)
 			return nil, err
@@ -92,7 +91,7 @@ func GetAllOrders() ([]Order, error) {
 // GetOrderItems returns all items for a specific order
 func GetOrderItems(orderID int) ([]OrderItem, error) {
 	rows, err := configs.DB.Query(`
-		SELECT id, order_id, product, quantity, price, created_at 
+		SELECT id, order_id, product, quantity, price, options, COALESCE(inscription, ''), COALESCE(product_id, 0), created_at 
 		FROM order_items 
 		WHERE order_id = $1 
 		ORDER BY id
@@ -105,18 +104,23 @@ func GetOrderItems(orderID int) ([]OrderItem, error) {
 	var items []OrderItem
 	for rows.Next() {
 		var item OrderItem
-		err := rows.Scan(&item.ID, &item.OrderID, &item.Product, &item.Quantity, &item.Price, &item.CreatedAt)
+		var options []byte
+		err := rows.Scan(&item.ID, &item.OrderID, &item.Product, &item.Quantity, &item.Price, &options, &item.Inscription, &item.ProductID, &item.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(options, &item.Options); err != nil {
+			return nil, err
+		}
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-// CreateOrder inserts a new order and its items into the database
-func CreateOrder(o *Order, items []OrderItem) error {
+// CreateOrder inserts a new order and its items into the database and counts the purchases
+// on shopNow's date (shop-local time the order is placed)
+func CreateOrder(o *Order, items []OrderItem, shopNow time.Time) error {
 	if configs.DB == nil {
 		return sql.ErrConnDone
 	}
@@ -128,31 +132,66 @@ func CreateOrder(o *Order, items []OrderItem) error {
 	}
 	defer tx.Rollback()
 
+	// Scheduled orders must fit in their slot's capacity
+	if o.SlotStart != nil {
+		if err := reserveSlot(tx, *o.SlotStart); err != nil {
+			return err
+		}
+	}
+
+	// Chosen variants with tracked stock must still be available
+	if err := reserveOptionStock(tx, items); err != nil {
+		return err
+	}
+
 	// Insert the order
 	query := `
 		INSERT INTO orders (customer_name, delivery_type, address, status, total_items,
-		                    subtotal, delivery_fee, total_amount, reordered_from, sender_id, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		                    subtotal, delivery_fee, total_amount, reordered_from, sender_id, language,
+		                    slot_start, slot_end, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, NOW())
 		RETURNING id, created_at
 	`
 
 	err = tx.QueryRow(query, o.CustomerName, o.DeliveryType, o.Address, o.Status, o.TotalItems,
-		o.Subtotal, o.DeliveryFee, o.TotalAmount, o.ReorderedFrom, o.SenderID).Scan(&o.ID, &o.CreatedAt)
+		o.Subtotal, o.DeliveryFee, o.TotalAmount, o.ReorderedFrom, o.SenderID, o.Language,
+		o.SlotStart, o.SlotEnd).Scan(&o.ID, &o.CreatedAt)
 	if err != nil {
 		return err
 	}
 
-	// Insert all order items
+	// Insert all order items; items without a product ID are linked by name
 	itemQuery := `
-		INSERT INTO order_items (order_id, product, quantity, price, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
+		INSERT INTO order_items (order_id, product, quantity, price, options, inscription, product_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''),
+		        COALESCE(NULLIF($7, 0), (SELECT id FROM products WHERE LOWER(name) = LOWER(TRIM($2)) AND deleted_at IS NULL ORDER BY id LIMIT 1)),
+		        NOW())
+		RETURNING COALESCE(product_id, 0)
 	`
 	
-	for _, item := range items {
-		_, err = tx.Exec(itemQuery, o.ID, item.Product, item.Quantity, item.Price)
+	purchased := map[int]int{}
+	for i, item := range items {
+		options := item.Options
+		if options == nil {
+			options = []OrderItemOption{}
+		}
+		optionsJSON, err := json.Marshal(options)
 		if err != nil {
 			return err
 		}
+		err = tx.QueryRow(itemQuery, o.ID, item.Product, item.Quantity, item.Price, optionsJSON, item.Inscription, item.ProductID).Scan(&items[i].ProductID)
+		if err != nil {
+			return err
+		}
+		if items[i].ProductID != 0 {
+			purchased[items[i].ProductID] += item.Quantity
+		}
+	}
+
+	for productID, quantity := range purchased {
+		if err := IncrementPurchases(tx, productID, quantity, shopNow); err != nil {
+			return err
+		}
 	}
 
 	// Commit the transaction
@@ -172,7 +211,8 @@ func GetOrderByID(orderID int) (*Order, error) {
 	query := `
 		SELECT id, customer_name, delivery_type, address, status, total_items,
 		       COALESCE(subtotal, 0), COALESCE(delivery_fee, 0), COALESCE(total_amount, 0),
-		       reordered_from, rating_id, COALESCE(sender_id, ''), created_at, completed_at
+		       reordered_from, rating_id, COALESCE(sender_id, ''), COALESCE(language, ''),
+		       slot_start, slot_end, created_at, completed_at
 		FROM orders
 		WHERE id = $1
 	`
@@ -183,7 +223,7 @@ func GetOrderByID(orderID int) (*Order, error) {
 	err := configs.DB.QueryRow(query, orderID).Scan(
 		&o.ID, &o.CustomerName, &o.DeliveryType, &o.Address, &o.Status, &o.TotalItems,
 		&o.Subtotal, &o.DeliveryFee, &o.TotalAmount, &reorderedFrom, &ratingID, &o.SenderID,
-		&o.CreatedAt, &completedAt,
+		&o.Language, &o.SlotStart, &o.SlotEnd, &o.CreatedAt, &completedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -211,38 +251,6 @@ func GetOrderByID(orderID int) (*Order, error) {
 	return &o, nil
 }
 
-// CreateRating saves a customer rating for an order
-func CreateRating(r *Rating) error {
-	if configs.DB == nil {
-		return sql.ErrConnDone
-	}
-	
-	query := `
-		INSERT INTO ratings (order_id, user_id, stars, comment, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		RETURNING id, created_at
-	`
-	
-	return configs.DB.QueryRow(query, r.OrderID, r.UserID, r.Stars, r.Comment).Scan(&r.ID, &r.CreatedAt)
-}
-
-// GetRatingByOrderID returns the rating for a specific order
-func GetRatingByOrderID(orderID int) (*Rating, error) {
-	var r Rating
-	query := `
-		SELECT id, order_id, user_id, stars, comment, created_at
-		FROM ratings
-		WHERE order_id = $1
-	`
-	
-	err := configs.DB.QueryRow(query, orderID).Scan(&r.ID, &r.OrderID, &r.UserID, &r.Stars, &r.Comment, &r.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	
-	return &r, nil
-}
-
 // UpdateOrderStatus updates the status of an order
 func UpdateOrderStatus(orderID int, newStatus string) error {
 	if configs.DB == nil {
@@ -254,5 +262,43 @@ func UpdateOrderStatus(orderID int, newStatus string) error {
 	return err
 }
 
+// GetOrdersBySlotRange returns scheduled orders whose slot starts in [from, to), with items
+func GetOrdersBySlotRange(from, to time.Time) ([]Order, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
 
+	rows, err := configs.DB.Query(`
+		SELECT id, customer_name, COALESCE(delivery_type, 'pickup'), COALESCE(address, ''),
+		       status, total_items, COALESCE(total_amount, 0), COALESCE(sender_id, ''),
+		       slot_start, slot_end, created_at
+		FROM orders
+		WHERE slot_start >= $1 AND slot_start < $2
+		ORDER BY slot_start, id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	orders := []Order{}
+	for rows.Next() {
+		var o Order
+		err := rows.Scan(&o.ID, &o.CustomerName, &o.DeliveryType, &o.Address, &o.Status, &o.TotalItems,
+			&o.TotalAmount, &o.SenderID, &o.SlotStart, &o.SlotEnd, &o.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if items, err := GetOrderItems(orders[i].ID); err == nil {
+			orders[i].Items = items
+		}
+	}
+	return orders, nil
+}
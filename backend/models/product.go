@@ -4,22 +4,34 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // Product represents a product in the system
 type Product struct {
-	ID          int             `json:"id"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Category    string          `json:"category"`
-	Price       float64         `json:"price"`
-	Stock       int             `json:"stock"`
-	ImageURL    string          `json:"image_url"`
-	Status      string          `json:"status"` // draft, active, inactive, archived
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   sql.NullTime    `json:"deleted_at,omitempty"`
+	ID                 int               `json:"id"`
+	SKU                string            `json:"sku"` // optional stock-keeping unit
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	CategoryID         int               `json:"category_id"`
+	Category           string            `json:"category"` // name of the category
+	Price              float64           `json:"price"`
+	Stock              int               `json:"stock"`
+	LowStockThreshold  int               `json:"low_stock_threshold"`   // reorder point; stock below it is low (0 = never)
+	OutOfStockBehavior string            `json:"out_of_stock_behavior"` // none, hide, badge
+	AutoHidden         bool              `json:"auto_hidden"`           // deactivated by the system at zero stock
+	ImageURL           string            `json:"image_url"`
+	Status             string            `json:"status"` // draft, active, inactive, archived
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+	DeletedAt          sql.NullTime      `json:"deleted_at,omitempty"`
+	PublishAt          *time.Time        `json:"publish_at"`   // scheduled activation; nil = none
+	UnpublishAt        *time.Time        `json:"unpublish_at"` // scheduled deactivation; nil = none
+	Names              map[string]string `json:"names"`        // localized names by language code
+	Tags               []string          `json:"tags"`         // search keywords
 }
 
 // ProductLog represents an audit log entry for product changes
@@ -65,19 +77,24 @@ type Admin struct {
 
 // ProductFilter represents filters for product queries
 type ProductFilter struct {
-	Category string
-	Status   string
-	MinPrice float64
-	MaxPrice float64
-	Search   string
-	Limit    int
-	Offset   int
-	SortBy   string
-	SortDir  string
+	Category   string  `json:"category"`
+	CategoryID int     `json:"category_id"`
+	Status     string  `json:"status"`
+	MinPrice   float64 `json:"min_price"`
+	MaxPrice   float64 `json:"max_price"` // 0 = no limit
+	Search     string  `json:"search"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	SortBy     string  `json:"sort_by"`
+	SortDir    string  `json:"sort_dir"`
 }
 
 // Validate validates product data
 func (p *Product) Validate() error {
+	p.SKU = strings.TrimSpace(p.SKU)
+	if len(p.SKU) > 64 {
+		return errors.New("product SKU must be at most 64 characters")
+	}
 	if p.Name == "" {
 		return errors.New("product name is required")
 	}
@@ -93,15 +110,37 @@ func (p *Product) Validate() error {
 	if p.Stock < 0 {
 		return errors.New("product stock cannot be negative")
 	}
+	if p.LowStockThreshold < 0 {
+		return errors.New("low stock threshold cannot be negative")
+	}
+	if p.OutOfStockBehavior == "" {
+		p.OutOfStockBehavior = OutOfStockNone
+	}
+	if p.OutOfStockBehavior != OutOfStockNone && p.OutOfStockBehavior != OutOfStockHide && p.OutOfStockBehavior != OutOfStockBadge {
+		return errors.New("out_of_stock_behavior must be none, hide or badge")
+	}
 	if p.Status != "" && p.Status != "draft" && p.Status != "active" && p.Status != "inactive" && p.Status != "archived" {
 		return errors.New("invalid product status")
 	}
-	return nil
+	if p.PublishAt != nil && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return p.validateSearchFields()
 }
 
-// IsLowStock checks if product stock is low (less than 10)
+// Out-of-stock behaviors
+const (
+	OutOfStockNone  = "none"  // stays listed and orderable
+	OutOfStockHide  = "hide"  // deactivated at zero stock, reactivated on restock
+	OutOfStockBadge = "badge" // stays listed with a "sold out" badge, not orderable
+)
+
+// DefaultLowStockThreshold is the reorder point for products created without one
+const DefaultLowStockThreshold = 10
+
+// IsLowStock checks if product stock is below its reorder threshold (0 disables alerts)
 func (p *Product) IsLowStock() bool {
-	return p.Stock < 10
+	return p.LowStockThreshold > 0 && p.Stock < p.LowStockThreshold
 }
 
 // IsOutOfStock checks if product is out of stock
@@ -109,13 +148,29 @@ func (p *Product) IsOutOfStock() bool {
 	return p.Stock == 0
 }
 
-// CanPublish checks if product can be published
+// IsSoldOut reports whether the product is listed but cannot be ordered (sold out badge)
+func (p *Product) IsSoldOut() bool {
+	return p.OutOfStockBehavior == OutOfStockBadge && p.IsOutOfStock()
+}
+
+// CanPublish checks if product can be published: a complete draft, or a product an admin
+// deactivated (ones the stock sync hid come back by themselves when restocked)
 func (p *Product) CanPublish() bool {
-	return p.Status == "draft" && p.Name != "" && p.Price > 0
+	return (p.Status == "draft" || (p.Status == "inactive" && !p.AutoHidden)) && p.Name != "" && p.Price > 0
 }
 
 // CreateLogEntry creates a log entry for this product
 func CreateLogEntry(db *sql.DB, productID int, adminID sql.NullInt64, action string, changes map[string]interface{}) error {
+	return insertLogEntry(db, productID, adminID, action, changes)
+}
+
+// sqlExecer is satisfied by *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertLogEntry writes a product log entry directly or as part of a transaction
+func insertLogEntry(db sqlExecer, productID int, adminID sql.NullInt64, action string, changes map[string]interface{}) error {
 	changesJSON, err := json.Marshal(changes)
 	if err != nil {
 		return err
@@ -129,9 +184,15 @@ func CreateLogEntry(db *sql.DB, productID int, adminID sql.NullInt64, action str
 	return err
 }
 
-// IncrementViews increments the view count for a product
-func IncrementViews(db *sql.DB, productID int) error {
+// IncrementViews counts a product view (card tap or detail request) in the lifetime
+// counter and in the daily series for day, the shop-local date
+func IncrementViews(db *sql.DB, productID int, day time.Time) error {
 	query := `
+		WITH daily AS (
+			INSERT INTO product_analytics_daily (product_id, day, views)
+			VALUES ($1, $2, 1)
+			ON CONFLICT (product_id, day) DO UPDATE SET views = product_analytics_daily.views + 1
+		)
 		INSERT INTO product_analytics (product_id, views, last_viewed_at)
 		VALUES ($1, 1, CURRENT_TIMESTAMP)
 		ON CONFLICT (product_id) 
@@ -139,34 +200,48 @@ func IncrementViews(db *sql.DB, productID int) error {
 			views = product_analytics.views + 1,
 			last_viewed_at = CURRENT_TIMESTAMP
 	`
-	_, err := db.Exec(query, productID)
+	_, err := db.Exec(query, productID, day.Format("2006-01-02"))
 	return err
 }
 
-// IncrementPurchases increments the purchase count for a product
-func IncrementPurchases(db *sql.DB, productID int) error {
+// IncrementPurchases adds quantity purchased units of a product to the lifetime counter and
+// to the daily series for day (shop-local date). Pass the order's transaction so the counts
+// are committed or rolled back with the order.
+func IncrementPurchases(db sqlExecer, productID, quantity int, day time.Time) error {
 	query := `
+		WITH daily AS (
+			INSERT INTO product_analytics_daily (product_id, day, purchases)
+			VALUES ($1, $3, $2)
+			ON CONFLICT (product_id, day) DO UPDATE SET purchases = product_analytics_daily.purchases + $2
+		)
 		INSERT INTO product_analytics (product_id, purchases, last_purchased_at)
-		VALUES ($1, 1, CURRENT_TIMESTAMP)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
 		ON CONFLICT (product_id) 
 		DO UPDATE SET 
-			purchases = product_analytics.purchases + 1,
+			purchases = product_analytics.purchases + $2,
 			last_purchased_at = CURRENT_TIMESTAMP
 	`
-	_, err := db.Exec(query, productID)
+	_, err := db.Exec(query, productID, quantity, day.Format("2006-01-02"))
 	return err
 }
 
-// GetActiveProducts returns active, non-deleted products (limited)
-func GetActiveProducts(db *sql.DB, limit int, offset int, category string, search string) ([]Product, error) {
+// GetActiveProducts returns active, non-deleted products, newest first (limited).
+// categoryID 0 means all categories; search (see ProductSearchSQL) ranks matches first, "" matches all.
+// availableAt (shop local time) drops products outside their availability windows; zero skips the check.
+func GetActiveProducts(db *sql.DB, limit int, offset int, categoryID int, search string, availableAt time.Time) ([]Product, error) {
 	query := `
-		SELECT id, name, description, category, price, stock, image_url, status, created_at, updated_at
+		SELECT id, COALESCE(sku, ''), name, description, category_id, category, price, stock, low_stock_threshold, out_of_stock_behavior, auto_hidden, image_url, status, created_at, updated_at,
+		       names, tags
 		FROM products
 		WHERE deleted_at IS NULL AND status = 'active'
-		ORDER BY created_at DESC
+		  AND ($3 = 0 OR category_id = $3)
+		  AND ($4 = '' OR ` + ProductSearchSQL("products", 4) + `)
+		  AND ` + availableNowSQL("products", 5, 6) + `
+		ORDER BY CASE WHEN $4 = '' THEN 0 ELSE ` + ProductSearchRankSQL("products", 4) + ` END DESC, created_at DESC, id DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := db.Query(query, limit, offset)
+	weekday, clock := availabilityArgs(availableAt)
+	rows, err := db.Query(query, limit, offset, categoryID, NormalizeSearch(search), weekday, clock)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +252,13 @@ func GetActiveProducts(db *sql.DB, limit int, offset int, category string, searc
 		var p Product
 		var desc sql.NullString
 		var img sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &desc, &p.Category, &p.Price, &p.Stock, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		var names []byte
+		var tags []string
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &desc, &p.CategoryID, &p.Category, &p.Price, &p.Stock, &p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt,
+			&names, pq.Array(&tags)); err != nil {
+			return nil, err
+		}
+		if err := p.SetSearchFields(names, tags); err != nil {
 			return nil, err
 		}
 		if desc.Valid {
@@ -194,14 +275,18 @@ func GetActiveProducts(db *sql.DB, limit int, offset int, category string, searc
 // GetProductByID fetches a single product by ID
 func GetProductByID(db *sql.DB, id int) (*Product, error) {
 	query := `
-		SELECT id, name, description, category, price, stock, image_url, status, created_at, updated_at
+		SELECT id, COALESCE(sku, ''), name, description, category_id, category, price, stock, low_stock_threshold, out_of_stock_behavior, auto_hidden, image_url, status, created_at, updated_at,
+		       publish_at, unpublish_at, names, tags
 		FROM products
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 	var p Product
 	var desc sql.NullString
 	var img sql.NullString
-	err := db.QueryRow(query, id).Scan(&p.ID, &p.Name, &desc, &p.Category, &p.Price, &p.Stock, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	var names []byte
+	var tags []string
+	err := db.QueryRow(query, id).Scan(&p.ID, &p.SKU, &p.Name, &desc, &p.CategoryID, &p.Category, &p.Price, &p.Stock, &p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt,
+		&p.PublishAt, &p.UnpublishAt, &names, pq.Array(&tags))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -214,5 +299,41 @@ func GetProductByID(db *sql.DB, id int) (*Product, error) {
 	if img.Valid {
 		p.ImageURL = img.String
 	}
+	if err := p.SetSearchFields(names, tags); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
+
+// SKUTaken reports whether another live product already uses the SKU (case-insensitive)
+func SKUTaken(db *sql.DB, sku string, excludeID int) (bool, error) {
+	if sku == "" {
+		return false, nil
+	}
+	var taken bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM products WHERE LOWER(sku) = LOWER($1) AND id <> $2 AND deleted_at IS NULL)
+	`, sku, excludeID).Scan(&taken)
+	return taken, err
+}
+
+// CreateBatchLogEntry records an audit entry covering many products (product_id NULL)
+func CreateBatchLogEntry(db *sql.DB, adminID sql.NullInt64, action string, changes map[string]interface{}) error {
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO product_logs (product_id, admin_id, action, changes)
+		VALUES (NULL, $1, $2, $3)
+	`, adminID, action, changesJSON)
+	return err
+}
+
+// ImageInUse reports whether a product still refers to the image URL. Deleted products
+// count until they are purged, so a restored product keeps its image.
+func ImageInUse(db *sql.DB, url string) (bool, error) {
+	var inUse bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE image_url = $1)`, url).Scan(&inUse)
+	return inUse, err
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"bakeflow/i18n"
+
+	"github.com/lib/pq"
+)
+
+// ErrAliasExists is returned when a product already has the alias
+var ErrAliasExists = errors.New("product already has this alias")
+
+// ProductAlias is an admin-defined synonym for a product (e.g. "choco", "ချောကလက်")
+type ProductAlias struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	Alias     string    `json:"alias"`
+	Language  string    `json:"language"` // en, my, th
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate validates alias data
+func (a *ProductAlias) Validate() error {
+	a.Alias = strings.TrimSpace(a.Alias)
+	if a.Alias == "" {
+		return errors.New("alias is required")
+	}
+	if len(a.Alias) > 255 {
+		return errors.New("alias must be less than 255 characters")
+	}
+	if a.Language == "" {
+		a.Language = i18n.DefaultLanguage
+	}
+	if !i18n.IsSupported(a.Language) {
+		return errors.New("invalid alias language")
+	}
+	return nil
+}
+
+// GetProductAliases returns all aliases for a single product
+func GetProductAliases(db *sql.DB, productID int) ([]ProductAlias, error) {
+	rows, err := db.Query(`
+		SELECT id, product_id, alias, language, created_at
+		FROM product_aliases
+		WHERE product_id = $1
+		ORDER BY id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []ProductAlias{}
+	for rows.Next() {
+		var a ProductAlias
+		if err := rows.Scan(&a.ID, &a.ProductID, &a.Alias, &a.Language, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// GetActiveProductAliases returns aliases of all active, non-deleted products keyed by product ID
+func GetActiveProductAliases(db *sql.DB) (map[int][]string, error) {
+	rows, err := db.Query(`
+		SELECT pa.product_id, pa.alias
+		FROM product_aliases pa
+		JOIN products p ON p.id = pa.product_id
+		WHERE p.deleted_at IS NULL AND p.status = 'active'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := map[int][]string{}
+	for rows.Next() {
+		var productID int
+		var alias string
+		if err := rows.Scan(&productID, &alias); err != nil {
+			return nil, err
+		}
+		aliases[productID] = append(aliases[productID], alias)
+	}
+	return aliases, rows.Err()
+}
+
+// CreateProductAlias inserts a new alias for a product; returns ErrAliasExists if the product already has it
+func CreateProductAlias(db *sql.DB, a *ProductAlias) error {
+	query := `
+		INSERT INTO product_aliases (product_id, alias, language)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err := db.QueryRow(query, a.ProductID, a.Alias, a.Language).Scan(&a.ID, &a.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrAliasExists
+	}
+	return err
+}
+
+// DeleteProductAlias removes an alias; returns false if it did not exist
+func DeleteProductAlias(db *sql.DB, productID, aliasID int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM product_aliases WHERE id = $1 AND product_id = $2`, aliasID, productID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DailyProductStats is one shop day of product views and purchased units
+type DailyProductStats struct {
+	Day       string `json:"day"` // YYYY-MM-DD in the shop timezone
+	Views     int    `json:"views"`
+	Purchases int    `json:"purchases"`
+}
+
+// ProductTrend is one product's views and purchased units over a date range
+type ProductTrend struct {
+	ProductID        int     `json:"product_id"`
+	Name             string  `json:"name"`
+	Views            int     `json:"views"`
+	Purchases        int     `json:"purchases"`
+	PurchasesPerView float64 `json:"purchases_per_view"` // 0 without views
+}
+
+// Product trend orderings
+const (
+	TrendByViews     = "views"
+	TrendByPurchases = "purchases"
+)
+
+// GetProductDailyStats returns one row per day from from to to (shop-local dates, inclusive),
+// with zeros for days without activity. productID 0 sums all products.
+func GetProductDailyStats(db *sql.DB, productID int, from, to time.Time) ([]DailyProductStats, error) {
+	rows, err := db.Query(`
+		SELECT to_char(d, 'YYYY-MM-DD'), COALESCE(SUM(a.views), 0), COALESCE(SUM(a.purchases), 0)
+		FROM generate_series($1::date, $2::date, INTERVAL '1 day') d
+		LEFT JOIN product_analytics_daily a ON a.day = d::date AND ($3 = 0 OR a.product_id = $3)
+		GROUP BY d
+		ORDER BY d
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"), productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DailyProductStats{}
+	for rows.Next() {
+		var d DailyProductStats
+		if err := rows.Scan(&d.Day, &d.Views, &d.Purchases); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// GetProductTrends returns the products with the most views or purchases (orderBy) from
+// from to to (shop-local dates, inclusive), up to limit
+func GetProductTrends(db *sql.DB, from, to time.Time, orderBy string, limit int) ([]ProductTrend, error) {
+	order := "SUM(a.purchases) DESC, SUM(a.views) DESC"
+	if orderBy == TrendByViews {
+		order = "SUM(a.views) DESC, SUM(a.purchases) DESC"
+	}
+	rows, err := db.Query(`
+		SELECT p.id, p.name, SUM(a.views), SUM(a.purchases)
+		FROM product_analytics_daily a
+		JOIN products p ON p.id = a.product_id
+		WHERE a.day BETWEEN $1::date AND $2::date
+		GROUP BY p.id, p.name
+		ORDER BY `+order+`, p.id
+		LIMIT $3
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trends := []ProductTrend{}
+	for rows.Next() {
+		var t ProductTrend
+		if err := rows.Scan(&t.ProductID, &t.Name, &t.Views, &t.Purchases); err != nil {
+			return nil, err
+		}
+		if t.Views > 0 {
+			t.PurchasesPerView = float64(t.Purchases) / float64(t.Views)
+		}
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
+
+// GetProductLifetimeStats returns a product's all-time views and purchased units
+func GetProductLifetimeStats(db *sql.DB, productID int) (views, purchases int, err error) {
+	err = db.QueryRow(`
+		SELECT COALESCE(MAX(views), 0), COALESCE(MAX(purchases), 0)
+		FROM product_analytics WHERE product_id = $1
+	`, productID).Scan(&views, &purchases)
+	return views, purchases, err
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+)
+
+// SystemActor is the admin ID recorded in product logs for automatic changes
+var SystemActor = sql.NullInt64{Valid: false}
+
+// Product log actions for automatic availability changes
+const (
+	ActionAutoDeactivate = "AUTO_DEACTIVATE"
+	ActionAutoReactivate = "AUTO_REACTIVATE"
+)
+
+// SyncProductAvailability hides "hide" products that ran out of stock and shows again
+// the ones the system hid once they are restocked (or no longer set to hide).
+// productID limits the sync to one product; 0 syncs the whole catalog.
+// Returns the IDs of products whose status changed.
+func SyncProductAvailability(db *sql.DB, productID int) ([]int, error) {
+	deactivated, err := updateAvailability(db, `
+		UPDATE products SET status = 'inactive', auto_hidden = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE deleted_at IS NULL AND status = 'active' AND out_of_stock_behavior = 'hide' AND stock <= 0
+		  AND ($1 = 0 OR id = $1)
+		RETURNING id, stock
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	reactivated, err := updateAvailability(db, `
+		UPDATE products SET status = 'active', auto_hidden = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE deleted_at IS NULL AND auto_hidden AND status = 'inactive'
+		  AND (stock > 0 OR out_of_stock_behavior <> 'hide')
+		  AND ($1 = 0 OR id = $1)
+		RETURNING id, stock
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []int
+	for id, stock := range deactivated {
+		logAvailabilityChange(db, id, ActionAutoDeactivate, "active", "inactive", stock)
+		changed = append(changed, id)
+	}
+	for id, stock := range reactivated {
+		logAvailabilityChange(db, id, ActionAutoReactivate, "inactive", "active", stock)
+		changed = append(changed, id)
+	}
+	return changed, nil
+}
+
+// updateAvailability runs a status update and returns the changed product IDs with their stock
+func updateAvailability(db *sql.DB, query string, productID int) (map[int]int, error) {
+	rows, err := db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changed := map[int]int{}
+	for rows.Next() {
+		var id, stock int
+		if err := rows.Scan(&id, &stock); err != nil {
+			return nil, err
+		}
+		changed[id] = stock
+	}
+	return changed, rows.Err()
+}
+
+func logAvailabilityChange(db *sql.DB, productID int, action, oldStatus, newStatus string, stock int) {
+	changes := map[string]interface{}{
+		"action":     "status_changed",
+		"actor":      "system",
+		"reason":     "stock",
+		"stock":      stock,
+		"old_status": oldStatus,
+		"new_status": newStatus,
+	}
+	if err := CreateLogEntry(db, productID, SystemActor, action, changes); err != nil {
+		log.Printf("⚠️ Failed to log %s for product %d: %v", action, productID, err)
+	}
+}
@@ -0,0 +1,269 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+// Bulk operations
+const (
+	BulkStatus   = "status"
+	BulkPrice    = "price"
+	BulkStock    = "stock"
+	BulkCategory = "category"
+	BulkDelete   = "delete"
+)
+
+// Adjustment modes for bulk price and stock changes
+const (
+	AdjustSet     = "set"     // replace the value
+	AdjustAdd     = "add"     // add the (possibly negative) value
+	AdjustPercent = "percent" // change by a percentage (price only)
+)
+
+// MaxBulkProducts caps how many products one bulk request may change
+const MaxBulkProducts = 1000
+
+// ErrBulkRejected means at least one product could not be changed, so none were
+var ErrBulkRejected = errors.New("bulk operation rejected")
+
+// Adjustment is a numeric change for bulk price or stock updates
+type Adjustment struct {
+	Mode  string  `json:"mode"`
+	Value float64 `json:"value"`
+}
+
+// BulkOperation is one change applied to every selected product
+type BulkOperation struct {
+	Operation    string      `json:"operation"`
+	Status       string      `json:"status,omitempty"`
+	Price        *Adjustment `json:"price,omitempty"`
+	Stock        *Adjustment `json:"stock,omitempty"`
+	CategoryID   int         `json:"category_id,omitempty"`
+	CategoryName string      `json:"-"` // resolved by the caller for category moves
+}
+
+// BulkResult is the outcome for one product
+type BulkResult struct {
+	ProductID int         `json:"product_id"`
+	Name      string      `json:"name,omitempty"`
+	Success   bool        `json:"success"`
+	Error     string      `json:"error,omitempty"`
+	Old       interface{} `json:"old,omitempty"`
+	New       interface{} `json:"new,omitempty"`
+}
+
+// Validate checks that the operation has the parameters it needs
+func (op *BulkOperation) Validate() error {
+	switch op.Operation {
+	case BulkStatus:
+		if op.Status != "draft" && op.Status != "active" && op.Status != "inactive" && op.Status != "archived" {
+			return errors.New("status must be draft, active, inactive or archived")
+		}
+	case BulkPrice:
+		if op.Price == nil {
+			return errors.New("price adjustment is required")
+		}
+		if op.Price.Mode != AdjustSet && op.Price.Mode != AdjustAdd && op.Price.Mode != AdjustPercent {
+			return errors.New("price mode must be set, add or percent")
+		}
+		if op.Price.Mode == AdjustPercent && op.Price.Value <= -100 {
+			return errors.New("price percentage must be greater than -100")
+		}
+	case BulkStock:
+		if op.Stock == nil {
+			return errors.New("stock adjustment is required")
+		}
+		if op.Stock.Mode != AdjustSet && op.Stock.Mode != AdjustAdd {
+			return errors.New("stock mode must be set or add")
+		}
+		if op.Stock.Value != math.Trunc(op.Stock.Value) {
+			return errors.New("stock must be a whole number")
+		}
+	case BulkCategory:
+		if op.CategoryID <= 0 {
+			return errors.New("category_id is required")
+		}
+	case BulkDelete:
+	default:
+		return errors.New("operation must be status, price, stock, category or delete")
+	}
+	return nil
+}
+
+// apply changes p in memory and returns the old and new value of the changed field
+func (op *BulkOperation) apply(p *Product) (interface{}, interface{}, error) {
+	switch op.Operation {
+	case BulkStatus:
+		old := p.Status
+		p.Status = op.Status
+		return old, p.Status, nil
+	case BulkPrice:
+		old := p.Price
+		switch op.Price.Mode {
+		case AdjustSet:
+			p.Price = op.Price.Value
+		case AdjustAdd:
+			p.Price += op.Price.Value
+		case AdjustPercent:
+			p.Price *= 1 + op.Price.Value/100
+		}
+		p.Price = math.Round(p.Price*100) / 100
+		return old, p.Price, p.Validate()
+	case BulkStock:
+		old := p.Stock
+		if op.Stock.Mode == AdjustSet {
+			p.Stock = int(op.Stock.Value)
+		} else {
+			p.Stock += int(op.Stock.Value)
+		}
+		return old, p.Stock, p.Validate()
+	case BulkCategory:
+		old := p.Category
+		p.CategoryID, p.Category = op.CategoryID, op.CategoryName
+		return old, p.Category, nil
+	case BulkDelete:
+		return p.Status, "deleted", nil
+	}
+	return nil, nil, fmt.Errorf("unknown operation %q", op.Operation)
+}
+
+// FindProductIDs returns the IDs of live products matching the filter's conditions
+// (category, status, price range and search), up to limit
+func FindProductIDs(db *sql.DB, filter ProductFilter, limit int) ([]int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM products
+		WHERE deleted_at IS NULL
+		  AND ($1 = '' OR LOWER(category) = LOWER($1))
+		  AND ($2 = 0 OR category_id = $2)
+		  AND ($3 = '' OR status = $3)
+		  AND price >= $4 AND ($5 = 0 OR price <= $5)
+		  AND ($6 = '' OR `+ProductSearchSQL("products", 6)+`)
+		ORDER BY id
+		LIMIT $7
+	`, filter.Category, filter.CategoryID, filter.Status, filter.MinPrice, filter.MaxPrice, NormalizeSearch(filter.Search), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ApplyBulkOperation changes all given products in one transaction, logging each change.
+// Every product is checked first; if any cannot be changed (missing, or the result is invalid)
+// nothing is written and ErrBulkRejected is returned along with the per-product results.
+func ApplyBulkOperation(db *sql.DB, op BulkOperation, ids []int, adminID sql.NullInt64) ([]BulkResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, COALESCE(sku, ''), name, COALESCE(description, ''), category_id, category, price, stock,
+		       low_stock_threshold, out_of_stock_behavior, auto_hidden, COALESCE(image_url, ''), status
+		FROM products
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		FOR UPDATE
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	products := map[int]*Product{}
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.CategoryID, &p.Category, &p.Price, &p.Stock,
+			&p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &p.ImageURL, &p.Status); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		products[p.ID] = &p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Work out every change before writing any
+	results := make([]BulkResult, len(ids))
+	rejected := false
+	for i, id := range ids {
+		results[i].ProductID = id
+		p, ok := products[id]
+		if !ok {
+			results[i].Error = "product not found"
+			rejected = true
+			continue
+		}
+		results[i].Name = p.Name
+		if results[i].Old, results[i].New, err = op.apply(p); err != nil {
+			results[i].Error = err.Error()
+			rejected = true
+		}
+	}
+	if rejected {
+		return results, ErrBulkRejected
+	}
+
+	for i, id := range ids {
+		p := products[id]
+		action, err := writeBulkChange(tx, op, p)
+		if err != nil {
+			return nil, fmt.Errorf("product %d: %w", id, err)
+		}
+		changes := map[string]interface{}{
+			"action":    "bulk_" + op.Operation,
+			"operation": op,
+			"old_value": results[i].Old,
+			"new_value": results[i].New,
+		}
+		if op.Operation == BulkStatus || op.Operation == BulkDelete {
+			changes["old_status"] = results[i].Old // a restore after a bulk delete brings it back
+			changes["new_status"] = p.Status
+		}
+		if err := insertLogEntry(tx, id, adminID, action, changes); err != nil {
+			return nil, err
+		}
+		results[i].Success = true
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// writeBulkChange stores the changed field of one product and returns the log action
+func writeBulkChange(tx *sql.Tx, op BulkOperation, p *Product) (string, error) {
+	var err error
+	action := "UPDATE"
+	switch op.Operation {
+	case BulkStatus:
+		// An admin status change takes over from the system
+		_, err = tx.Exec(`UPDATE products SET status = $1, auto_hidden = FALSE WHERE id = $2`, p.Status, p.ID)
+		action = "STATUS_CHANGE"
+	case BulkPrice:
+		_, err = tx.Exec(`UPDATE products SET price = $1 WHERE id = $2`, p.Price, p.ID)
+	case BulkStock:
+		_, err = tx.Exec(`UPDATE products SET stock = $1 WHERE id = $2`, p.Stock, p.ID)
+	case BulkCategory:
+		_, err = tx.Exec(`UPDATE products SET category_id = $1, category = $2 WHERE id = $3`, p.CategoryID, p.Category, p.ID)
+	case BulkDelete:
+		_, err = tx.Exec(`UPDATE products SET deleted_at = CURRENT_TIMESTAMP, status = 'archived' WHERE id = $1`, p.ID)
+		p.Status = "archived"
+		action = "DELETE"
+	}
+	return action, err
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// GetDeletedProducts returns soft-deleted products, most recently deleted first
+func GetDeletedProducts(db *sql.DB) ([]Product, error) {
+	rows, err := db.Query(`
+		SELECT id, COALESCE(sku, ''), name, COALESCE(description, ''), category_id, category, price, stock,
+		       low_stock_threshold, out_of_stock_behavior, auto_hidden, COALESCE(image_url, ''), status,
+		       created_at, updated_at, deleted_at
+		FROM products
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.CategoryID, &p.Category, &p.Price, &p.Stock,
+			&p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &p.ImageURL, &p.Status,
+			&p.CreatedAt, &p.UpdatedAt, &p.DeletedAt); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// GetDeletedProductByID returns a soft-deleted product, or nil if it is live or does not exist
+func GetDeletedProductByID(db *sql.DB, id int) (*Product, error) {
+	var p Product
+	err := db.QueryRow(`
+		SELECT id, COALESCE(sku, ''), name, COALESCE(description, ''), category_id, category, price, stock,
+		       low_stock_threshold, out_of_stock_behavior, auto_hidden, COALESCE(image_url, ''), status,
+		       created_at, updated_at, deleted_at
+		FROM products
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id).Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.CategoryID, &p.Category, &p.Price, &p.Stock,
+		&p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &p.ImageURL, &p.Status,
+		&p.CreatedAt, &p.UpdatedAt, &p.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// RestoreProduct undeletes a product with the given status; false if it was not deleted
+func RestoreProduct(db *sql.DB, id int, status string) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE products SET deleted_at = NULL, status = $1, auto_hidden = FALSE
+		WHERE id = $2 AND deleted_at IS NOT NULL
+	`, status, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetProductLog returns one audit entry of a product, or nil if it belongs to another product
+func GetProductLog(db *sql.DB, productID, logID int) (*ProductLog, error) {
+	var l ProductLog
+	err := db.QueryRow(`
+		SELECT id, product_id, admin_id, action, changes, created_at
+		FROM product_logs
+		WHERE id = $1 AND product_id = $2
+	`, logID, productID).Scan(&l.ID, &l.ProductID, &l.AdminID, &l.Action, &l.Changes, &l.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetLatestProductLog returns the newest audit entry of a product with the given action, or nil
+func GetLatestProductLog(db *sql.DB, productID int, action string) (*ProductLog, error) {
+	var id int
+	err := db.QueryRow(`
+		SELECT id FROM product_logs
+		WHERE product_id = $1 AND action = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, productID, action).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetProductLog(db, productID, id)
+}
+
+// PurgeDeletedProducts permanently removes products soft-deleted more than days ago, together
+// with their analytics, aliases, recipes, options and audit trail (ON DELETE CASCADE). The
+// purged products' image URLs are returned so unused uploads can be removed.
+func PurgeDeletedProducts(db *sql.DB, days int) ([]Product, error) {
+	rows, err := db.Query(`
+		DELETE FROM products
+		WHERE deleted_at IS NOT NULL AND deleted_at < CURRENT_TIMESTAMP - make_interval(days => $1)
+		RETURNING id, COALESCE(sku, ''), name, category, COALESCE(image_url, ''), deleted_at
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purged := []Product{}
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Category, &p.ImageURL, &p.DeletedAt); err != nil {
+			return nil, err
+		}
+		purged = append(purged, p)
+	}
+	return purged, rows.Err()
+}
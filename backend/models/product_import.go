@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// Import row actions
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+)
+
+// ImportItem is a validated import row ready to be written
+type ImportItem struct {
+	Row     int     // 1-based row number in the uploaded file
+	Action  string  // create, update
+	Product Product // full product state after the import
+}
+
+// GetProductsForExport returns every non-deleted product, grouped by category
+func GetProductsForExport(db *sql.DB) ([]Product, error) {
+	rows, err := db.Query(`
+		SELECT id, COALESCE(sku, ''), name, COALESCE(description, ''), category_id, category, price, stock,
+		       low_stock_threshold, out_of_stock_behavior, auto_hidden, COALESCE(image_url, ''), status, created_at, updated_at
+		FROM products
+		WHERE deleted_at IS NULL
+		ORDER BY category, name, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.CategoryID, &p.Category, &p.Price, &p.Stock,
+			&p.LowStockThreshold, &p.OutOfStockBehavior, &p.AutoHidden, &p.ImageURL, &p.Status, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// FindProductForImport finds the live product an import row refers to: by SKU when the row
+// has one (falling back to a product of that name without SKU), otherwise by name
+func FindProductForImport(db *sql.DB, sku, name string) (*Product, error) {
+	var id int
+	var err error
+	if sku != "" {
+		err = db.QueryRow(`SELECT id FROM products WHERE LOWER(sku) = LOWER($1) AND deleted_at IS NULL`, sku).Scan(&id)
+		if err == sql.ErrNoRows {
+			err = db.QueryRow(`
+				SELECT id FROM products WHERE LOWER(name) = LOWER($1) AND sku IS NULL AND deleted_at IS NULL
+				ORDER BY id LIMIT 1
+			`, name).Scan(&id)
+		}
+	} else {
+		err = db.QueryRow(`
+			SELECT id FROM products WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL
+			ORDER BY id LIMIT 1
+		`, name).Scan(&id)
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetProductByID(db, id)
+}
+
+// ApplyProductImport writes all rows in one transaction; IDs of created products are filled in
+func ApplyProductImport(db *sql.DB, items []ImportItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range items {
+		p := &items[i].Product
+		if items[i].Action == ImportCreate {
+			err = tx.QueryRow(`
+				INSERT INTO products (sku, name, description, category_id, category, price, stock, low_stock_threshold,
+				                      out_of_stock_behavior, image_url, status)
+				VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				RETURNING id, created_at, updated_at
+			`, p.SKU, p.Name, p.Description, p.CategoryID, p.Category, p.Price, p.Stock, p.LowStockThreshold,
+				p.OutOfStockBehavior, p.ImageURL, p.Status).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO product_analytics (product_id) VALUES ($1) ON CONFLICT (product_id) DO NOTHING`, p.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = tx.QueryRow(`
+			UPDATE products
+			SET sku = NULLIF($1, ''), name = $2, description = $3, category_id = $4, category = $5, price = $6,
+			    stock = $7, low_stock_threshold = $8, out_of_stock_behavior = $9, image_url = $10, status = $11,
+			    auto_hidden = auto_hidden AND status = $11
+			WHERE id = $12 AND deleted_at IS NULL
+			RETURNING updated_at, auto_hidden
+		`, p.SKU, p.Name, p.Description, p.CategoryID, p.Category, p.Price, p.Stock, p.LowStockThreshold,
+			p.OutOfStockBehavior, p.ImageURL, p.Status, p.ID).Scan(&p.UpdatedAt, &p.AutoHidden)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,338 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Option group kinds
+const (
+	OptionGroupChoice      = "choice"      // pick one of the options
+	OptionGroupInscription = "inscription" // free-text message on the product
+)
+
+// DefaultInscriptionLength is used for inscription groups created without max_length
+const DefaultInscriptionLength = 40
+
+// ErrOptionUnavailable is returned when an ordered option is out of stock or was removed
+var ErrOptionUnavailable = errors.New("product option is no longer available")
+
+// ProductOptionGroup is one customization step of a product (size, flavour, message...)
+type ProductOptionGroup struct {
+	ID        int             `json:"id"`
+	ProductID int             `json:"product_id"`
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"` // choice, inscription
+	Required  bool            `json:"required"`
+	MaxLength int             `json:"max_length,omitempty"` // inscription groups only
+	SortOrder int             `json:"sort_order"`
+	CreatedAt time.Time       `json:"created_at"`
+	Options   []ProductOption `json:"options"`
+}
+
+// ProductOption is a choice within a group, e.g. 8" (+$10) with its own stock
+type ProductOption struct {
+	ID         int       `json:"id"`
+	GroupID    int       `json:"group_id"`
+	Name       string    `json:"name"`
+	PriceDelta float64   `json:"price_delta"`
+	Stock      *int      `json:"stock"` // nil = not tracked
+	Active     bool      `json:"active"`
+	SortOrder  int       `json:"sort_order"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderItemOption is a chosen option as stored on an order item
+type OrderItemOption struct {
+	GroupID    int     `json:"group_id"`
+	Group      string  `json:"group"`
+	OptionID   int     `json:"option_id"`
+	Option     string  `json:"option"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+// Validate validates option group data
+func (g *ProductOptionGroup) Validate() error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return errors.New("option group name is required")
+	}
+	if len(g.Name) > 100 {
+		return errors.New("option group name must be less than 100 characters")
+	}
+	if g.Kind == "" {
+		g.Kind = OptionGroupChoice
+	}
+	switch g.Kind {
+	case OptionGroupChoice:
+		g.MaxLength = 0
+	case OptionGroupInscription:
+		if g.MaxLength <= 0 {
+			g.MaxLength = DefaultInscriptionLength
+		}
+	default:
+		return errors.New("option group kind must be choice or inscription")
+	}
+	return nil
+}
+
+// Validate validates option data
+func (o *ProductOption) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" {
+		return errors.New("option name is required")
+	}
+	if len(o.Name) > 100 {
+		return errors.New("option name must be less than 100 characters")
+	}
+	if o.Stock != nil && *o.Stock < 0 {
+		return errors.New("option stock cannot be negative")
+	}
+	return nil
+}
+
+// Available reports whether the option can be chosen right now
+func (o *ProductOption) Available() bool {
+	return o.Active && (o.Stock == nil || *o.Stock > 0)
+}
+
+// Label is the option name with its price delta, e.g. `8" (+$10.00)`
+func (o *ProductOption) Label() string {
+	switch {
+	case o.PriceDelta > 0:
+		return fmt.Sprintf("%s (+$%.2f)", o.Name, o.PriceDelta)
+	case o.PriceDelta < 0:
+		return fmt.Sprintf("%s (-$%.2f)", o.Name, -o.PriceDelta)
+	}
+	return o.Name
+}
+
+// ValidateInscription trims the text and checks it fits the group
+func (g *ProductOptionGroup) ValidateInscription(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("inscription is empty")
+	}
+	if utf8.RuneCountInString(text) > g.MaxLength {
+		return "", fmt.Errorf("inscription must be at most %d characters", g.MaxLength)
+	}
+	return text, nil
+}
+
+// GetProductOptionGroups returns a product's option groups in display order, with options
+func GetProductOptionGroups(db *sql.DB, productID int) ([]ProductOptionGroup, error) {
+	rows, err := db.Query(`
+		SELECT id, product_id, name, kind, required, max_length, sort_order, created_at
+		FROM product_option_groups
+		WHERE product_id = $1
+		ORDER BY sort_order, id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []ProductOptionGroup{}
+	index := map[int]int{}
+	for rows.Next() {
+		var g ProductOptionGroup
+		if err := rows.Scan(&g.ID, &g.ProductID, &g.Name, &g.Kind, &g.Required, &g.MaxLength, &g.SortOrder, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		g.Options = []ProductOption{}
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	optRows, err := db.Query(`
+		SELECT o.id, o.group_id, o.name, o.price_delta, o.stock, o.active, o.sort_order, o.created_at
+		FROM product_options o
+		JOIN product_option_groups g ON g.id = o.group_id
+		WHERE g.product_id = $1
+		ORDER BY o.sort_order, o.id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer optRows.Close()
+
+	for optRows.Next() {
+		o, err := scanProductOption(optRows)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[o.GroupID]; ok {
+			groups[i].Options = append(groups[i].Options, *o)
+		}
+	}
+	return groups, optRows.Err()
+}
+
+func scanProductOption(row interface{ Scan(...interface{}) error }) (*ProductOption, error) {
+	var o ProductOption
+	var stock sql.NullInt64
+	if err := row.Scan(&o.ID, &o.GroupID, &o.Name, &o.PriceDelta, &stock, &o.Active, &o.SortOrder, &o.CreatedAt); err != nil {
+		return nil, err
+	}
+	if stock.Valid {
+		s := int(stock.Int64)
+		o.Stock = &s
+	}
+	return &o, nil
+}
+
+// GetProductOptionGroup fetches one group (without options); nil if it does not belong to the product
+func GetProductOptionGroup(db *sql.DB, productID, groupID int) (*ProductOptionGroup, error) {
+	var g ProductOptionGroup
+	err := db.QueryRow(`
+		SELECT id, product_id, name, kind, required, max_length, sort_order, created_at
+		FROM product_option_groups
+		WHERE id = $1 AND product_id = $2
+	`, groupID, productID).Scan(&g.ID, &g.ProductID, &g.Name, &g.Kind, &g.Required, &g.MaxLength, &g.SortOrder, &g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// CreateProductOptionGroup inserts a group; a product may have only one inscription group
+func CreateProductOptionGroup(db *sql.DB, g *ProductOptionGroup) error {
+	if g.Kind == OptionGroupInscription {
+		var exists bool
+		if err := db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM product_option_groups WHERE product_id = $1 AND kind = 'inscription')
+		`, g.ProductID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("product already has an inscription group")
+		}
+	}
+	g.Options = []ProductOption{}
+	return db.QueryRow(`
+		INSERT INTO product_option_groups (product_id, name, kind, required, max_length, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, g.ProductID, g.Name, g.Kind, g.Required, g.MaxLength, g.SortOrder).Scan(&g.ID, &g.CreatedAt)
+}
+
+// UpdateProductOptionGroup updates name, required flag, length and order (the kind is fixed)
+func UpdateProductOptionGroup(db *sql.DB, g *ProductOptionGroup) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE product_option_groups SET name = $1, required = $2, max_length = $3, sort_order = $4
+		WHERE id = $5 AND product_id = $6
+	`, g.Name, g.Required, g.MaxLength, g.SortOrder, g.ID, g.ProductID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteProductOptionGroup removes a group and its options
+func DeleteProductOptionGroup(db *sql.DB, productID, groupID int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM product_option_groups WHERE id = $1 AND product_id = $2`, groupID, productID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreateProductOption adds an option to a choice group
+func CreateProductOption(db *sql.DB, o *ProductOption) error {
+	return db.QueryRow(`
+		INSERT INTO product_options (group_id, name, price_delta, stock, active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, o.GroupID, o.Name, o.PriceDelta, o.Stock, o.Active, o.SortOrder).Scan(&o.ID, &o.CreatedAt)
+}
+
+// UpdateProductOption updates an option of a group
+func UpdateProductOption(db *sql.DB, o *ProductOption) (bool, error) {
+	err := db.QueryRow(`
+		UPDATE product_options SET name = $1, price_delta = $2, stock = $3, active = $4, sort_order = $5
+		WHERE id = $6 AND group_id = $7
+		RETURNING created_at
+	`, o.Name, o.PriceDelta, o.Stock, o.Active, o.SortOrder, o.ID, o.GroupID).Scan(&o.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteProductOption removes an option from a group
+func DeleteProductOption(db *sql.DB, groupID, optionID int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM product_options WHERE id = $1 AND group_id = $2`, optionID, groupID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// reserveOptionStock takes ordered quantities out of tracked option stock inside the order transaction
+func reserveOptionStock(tx *sql.Tx, items []OrderItem) error {
+	for _, item := range items {
+		for _, opt := range item.Options {
+			var tracked bool
+			err := tx.QueryRow(`
+				SELECT stock IS NOT NULL FROM product_options WHERE id = $1 AND active
+			`, opt.OptionID).Scan(&tracked)
+			if err == sql.ErrNoRows {
+				return ErrOptionUnavailable
+			}
+			if err != nil {
+				return err
+			}
+			if !tracked {
+				continue
+			}
+			res, err := tx.Exec(`
+				UPDATE product_options SET stock = stock - $1 WHERE id = $2 AND stock >= $1
+			`, item.Quantity, opt.OptionID)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				return ErrOptionUnavailable
+			}
+		}
+	}
+	return nil
+}
+
+// OptionsAvailable reports whether every chosen option can still be ordered in this quantity
+func OptionsAvailable(db *sql.DB, options []OrderItemOption, quantity int) (bool, error) {
+	for _, opt := range options {
+		var ok bool
+		err := db.QueryRow(`
+			SELECT active AND (stock IS NULL OR stock >= $2) FROM product_options WHERE id = $1
+		`, opt.OptionID, quantity).Scan(&ok)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
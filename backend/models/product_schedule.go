@@ -0,0 +1,260 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Product log actions for scheduled status changes
+const (
+	ActionAutoPublish    = "AUTO_PUBLISH"
+	ActionAutoUnpublish  = "AUTO_UNPUBLISH"
+	ActionPublishSkipped = "PUBLISH_SKIPPED"
+)
+
+// AvailabilityWindow is a weekday and time range in which a product is offered, in the shop timezone
+type AvailabilityWindow struct {
+	Weekday   int    `json:"weekday"`    // 0 = Sunday ... 6 = Saturday
+	StartTime string `json:"start_time"` // HH:MM
+	EndTime   string `json:"end_time"`   // HH:MM, exclusive
+}
+
+// Validate validates an availability window
+func (w *AvailabilityWindow) Validate() error {
+	if w.Weekday < 0 || w.Weekday > 6 {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return errors.New("start_time must be HH:MM")
+	}
+	end, err := time.Parse("15:04", w.EndTime)
+	if err != nil {
+		return errors.New("end_time must be HH:MM")
+	}
+	if !start.Before(end) {
+		return errors.New("start_time must be before end_time")
+	}
+	w.StartTime, w.EndTime = start.Format("15:04"), end.Format("15:04")
+	return nil
+}
+
+// AvailableAt reports whether a product with these windows is offered at t (shop local time).
+// A product without windows is always available.
+func AvailableAt(windows []AvailabilityWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	clock := t.Format("15:04")
+	for _, w := range windows {
+		if w.Weekday == int(t.Weekday()) && w.StartTime <= clock && clock < w.EndTime {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAvailabilityWindows returns the windows of one product, by weekday and start time
+func GetAvailabilityWindows(db *sql.DB, productID int) ([]AvailabilityWindow, error) {
+	all, err := queryAvailabilityWindows(db, `WHERE product_id = $1`, productID)
+	if err != nil {
+		return nil, err
+	}
+	windows := all[productID]
+	if windows == nil {
+		windows = []AvailabilityWindow{}
+	}
+	return windows, nil
+}
+
+// GetAllAvailabilityWindows returns the windows of every product that has any, by product ID
+func GetAllAvailabilityWindows(db *sql.DB) (map[int][]AvailabilityWindow, error) {
+	return queryAvailabilityWindows(db, ``)
+}
+
+func queryAvailabilityWindows(db *sql.DB, where string, args ...interface{}) (map[int][]AvailabilityWindow, error) {
+	rows, err := db.Query(`
+		SELECT product_id, weekday, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM product_availability_windows `+where+`
+		ORDER BY product_id, weekday, start_time
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := map[int][]AvailabilityWindow{}
+	for rows.Next() {
+		var productID int
+		var w AvailabilityWindow
+		if err := rows.Scan(&productID, &w.Weekday, &w.StartTime, &w.EndTime); err != nil {
+			return nil, err
+		}
+		windows[productID] = append(windows[productID], w)
+	}
+	return windows, rows.Err()
+}
+
+// SetAvailabilityWindows replaces the windows of a product (an empty list makes it available all day)
+func SetAvailabilityWindows(db *sql.DB, productID int, windows []AvailabilityWindow) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_availability_windows WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for _, w := range windows {
+		if _, err := tx.Exec(`
+			INSERT INTO product_availability_windows (product_id, weekday, start_time, end_time)
+			VALUES ($1, $2, $3, $4)
+		`, productID, w.Weekday, w.StartTime, w.EndTime); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// availableNowSQL is a condition limiting a products query (table or alias name) to products
+// offered at the weekday and time of day (HH:MM) passed as the given placeholders.
+// A weekday of -1 disables the check.
+func availableNowSQL(table string, weekdayArg, timeArg int) string {
+	return fmt.Sprintf(`
+		($%[2]d < 0 OR NOT EXISTS (SELECT 1 FROM product_availability_windows aw WHERE aw.product_id = %[1]s.id)
+		 OR EXISTS (
+			SELECT 1 FROM product_availability_windows aw
+			WHERE aw.product_id = %[1]s.id AND aw.weekday = $%[2]d
+			  AND aw.start_time <= $%[3]d::time AND $%[3]d::time < aw.end_time
+		 ))`, table, weekdayArg, timeArg)
+}
+
+// availabilityArgs returns the weekday and time of day for availableNowSQL (zero t = no check)
+func availabilityArgs(t time.Time) (int, string) {
+	if t.IsZero() {
+		return -1, "00:00"
+	}
+	return int(t.Weekday()), t.Format("15:04")
+}
+
+// ApplyProductSchedule activates products whose publish_at has passed and deactivates those
+// whose unpublish_at has passed, logging each change. Both times are cleared once applied so
+// later manual status changes stick. Returns the IDs of products whose status changed.
+func ApplyProductSchedule(db *sql.DB) ([]int, error) {
+	var changed []int
+
+	// Publishing goes through CanPublish, so load the due products first
+	rows, err := db.Query(`SELECT id FROM products WHERE publish_at <= NOW() AND deleted_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	var due []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range due {
+		p, err := GetProductByID(db, id)
+		if err != nil {
+			return changed, err
+		}
+		if p == nil || p.PublishAt == nil {
+			continue
+		}
+		publish := p.CanPublish()
+		status := p.Status
+		if publish {
+			status = "active"
+		}
+		res, err := db.Exec(`
+			UPDATE products SET status = $1, publish_at = NULL
+			WHERE id = $2 AND publish_at IS NOT NULL AND deleted_at IS NULL
+		`, status, id)
+		if err != nil {
+			return changed, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		switch {
+		case publish:
+			logScheduleChange(db, id, ActionAutoPublish, p.Status, status, *p.PublishAt, "")
+			changed = append(changed, id)
+		case p.Status != "active" && !p.AutoHidden:
+			// Already active or hidden for stock needs no note; anything else could not go live
+			logScheduleChange(db, id, ActionPublishSkipped, p.Status, p.Status, *p.PublishAt, "product is not a publishable draft (needs a name and a price)")
+		}
+	}
+
+	// Unpublishing also takes products the stock sync hid, so a restock does not bring them back
+	rows, err = db.Query(`
+		UPDATE products p SET status = 'inactive', auto_hidden = FALSE, unpublish_at = NULL
+		FROM (
+			SELECT id, status, unpublish_at FROM products
+			WHERE unpublish_at <= NOW() AND deleted_at IS NULL AND (status = 'active' OR auto_hidden)
+			FOR UPDATE
+		) due
+		WHERE p.id = due.id
+		RETURNING p.id, due.status, due.unpublish_at
+	`)
+	if err != nil {
+		return changed, err
+	}
+	defer rows.Close()
+	type unpublished struct {
+		id     int
+		status string
+		at     time.Time
+	}
+	var done []unpublished
+	for rows.Next() {
+		var u unpublished
+		if err := rows.Scan(&u.id, &u.status, &u.at); err != nil {
+			return changed, err
+		}
+		done = append(done, u)
+	}
+	if err := rows.Err(); err != nil {
+		return changed, err
+	}
+	for _, u := range done {
+		logScheduleChange(db, u.id, ActionAutoUnpublish, u.status, "inactive", u.at, "")
+		changed = append(changed, u.id)
+	}
+
+	// Schedules that passed while the product was already off need no action
+	if _, err := db.Exec(`UPDATE products SET unpublish_at = NULL WHERE unpublish_at <= NOW()`); err != nil {
+		return changed, err
+	}
+	return changed, nil
+}
+
+func logScheduleChange(db *sql.DB, productID int, action, oldStatus, newStatus string, scheduledAt time.Time, note string) {
+	changes := map[string]interface{}{
+		"action":       "status_changed",
+		"actor":        "system",
+		"reason":       "schedule",
+		"scheduled_at": scheduledAt,
+		"old_status":   oldStatus,
+		"new_status":   newStatus,
+	}
+	if note != "" {
+		changes["note"] = note
+	}
+	if err := CreateLogEntry(db, productID, SystemActor, action, changes); err != nil {
+		log.Printf("⚠️ Failed to log %s for product %d: %v", action, productID, err)
+	}
+}
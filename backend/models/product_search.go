@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxProductTags caps how many search tags a product may have
+const MaxProductTags = 20
+
+// maxSearchLength caps a search term; longer input is cut
+const maxSearchLength = 100
+
+// NormalizeSearch trims and lowercases a search term the way search_text is stored
+func NormalizeSearch(term string) string {
+	term = strings.ToLower(strings.Join(strings.Fields(term), " "))
+	if utf8.RuneCountInString(term) > maxSearchLength {
+		term = string([]rune(term)[:maxSearchLength])
+	}
+	return term
+}
+
+// ProductSearchSQL is the condition matching products (table alias) to a normalized search
+// term ($arg): whole words via full text, any substring (Burmese and Thai are written without
+// spaces between words; % and _ are matched literally) and close spellings via trigram word
+// similarity ("chesecake")
+func ProductSearchSQL(table string, arg int) string {
+	return fmt.Sprintf(`(%[1]s.search_vector @@ plainto_tsquery('simple', $%[2]d)
+		OR %[1]s.search_text LIKE '%%' || replace(replace(replace($%[2]d, '\', '\\'), '%%', '\%%'), '_', '\_') || '%%'
+		OR $%[2]d <%% %[1]s.search_text)`, table, arg)
+}
+
+// ProductSearchRankSQL scores a product against the search term ($arg), best first:
+// an exact name beats name words, which beat tags and category, which beat the description
+func ProductSearchRankSQL(table string, arg int) string {
+	return fmt.Sprintf(`(CASE WHEN LOWER(%[1]s.name) = $%[2]d THEN 1 ELSE 0 END
+		+ ts_rank(%[1]s.search_vector, plainto_tsquery('simple', $%[2]d))
+		+ word_similarity($%[2]d, %[1]s.search_text))`, table, arg)
+}
+
+// DisplayName returns the product name in a language, falling back to Name
+func (p *Product) DisplayName(lang string) string {
+	if name := p.Names[lang]; name != "" {
+		return name
+	}
+	return p.Name
+}
+
+// SetSearchFields fills Names and Tags from their scanned columns (names JSONB, tags TEXT[])
+func (p *Product) SetSearchFields(names []byte, tags []string) error {
+	p.Names = map[string]string{}
+	if len(names) > 0 {
+		if err := json.Unmarshal(names, &p.Names); err != nil {
+			return err
+		}
+	}
+	p.Tags = tags
+	if p.Tags == nil {
+		p.Tags = []string{}
+	}
+	return nil
+}
+
+// ProductNamesJSON encodes localized names for the names JSONB column
+func ProductNamesJSON(names map[string]string) string {
+	if len(names) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(names) // a string map always encodes
+	return string(data)
+}
+
+// validateSearchFields normalizes localized names (by lowercase language code) and tags
+// (lowercase, deduplicated)
+func (p *Product) validateSearchFields() error {
+	names := map[string]string{}
+	for lang, name := range p.Names {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if name = strings.TrimSpace(name); lang != "" && name != "" {
+			if len(name) > 255 {
+				return fmt.Errorf("product name for %q must be less than 255 characters", lang)
+			}
+			names[lang] = name
+		}
+	}
+	p.Names = names
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range p.Tags {
+		tag = NormalizeSearch(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > 50 {
+			return errors.New("product tags must be at most 50 characters")
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxProductTags {
+		return fmt.Errorf("a product can have at most %d tags", MaxProductTags)
+	}
+	p.Tags = tags
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"bakeflow/configs"
+)
+
+// ProductionItem is one order line that still has to be baked
+type ProductionItem struct {
+	OrderID   int
+	Status    string
+	SlotStart *time.Time
+	SlotEnd   *time.Time
+	ProductID int    // 0 for items not linked to the catalog
+	Product   string // current catalog name for linked items
+	Quantity  int
+}
+
+// GetProductionItems returns the items of pending/preparing orders due in [from, to):
+// scheduled orders by slot start, ASAP orders by the time they were placed
+func GetProductionItems(from, to time.Time) ([]ProductionItem, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`
+		SELECT o.id, o.status, o.slot_start, o.slot_end, COALESCE(oi.product_id, 0), COALESCE(p.name, oi.product), oi.quantity
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		LEFT JOIN products p ON p.id = oi.product_id
+		WHERE o.status IN ('pending', 'preparing')
+		  AND ((o.slot_start >= $1 AND o.slot_start < $2)
+		       OR (o.slot_start IS NULL AND o.created_at >= $1 AND o.created_at < $2))
+		ORDER BY o.slot_start NULLS FIRST, oi.product
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ProductionItem{}
+	for rows.Next() {
+		var it ProductionItem
+		if err := rows.Scan(&it.OrderID, &it.Status, &it.SlotStart, &it.SlotEnd, &it.ProductID, &it.Product, &it.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,326 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+)
+
+// LowRatingStars is the highest score that is flagged for follow-up when given
+const LowRatingStars = 2
+
+// Rating follow-up states (no follow-up needed when empty)
+const (
+	FollowUpOpen     = "open"
+	FollowUpResolved = "resolved"
+)
+
+// ErrAlreadyRated is returned when an order already has a rating
+var ErrAlreadyRated = errors.New("order already rated")
+
+type Rating struct {
+	ID           int        `json:"id"`
+	OrderID      int        `json:"order_id"`
+	UserID       string     `json:"user_id"`
+	Stars        int        `json:"stars"` // 1-5
+	Comment      string     `json:"comment,omitempty"`
+	FollowUp     string     `json:"follow_up,omitempty"` // "", open or resolved
+	FollowUpNote string     `json:"follow_up_note,omitempty"`
+	FlaggedAt    *time.Time `json:"flagged_at,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RatingView is a rating with the order it is about, for the admin list
+type RatingView struct {
+	Rating
+	CustomerName string  `json:"customer_name"`
+	TotalAmount  float64 `json:"total_amount"`
+	Products     string  `json:"products"` // ordered products, comma separated
+}
+
+// RatingFilter selects ratings for the admin list
+type RatingFilter struct {
+	Range    SalesRange
+	MinStars int    // 0 = any
+	MaxStars int    // 0 = any
+	FollowUp string // "", open or resolved
+	Limit    int
+	Offset   int
+}
+
+// RatingSummary is the number and average of ratings in a report period
+type RatingSummary struct {
+	Ratings      int     `json:"ratings"`
+	Average      float64 `json:"average"`      // 0 without ratings
+	Distribution [5]int  `json:"distribution"` // ratings with 1 to 5 stars
+}
+
+// RatingPeriod is the ratings of one day, week or month
+type RatingPeriod struct {
+	Period  string  `json:"period"` // first day of the period, YYYY-MM-DD
+	Ratings int     `json:"ratings"`
+	Average float64 `json:"average"`
+}
+
+// ProductRating is the average score of the orders a product was part of
+type ProductRating struct {
+	ProductID int     `json:"product_id,omitempty"` // 0 for items not linked to the catalog
+	Name      string  `json:"name"`
+	Ratings   int     `json:"ratings"`
+	Average   float64 `json:"average"`
+}
+
+// ratingColumns are the columns scanned by scanRating, for ratings aliased r
+const ratingColumns = `r.id, r.order_id, r.user_id, r.stars, COALESCE(r.comment, ''),
+	COALESCE(r.follow_up, ''), COALESCE(r.follow_up_note, ''), r.flagged_at, r.resolved_at, r.created_at`
+
+// ratingRangeWhere limits ratings (alias r) to the range's $1 and $2, compared as instants
+// like orders in sales reports
+const ratingRangeWhere = `r.created_at >= $1::timestamptz AND r.created_at < $2::timestamptz`
+
+func scanRating(row interface{ Scan(...interface{}) error }, r *Rating, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{&r.ID, &r.OrderID, &r.UserID, &r.Stars, &r.Comment,
+		&r.FollowUp, &r.FollowUpNote, &r.FlaggedAt, &r.ResolvedAt, &r.CreatedAt}, extra...)...)
+}
+
+// CreateRating saves a customer rating for an order and links it to the order. Low ratings
+// are flagged for follow-up. Returns ErrAlreadyRated if the order was rated before.
+func CreateRating(r *Rating) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+	tx, err := configs.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if r.Stars <= LowRatingStars {
+		r.FollowUp = FollowUpOpen
+	}
+	err = tx.QueryRow(`
+		INSERT INTO ratings (order_id, user_id, stars, comment, follow_up, flagged_at, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5::text, ''), CASE WHEN $5::text = '' THEN NULL ELSE NOW() END, NOW())
+		ON CONFLICT (order_id) DO NOTHING
+		RETURNING id, flagged_at, created_at
+	`, r.OrderID, r.UserID, r.Stars, r.Comment, r.FollowUp).Scan(&r.ID, &r.FlaggedAt, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAlreadyRated
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE orders SET rating_id = $1 WHERE id = $2`, r.ID, r.OrderID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetRatingComment stores the comment a customer added after rating
+func SetRatingComment(id int, comment string) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+	_, err := configs.DB.Exec(`UPDATE ratings SET comment = $2 WHERE id = $1`, id, comment)
+	return err
+}
+
+// GetRatingByOrderID returns the rating for a specific order
+func GetRatingByOrderID(orderID int) (*Rating, error) {
+	var r Rating
+	err := scanRating(configs.DB.QueryRow(`
+		SELECT `+ratingColumns+`
+		FROM ratings r
+		WHERE r.order_id = $1
+	`, orderID), &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRatings returns the ratings matching the filter, newest first, and how many match in total
+func ListRatings(f RatingFilter) ([]RatingView, int, error) {
+	if configs.DB == nil {
+		return nil, 0, sql.ErrConnDone
+	}
+	where := []string{ratingRangeWhere}
+	args := []interface{}{f.Range.From, f.Range.To}
+	if f.MinStars > 0 {
+		args = append(args, f.MinStars)
+		where = append(where, fmt.Sprintf("r.stars >= $%d", len(args)))
+	}
+	if f.MaxStars > 0 {
+		args = append(args, f.MaxStars)
+		where = append(where, fmt.Sprintf("r.stars <= $%d", len(args)))
+	}
+	if f.FollowUp != "" {
+		args = append(args, f.FollowUp)
+		where = append(where, fmt.Sprintf("r.follow_up = $%d", len(args)))
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int
+	if err := configs.DB.QueryRow(`SELECT COUNT(*) FROM ratings r WHERE `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	rows, err := configs.DB.Query(fmt.Sprintf(`
+		SELECT `+ratingColumns+`, COALESCE(o.customer_name, ''), COALESCE(o.total_amount, 0),
+		       COALESCE((SELECT string_agg(oi.quantity || '× ' || oi.product, ', ' ORDER BY oi.id)
+		                 FROM order_items oi WHERE oi.order_id = r.order_id), '')
+		FROM ratings r
+		LEFT JOIN orders o ON o.id = r.order_id
+		WHERE %s
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $%d OFFSET $%d
+	`, cond, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	ratings := []RatingView{}
+	for rows.Next() {
+		var v RatingView
+		if err := scanRating(rows, &v.Rating, &v.CustomerName, &v.TotalAmount, &v.Products); err != nil {
+			return nil, 0, err
+		}
+		ratings = append(ratings, v)
+	}
+	return ratings, total, rows.Err()
+}
+
+// SetRatingFollowUp changes a rating's follow-up state ("" clears the flag) and, when note
+// is not nil, the staff note. Reports false if the rating does not exist.
+func SetRatingFollowUp(id int, status string, note *string) (bool, error) {
+	if configs.DB == nil {
+		return false, sql.ErrConnDone
+	}
+	res, err := configs.DB.Exec(`
+		UPDATE ratings SET
+			follow_up = NULLIF($2::text, ''),
+			flagged_at = CASE WHEN $2 = '' THEN NULL ELSE COALESCE(flagged_at, NOW()) END,
+			resolved_at = CASE WHEN $2 = 'resolved' THEN COALESCE(resolved_at, NOW()) END,
+			follow_up_note = CASE WHEN $3::boolean THEN $4 ELSE follow_up_note END
+		WHERE id = $1
+	`, id, status, note != nil, note)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetRatingSummary counts and averages the ratings given in the range
+func GetRatingSummary(rng SalesRange) (*RatingSummary, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := configs.DB.Query(`
+		SELECT r.stars, COUNT(*)
+		FROM ratings r
+		WHERE `+ratingRangeWhere+`
+		GROUP BY r.stars
+	`, rng.From, rng.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var s RatingSummary
+	sum := 0
+	for rows.Next() {
+		var stars, count int
+		if err := rows.Scan(&stars, &count); err != nil {
+			return nil, err
+		}
+		if stars >= 1 && stars <= 5 {
+			s.Distribution[stars-1] = count
+		}
+		s.Ratings += count
+		sum += stars * count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if s.Ratings > 0 {
+		s.Average = float64(sum) / float64(s.Ratings)
+	}
+	return &s, nil
+}
+
+// GetRatingsByPeriod returns the number and average of ratings for every day, week or month
+// in the range, oldest first, with zeros for periods without ratings
+func GetRatingsByPeriod(rng SalesRange, interval string) ([]RatingPeriod, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	last := rng.To.Add(-time.Nanosecond).In(rng.Location)
+	rows, err := configs.DB.Query(`
+		WITH rated AS (
+			SELECT date_trunc($4, (r.created_at::timestamptz AT TIME ZONE $3)) AS period,
+			       COUNT(*) AS ratings, AVG(r.stars) AS average
+			FROM ratings r
+			WHERE `+ratingRangeWhere+`
+			GROUP BY 1
+		)
+		SELECT to_char(p, 'YYYY-MM-DD'), COALESCE(s.ratings, 0), COALESCE(s.average, 0)
+		FROM generate_series(date_trunc($4, $5::timestamp), date_trunc($4, $6::timestamp), ('1 ' || $4)::interval) p
+		LEFT JOIN rated s ON s.period = p
+		ORDER BY p
+	`, rng.From, rng.To, rng.Location.String(), interval,
+		rng.From.In(rng.Location).Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []RatingPeriod{}
+	for rows.Next() {
+		var p RatingPeriod
+		if err := rows.Scan(&p.Period, &p.Ratings, &p.Average); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// GetProductRatings returns the average rating of the orders each product was part of, in
+// the range, best rated first. Items are grouped like GetTopProducts.
+func GetProductRatings(rng SalesRange) ([]ProductRating, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := configs.DB.Query(`
+		SELECT COALESCE(oi.product_id, 0), COALESCE(MAX(p.name), MIN(oi.product)),
+		       COUNT(DISTINCT r.id), AVG(r.stars)
+		FROM ratings r
+		JOIN order_items oi ON oi.order_id = r.order_id
+		LEFT JOIN products p ON p.id = oi.product_id
+		WHERE `+ratingRangeWhere+`
+		GROUP BY oi.product_id, CASE WHEN oi.product_id IS NULL THEN LOWER(TRIM(oi.product)) END
+		ORDER BY 4 DESC, 3 DESC, 2
+	`, rng.From, rng.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []ProductRating{}
+	for rows.Next() {
+		var p ProductRating
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Ratings, &p.Average); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"bakeflow/configs"
+)
+
+// Sales report intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week" // weeks start on Monday
+	IntervalMonth = "month"
+)
+
+// Top product orderings
+const (
+	TopByRevenue  = "revenue"
+	TopByQuantity = "quantity"
+)
+
+// SalesRange is the period a sales report covers: orders placed in [From, To), bucketed
+// in the Location's timezone
+type SalesRange struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+// salesRangeWhere limits orders (alias o) to the range's $1 and $2. created_at is a local
+// timestamp of the DB session, so the bounds are compared as instants.
+const salesRangeWhere = `o.created_at >= $1::timestamptz AND o.created_at < $2::timestamptz`
+
+// salesLocalTime is when an order was placed, in the report timezone ($3)
+const salesLocalTime = `(o.created_at::timestamptz AT TIME ZONE $3)`
+
+// SalesSummary totals the orders of a report period
+type SalesSummary struct {
+	Orders            int     `json:"orders"`
+	Items             int     `json:"items"`
+	Revenue           float64 `json:"revenue"` // order totals including delivery fees
+	DeliveryFees      float64 `json:"delivery_fees"`
+	AverageOrderValue float64 `json:"average_order_value"`
+}
+
+// SalesPeriod is the sales of one day, week or month
+type SalesPeriod struct {
+	Period string `json:"period"` // first day of the period, YYYY-MM-DD
+	SalesSummary
+}
+
+// DeliverySplit is the sales of one fulfilment type (pickup or delivery)
+type DeliverySplit struct {
+	DeliveryType string  `json:"delivery_type"`
+	Orders       int     `json:"orders"`
+	Revenue      float64 `json:"revenue"`
+	Share        float64 `json:"share"` // fraction of all orders
+}
+
+// TopProduct is one product's sales in a report period
+type TopProduct struct {
+	ProductID int     `json:"product_id,omitempty"` // 0 for items not linked to the catalog
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	Revenue   float64 `json:"revenue"` // item prices including options, without delivery fees
+	Orders    int     `json:"orders"`
+}
+
+// HourlySales is the sales placed in one hour of the day, summed over the period
+type HourlySales struct {
+	Hour    int     `json:"hour"` // 0-23 in the report timezone
+	Orders  int     `json:"orders"`
+	Revenue float64 `json:"revenue"`
+}
+
+func (s *SalesSummary) setAverage() {
+	if s.Orders > 0 {
+		s.AverageOrderValue = s.Revenue / float64(s.Orders)
+	}
+}
+
+// GetSalesSummary totals the orders placed in the range
+func GetSalesSummary(rng SalesRange) (*SalesSummary, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	var s SalesSummary
+	err := configs.DB.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(o.total_items), 0), COALESCE(SUM(o.total_amount), 0), COALESCE(SUM(o.delivery_fee), 0)
+		FROM orders o
+		WHERE `+salesRangeWhere, rng.From, rng.To).Scan(&s.Orders, &s.Items, &s.Revenue, &s.DeliveryFees)
+	if err != nil {
+		return nil, err
+	}
+	s.setAverage()
+	return &s, nil
+}
+
+// GetSalesByPeriod returns the sales of every day, week or month in the range, oldest first,
+// with zeros for periods without orders
+func GetSalesByPeriod(rng SalesRange, interval string) ([]SalesPeriod, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	last := rng.To.Add(-time.Nanosecond).In(rng.Location)
+	rows, err := configs.DB.Query(`
+		WITH sales AS (
+			SELECT date_trunc($4, `+salesLocalTime+`) AS period, COUNT(*) AS orders,
+			       SUM(o.total_items) AS items, SUM(o.total_amount) AS revenue, SUM(o.delivery_fee) AS fees
+			FROM orders o
+			WHERE `+salesRangeWhere+`
+			GROUP BY 1
+		)
+		SELECT to_char(p, 'YYYY-MM-DD'), COALESCE(s.orders, 0), COALESCE(s.items, 0),
+		       COALESCE(s.revenue, 0), COALESCE(s.fees, 0)
+		FROM generate_series(date_trunc($4, $5::timestamp), date_trunc($4, $6::timestamp), ('1 ' || $4)::interval) p
+		LEFT JOIN sales s ON s.period = p
+		ORDER BY p
+	`, rng.From, rng.To, rng.Location.String(), interval,
+		rng.From.In(rng.Location).Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []SalesPeriod{}
+	for rows.Next() {
+		var p SalesPeriod
+		if err := rows.Scan(&p.Period, &p.Orders, &p.Items, &p.Revenue, &p.DeliveryFees); err != nil {
+			return nil, err
+		}
+		p.setAverage()
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// GetDeliverySplit returns pickup and delivery sales in the range
+func GetDeliverySplit(rng SalesRange) ([]DeliverySplit, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := configs.DB.Query(`
+		SELECT COALESCE(o.delivery_type, 'pickup'), COUNT(*), COALESCE(SUM(o.total_amount), 0),
+		       COUNT(*)::float / SUM(COUNT(*)) OVER ()
+		FROM orders o
+		WHERE `+salesRangeWhere+`
+		GROUP BY 1
+		ORDER BY 1
+	`, rng.From, rng.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	split := []DeliverySplit{}
+	for rows.Next() {
+		var d DeliverySplit
+		if err := rows.Scan(&d.DeliveryType, &d.Orders, &d.Revenue, &d.Share); err != nil {
+			return nil, err
+		}
+		split = append(split, d)
+	}
+	return split, rows.Err()
+}
+
+// GetTopProducts returns the best-selling products in the range by revenue or quantity.
+// Items are grouped by catalog product, or by name for items not linked to one.
+func GetTopProducts(rng SalesRange, orderBy string, limit int) ([]TopProduct, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	order := "revenue DESC, quantity DESC"
+	if orderBy == TopByQuantity {
+		order = "quantity DESC, revenue DESC"
+	}
+	rows, err := configs.DB.Query(`
+		SELECT COALESCE(oi.product_id, 0), COALESCE(MAX(p.name), MIN(oi.product)),
+		       SUM(oi.quantity) AS quantity, SUM(oi.price * oi.quantity) AS revenue, COUNT(DISTINCT o.id)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		LEFT JOIN products p ON p.id = oi.product_id
+		WHERE `+salesRangeWhere+`
+		GROUP BY oi.product_id, CASE WHEN oi.product_id IS NULL THEN LOWER(TRIM(oi.product)) END
+		ORDER BY `+order+`, 2
+		LIMIT $3
+	`, rng.From, rng.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []TopProduct{}
+	for rows.Next() {
+		var t TopProduct
+		if err := rows.Scan(&t.ProductID, &t.Name, &t.Quantity, &t.Revenue, &t.Orders); err != nil {
+			return nil, err
+		}
+		products = append(products, t)
+	}
+	return products, rows.Err()
+}
+
+// GetSalesByHour returns the sales placed in each hour of the day (all 24) in the range
+func GetSalesByHour(rng SalesRange) ([]HourlySales, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := configs.DB.Query(`
+		WITH sales AS (
+			SELECT EXTRACT(HOUR FROM `+salesLocalTime+`)::int AS hour, COUNT(*) AS orders, SUM(o.total_amount) AS revenue
+			FROM orders o
+			WHERE `+salesRangeWhere+`
+			GROUP BY 1
+		)
+		SELECT h, COALESCE(s.orders, 0), COALESCE(s.revenue, 0)
+		FROM generate_series(0, 23) h
+		LEFT JOIN sales s ON s.hour = h
+		ORDER BY h
+	`, rng.From, rng.To, rng.Location.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []HourlySales{}
+	for rows.Next() {
+		var h HourlySales
+		if err := rows.Scan(&h.Hour, &h.Orders, &h.Revenue); err != nil {
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
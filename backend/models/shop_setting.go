@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+
+	"bakeflow/configs"
+)
+
+// Shop setting keys
+const (
+	SettingTimezone     = "timezone"      // IANA name, e.g. Asia/Yangon
+	SettingClosedUntil  = "closed_until"  // RFC3339 time; shop is closed until then
+	SettingClosedReason = "closed_reason" // optional note shown with a temporary closure
+)
+
+// GetShopSetting returns a setting value ("" if not set)
+func GetShopSetting(key string) (string, error) {
+	if configs.DB == nil {
+		return "", sql.ErrConnDone
+	}
+
+	var value string
+	err := configs.DB.QueryRow(`SELECT value FROM shop_settings WHERE key = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// GetShopSettings returns all settings as a map
+func GetShopSettings() (map[string]string, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`SELECT key, value FROM shop_settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+// SetShopSetting creates or updates a setting
+func SetShopSetting(key, value string) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	_, err := configs.DB.Exec(`
+		INSERT INTO shop_settings (key, value)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+	`, key, value)
+	return err
+}
+
+// DeleteShopSetting removes a setting
+func DeleteShopSetting(key string) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	_, err := configs.DB.Exec(`DELETE FROM shop_settings WHERE key = $1`, key)
+	return err
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Stock alert item kinds
+const (
+	StockAlertProduct    = "product"
+	StockAlertIngredient = "ingredient"
+)
+
+// StockAlertItem is a product or ingredient below its reorder threshold
+type StockAlertItem struct {
+	Kind      string  `json:"kind"`
+	ItemID    int     `json:"item_id"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Stock     float64 `json:"stock"`
+	Threshold float64 `json:"threshold"`
+}
+
+// Key identifies the item across kinds
+func (i StockAlertItem) Key() string {
+	return fmt.Sprintf("%s:%d", i.Kind, i.ItemID)
+}
+
+// GetLowStockProducts returns active products below their own threshold, or below
+// override when it is greater than zero
+func GetLowStockProducts(db *sql.DB, override int) ([]Product, error) {
+	rows, err := db.Query(`
+		SELECT id, name, category, stock, low_stock_threshold, status
+		FROM products
+		WHERE status = 'active' AND deleted_at IS NULL
+		  AND CASE WHEN $1 > 0 THEN stock < $1 ELSE low_stock_threshold > 0 AND stock < low_stock_threshold END
+		ORDER BY stock ASC
+	`, override)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category, &p.Stock, &p.LowStockThreshold, &p.Status); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// GetLowStockAlertItems returns every product and ingredient currently below its threshold
+func GetLowStockAlertItems(db *sql.DB) ([]StockAlertItem, error) {
+	products, err := GetLowStockProducts(db, 0)
+	if err != nil {
+		return nil, err
+	}
+	ingredients, err := GetLowStockIngredients(db)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []StockAlertItem{}
+	for _, p := range products {
+		items = append(items, StockAlertItem{
+			Kind: StockAlertProduct, ItemID: p.ID, Name: p.Name, Unit: "pcs",
+			Stock: float64(p.Stock), Threshold: float64(p.LowStockThreshold),
+		})
+	}
+	for _, i := range ingredients {
+		items = append(items, StockAlertItem{
+			Kind: StockAlertIngredient, ItemID: i.ID, Name: i.Name, Unit: i.Unit,
+			Stock: i.Stock, Threshold: i.LowStockThreshold,
+		})
+	}
+	return items, nil
+}
+
+// GetAlertedStockItems returns the keys (see StockAlertItem.Key) staff were already alerted about
+func GetAlertedStockItems(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT kind, item_id FROM stock_alerts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerted := map[string]bool{}
+	for rows.Next() {
+		var item StockAlertItem
+		if err := rows.Scan(&item.Kind, &item.ItemID); err != nil {
+			return nil, err
+		}
+		alerted[item.Key()] = true
+	}
+	return alerted, rows.Err()
+}
+
+// MarkStockAlerted records that staff were told about the item
+func MarkStockAlerted(db *sql.DB, item StockAlertItem) error {
+	_, err := db.Exec(`
+		INSERT INTO stock_alerts (kind, item_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, item_id) DO UPDATE SET stock = EXCLUDED.stock, alerted_at = CURRENT_TIMESTAMP
+	`, item.Kind, item.ItemID, item.Stock)
+	return err
+}
+
+// ClearRecoveredStockAlerts forgets alerts for items that are back above their threshold
+// (or were deleted), so the next drop triggers a new alert
+func ClearRecoveredStockAlerts(db *sql.DB) (int64, error) {
+	res, err := db.Exec(`
+		DELETE FROM stock_alerts sa
+		WHERE (sa.kind = 'product' AND NOT EXISTS (
+		          SELECT 1 FROM products p
+		          WHERE p.id = sa.item_id AND p.status = 'active' AND p.deleted_at IS NULL
+		            AND p.low_stock_threshold > 0 AND p.stock < p.low_stock_threshold))
+		   OR (sa.kind = 'ingredient' AND NOT EXISTS (
+		          SELECT 1 FROM ingredients i
+		          WHERE i.id = sa.item_id AND i.low_stock_threshold > 0 AND i.stock < i.low_stock_threshold))
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
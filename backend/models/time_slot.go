@@ -0,0 +1,209 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"bakeflow/configs"
+)
+
+// ErrSlotFull is returned when an order is placed into a slot that has reached capacity
+var ErrSlotFull = errors.New("time slot is fully booked")
+
+// Slot setting keys (stored in shop_settings)
+const (
+	SettingSlotLength      = "slot_length_minutes"
+	SettingSlotCapacity    = "slot_capacity"
+	SettingSlotDefaultLead = "slot_default_lead_minutes"
+	SettingSlotBookingDays = "slot_booking_days"
+	SettingSlotCutoff      = "slot_same_day_cutoff"
+)
+
+// SlotSettings configures the pickup/delivery time slots
+type SlotSettings struct {
+	LengthMinutes      int            `json:"length_minutes"`
+	Capacity           int            `json:"capacity"` // orders per slot, 0 = unlimited
+	DefaultLeadMinutes int            `json:"default_lead_minutes"`
+	BookingDays        int            `json:"booking_days"`    // how many days ahead can be booked
+	SameDayCutoff      string         `json:"same_day_cutoff"` // HH:MM after which same-day slots close, "" = none
+	CategoryLeadTimes  map[string]int `json:"category_lead_times"`
+}
+
+// DefaultSlotSettings are used for any setting that is not stored
+func DefaultSlotSettings() SlotSettings {
+	return SlotSettings{
+		LengthMinutes:      60,
+		Capacity:           10,
+		DefaultLeadMinutes: 30,
+		BookingDays:        7,
+		CategoryLeadTimes:  map[string]int{},
+	}
+}
+
+// Validate validates slot settings
+func (s *SlotSettings) Validate() error {
+	if s.LengthMinutes < 15 || s.LengthMinutes > 24*60 {
+		return errors.New("length_minutes must be between 15 and 1440")
+	}
+	if s.Capacity < 0 {
+		return errors.New("capacity cannot be negative")
+	}
+	if s.DefaultLeadMinutes < 0 {
+		return errors.New("default_lead_minutes cannot be negative")
+	}
+	if s.BookingDays < 1 || s.BookingDays > 90 {
+		return errors.New("booking_days must be between 1 and 90")
+	}
+	if s.SameDayCutoff != "" {
+		if _, err := time.Parse("15:04", s.SameDayCutoff); err != nil {
+			return errors.New("same_day_cutoff must be HH:MM")
+		}
+	}
+	for category, minutes := range s.CategoryLeadTimes {
+		if strings.TrimSpace(category) == "" {
+			return errors.New("category lead time needs a category")
+		}
+		if minutes < 0 {
+			return errors.New("category lead time cannot be negative")
+		}
+	}
+	return nil
+}
+
+// GetSlotSettings loads slot settings, using defaults for anything not configured
+func GetSlotSettings() (SlotSettings, error) {
+	s := DefaultSlotSettings()
+	settings, err := GetShopSettings()
+	if err != nil {
+		return s, err
+	}
+
+	intSetting := func(key string, target *int) {
+		if v, err := strconv.Atoi(settings[key]); err == nil {
+			*target = v
+		}
+	}
+	intSetting(SettingSlotLength, &s.LengthMinutes)
+	intSetting(SettingSlotCapacity, &s.Capacity)
+	intSetting(SettingSlotDefaultLead, &s.DefaultLeadMinutes)
+	intSetting(SettingSlotBookingDays, &s.BookingDays)
+	s.SameDayCutoff = settings[SettingSlotCutoff]
+
+	rows, err := configs.DB.Query(`SELECT category, lead_minutes FROM slot_lead_times ORDER BY category`)
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		var minutes int
+		if err := rows.Scan(&category, &minutes); err != nil {
+			return s, err
+		}
+		s.CategoryLeadTimes[category] = minutes
+	}
+	return s, rows.Err()
+}
+
+// SaveSlotSettings stores slot settings and replaces the category lead times
+func SaveSlotSettings(s SlotSettings) error {
+	if configs.DB == nil {
+		return sql.ErrConnDone
+	}
+
+	tx, err := configs.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	values := map[string]string{
+		SettingSlotLength:      strconv.Itoa(s.LengthMinutes),
+		SettingSlotCapacity:    strconv.Itoa(s.Capacity),
+		SettingSlotDefaultLead: strconv.Itoa(s.DefaultLeadMinutes),
+		SettingSlotBookingDays: strconv.Itoa(s.BookingDays),
+		SettingSlotCutoff:      s.SameDayCutoff,
+	}
+	for key, value := range values {
+		_, err := tx.Exec(`
+			INSERT INTO shop_settings (key, value)
+			VALUES ($1, $2)
+			ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+		`, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM slot_lead_times`); err != nil {
+		return err
+	}
+	for category, minutes := range s.CategoryLeadTimes {
+		_, err := tx.Exec(`INSERT INTO slot_lead_times (category, lead_minutes) VALUES ($1, $2)`, strings.TrimSpace(category), minutes)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CountSlotBookings returns the number of orders per slot start (unix seconds) in [from, to)
+func CountSlotBookings(from, to time.Time) (map[int64]int, error) {
+	if configs.DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := configs.DB.Query(`
+		SELECT slot_start, COUNT(*)
+		FROM orders
+		WHERE slot_start >= $1 AND slot_start < $2
+		GROUP BY slot_start
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var start time.Time
+		var n int
+		if err := rows.Scan(&start, &n); err != nil {
+			return nil, err
+		}
+		counts[start.Unix()] = n
+	}
+	return counts, rows.Err()
+}
+
+// reserveSlot checks slot capacity inside an order transaction.
+// The advisory lock serialises concurrent bookings of the same slot until commit.
+func reserveSlot(tx *sql.Tx, slotStart time.Time) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, slotStart.Unix()); err != nil {
+		return err
+	}
+
+	var capacityStr string
+	err := tx.QueryRow(`SELECT value FROM shop_settings WHERE key = $1`, SettingSlotCapacity).Scan(&capacityStr)
+	if err == sql.ErrNoRows {
+		capacityStr = strconv.Itoa(DefaultSlotSettings().Capacity)
+	} else if err != nil {
+		return err
+	}
+	capacity, err := strconv.Atoi(capacityStr)
+	if err != nil || capacity <= 0 {
+		return nil
+	}
+
+	var booked int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM orders WHERE slot_start = $1`, slotStart).Scan(&booked); err != nil {
+		return err
+	}
+	if booked >= capacity {
+		return ErrSlotFull
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package notify delivers staff notifications outside Messenger.
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EmailSender delivers a plain-text email
+type EmailSender interface {
+	Send(to []string, subject, body string) error
+}
+
+// SMTPSender sends through an SMTP server (PLAIN auth when a username is set)
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send implements EmailSender
+func (s *SMTPSender) Send(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(s.Host+":"+s.Port, auth, s.From, to, buildMessage(s.From, to, subject, body))
+}
+
+// FileSender writes each email as an .eml file into Dir; a stand-in for local development
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+// Send implements EmailSender
+func (s *FileSender) Send(to []string, subject, body string) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.eml", time.Now().Format("20060102-150405.000000000"))
+	return os.WriteFile(filepath.Join(s.Dir, name), buildMessage(s.From, to, subject, body), 0o644)
+}
+
+// NewEmailSenderFromEnv returns an SMTP sender when SMTP_HOST is set, otherwise a
+// FileSender writing to MAIL_OUTBOX_DIR (default "mail_outbox")
+func NewEmailSenderFromEnv() EmailSender {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "bakeflow@localhost"
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+	dir := os.Getenv("MAIL_OUTBOX_DIR")
+	if dir == "" {
+		dir = "mail_outbox"
+	}
+	return &FileSender{Dir: dir, From: from}
+}
+
+// buildMessage formats an RFC 822 message with UTF-8 text
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
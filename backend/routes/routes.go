@@ -3,6 +3,7 @@ package routes
 import (
 	"bakeflow/configs"
 	"bakeflow/controllers"
+	"bakeflow/storage"
 	"log"
 	"net/http"
 	"time"
@@ -29,7 +30,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 		
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -75,13 +77,49 @@ func SetupRoutes() http.Handler {
 	
 	// Chat Order API (from webview)
 	router.HandleFunc("/api/chat/orders", controllers.CreateChatOrder).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/chat/slots", controllers.GetChatSlots).Methods("GET", "OPTIONS")
 	
 	// Admin API Routes - Orders
 	router.HandleFunc("/api/admin/orders", controllers.AdminGetOrders).Methods("GET")
 	router.HandleFunc("/api/admin/orders/{id}/status", controllers.AdminUpdateOrderStatus).Methods("PUT", "OPTIONS")
 
+	// Admin API Routes - Customer notification templates
+	router.HandleFunc("/api/admin/notification-templates", controllers.AdminGetNotificationTemplates).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/notification-templates/{status}/{lang}", controllers.AdminUpdateNotificationTemplate).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/notification-templates/{status}/{lang}", controllers.AdminResetNotificationTemplate).Methods("DELETE", "OPTIONS")
+
+	// Admin API Routes - Business hours, holidays and temporary closures
+	router.HandleFunc("/api/admin/business-hours", controllers.AdminGetBusinessHours).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/business-hours", controllers.AdminUpdateBusinessHours).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/business-hours/closures", controllers.AdminCreateBusinessClosure).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/business-hours/closures/{id:[0-9]+}", controllers.AdminDeleteBusinessClosure).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/business-hours/close", controllers.AdminCloseShop).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/business-hours/close", controllers.AdminReopenShop).Methods("DELETE", "OPTIONS")
+
+	// Admin API Routes - Scheduled orders and time slot settings
+	router.HandleFunc("/api/admin/slots", controllers.AdminGetSlotOrders).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/slot-settings", controllers.AdminGetSlotSettings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/slot-settings", controllers.AdminUpdateSlotSettings).Methods("PUT", "OPTIONS")
+
+	// Admin API Routes - Kitchen production plan (json, csv or printable html)
+	router.HandleFunc("/api/admin/production-plan", controllers.AdminGetProductionPlan).Methods("GET", "OPTIONS")
+
+	// Admin API Routes - Sales reports (?from=&to=[&timezone=], shop timezone by default)
+	router.HandleFunc("/api/admin/reports/sales", controllers.AdminGetSalesReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/delivery-split", controllers.AdminGetDeliverySplitReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/top-products", controllers.AdminGetTopProductsReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/hourly", controllers.AdminGetHourlySalesReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/funnel", controllers.AdminGetFunnelReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/cart-reminders", controllers.AdminGetCartReminderReport).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/reports/ratings", controllers.AdminGetRatingsReport).Methods("GET", "OPTIONS")
+
+	// Admin API Routes - Customer ratings (low ratings are flagged for follow-up)
+	router.HandleFunc("/api/admin/ratings", controllers.AdminGetRatings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/ratings/{id:[0-9]+}/follow-up", controllers.AdminUpdateRatingFollowUp).Methods("PUT", "OPTIONS")
+
 	// Admin API Routes - Products
-	productController := &controllers.ProductController{DB: configs.DB}
+	imageStore := storage.NewImageStoreFromEnv()
+	productController := &controllers.ProductController{DB: configs.DB, Images: imageStore}
 	
 	// Product CRUD
 	router.HandleFunc("/api/products", productController.GetProducts).Methods("GET", "OPTIONS")
@@ -93,14 +131,72 @@ func SetupRoutes() http.Handler {
 	// Debug info for diagnosing product visibility
 	router.HandleFunc("/api/products/debug", productController.DebugProducts).Methods("GET", "OPTIONS")
 
+	// Bulk import/export (CSV or JSON); import supports ?dry_run=true
+	router.HandleFunc("/api/products/export", productController.ExportProducts).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/products/import", productController.ImportProducts).Methods("POST", "OPTIONS")
+
+	// Soft-deleted products (restorable until purged)
+	router.HandleFunc("/api/products/deleted", productController.GetDeletedProducts).Methods("GET", "OPTIONS")
+
+	// One status/price/stock/category/delete change for many products
+	router.HandleFunc("/api/products/bulk", productController.BulkProducts).Methods("POST", "OPTIONS")
+
+	// Daily views and purchases across the catalog (?from=&to=&sort=&limit=)
+	router.HandleFunc("/api/products/analytics", productController.GetProductsAnalytics).Methods("GET", "OPTIONS")
+
 	// Use regex to ensure {id} is numeric, preventing collisions with static paths like /seed
 	router.HandleFunc("/api/products/{id:[0-9]+}", productController.GetProduct).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/products/{id:[0-9]+}", productController.UpdateProduct).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}", productController.PatchProduct).Methods("PATCH", "OPTIONS")
 	router.HandleFunc("/api/products/{id:[0-9]+}", productController.DeleteProduct).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/restore", productController.RestoreProduct).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/revert/{logId:[0-9]+}", productController.RevertProduct).Methods("POST", "OPTIONS")
 	
+	// Product image upload; stored files are served under /uploads/
+	router.HandleFunc("/api/products/{id:[0-9]+}/image", productController.UploadProductImage).Methods("POST", "OPTIONS")
+	router.PathPrefix(imageStore.Prefix).Handler(imageStore.Handler()).Methods("GET")
+
 	// Product Status (numeric id)
 	router.HandleFunc("/api/products/{id:[0-9]+}/status", productController.UpdateProductStatus).Methods("PATCH", "OPTIONS")
 	
+	// Product Aliases (chat matching synonyms)
+	router.HandleFunc("/api/products/{id:[0-9]+}/aliases", productController.GetProductAliases).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/aliases", productController.CreateProductAlias).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/aliases/{aliasId:[0-9]+}", productController.DeleteProductAlias).Methods("DELETE", "OPTIONS")
+
+	// Product Recipes (ingredients per unit)
+	router.HandleFunc("/api/products/{id:[0-9]+}/recipe", productController.GetProductRecipe).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/recipe", productController.UpdateProductRecipe).Methods("PUT", "OPTIONS")
+
+	// Product availability windows (weekday/time of day, shop timezone)
+	router.HandleFunc("/api/products/{id:[0-9]+}/availability", productController.GetProductAvailability).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/availability", productController.UpdateProductAvailability).Methods("PUT", "OPTIONS")
+
+	// Daily views and purchases of one product (?from=&to=)
+	router.HandleFunc("/api/products/{id:[0-9]+}/analytics", productController.GetProductAnalytics).Methods("GET", "OPTIONS")
+
+	// Product Options (variants such as size/flavour, and inscriptions)
+	router.HandleFunc("/api/products/{id:[0-9]+}/options", productController.GetProductOptions).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups", productController.CreateProductOptionGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups/{groupId:[0-9]+}", productController.UpdateProductOptionGroup).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups/{groupId:[0-9]+}", productController.DeleteProductOptionGroup).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups/{groupId:[0-9]+}/options", productController.CreateProductOption).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups/{groupId:[0-9]+}/options/{optionId:[0-9]+}", productController.UpdateProductOption).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/products/{id:[0-9]+}/option-groups/{groupId:[0-9]+}/options/{optionId:[0-9]+}", productController.DeleteProductOption).Methods("DELETE", "OPTIONS")
+
+	// Categories
+	router.HandleFunc("/api/categories", productController.GetCategories).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/categories", productController.CreateCategory).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/categories/{id:[0-9]+}", productController.UpdateCategory).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/categories/{id:[0-9]+}", productController.DeleteCategory).Methods("DELETE", "OPTIONS")
+
+	// Ingredient inventory
+	router.HandleFunc("/api/ingredients", productController.GetIngredients).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/ingredients", productController.CreateIngredient).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/ingredients/{id:[0-9]+}", productController.UpdateIngredient).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/ingredients/{id:[0-9]+}", productController.DeleteIngredient).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/ingredients/{id:[0-9]+}/stock", productController.AdjustIngredientStock).Methods("POST", "OPTIONS")
+
 	// Product Logs
 	router.HandleFunc("/api/products/{id}/logs", productController.GetProductLogs).Methods("GET", "OPTIONS")
 	
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register decoder
+	"image/jpeg"
+	_ "image/png" // register decoder
+	"net/http"
+)
+
+// Product image limits. Messenger shows carousel images at 1.91:1, so uploads are
+// center-cropped to that ratio and scaled down to at most ProductImageWidth pixels wide.
+const (
+	MaxImageBytes      = 5 << 20 // 5 MB upload limit
+	MaxImagePixels     = 40_000_000
+	MinImageSide       = 200
+	ProductImageWidth  = 1200
+	ProductImageHeight = 628
+)
+
+// AllowedImageTypes are the accepted upload content types (sniffed from the data)
+var AllowedImageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Image validation errors
+var (
+	ErrImageTooLarge   = fmt.Errorf("image must be at most %d MB", MaxImageBytes>>20)
+	ErrImageType       = errors.New("image must be a JPEG, PNG or GIF")
+	ErrImageDimensions = fmt.Errorf("image must be at least %dx%d pixels", MinImageSide, MinImageSide)
+)
+
+// ProcessProductImage validates an uploaded image and returns it as a Messenger-friendly JPEG
+func ProcessProductImage(data []byte) ([]byte, error) {
+	if len(data) > MaxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+	allowed := false
+	contentType := http.DetectContentType(data)
+	for _, t := range AllowedImageTypes {
+		if contentType == t {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, ErrImageType
+	}
+
+	// Check the header before decoding so a tiny file cannot claim a huge canvas
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageType
+	}
+	if cfg.Width < MinImageSide || cfg.Height < MinImageSide {
+		return nil, ErrImageDimensions
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageType
+	}
+	out := resize(cropToRatio(src, ProductImageWidth, ProductImageHeight), ProductImageWidth)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cropToRatio returns the largest centered region of img with the aspect ratio w:h
+func cropToRatio(img image.Image, w, h int) image.Image {
+	b := img.Bounds()
+	cw, ch := b.Dx(), b.Dy()
+	if cw*h > ch*w {
+		cw = ch * w / h
+	} else {
+		ch = cw * h / w
+	}
+	x0 := b.Min.X + (b.Dx()-cw)/2
+	y0 := b.Min.Y + (b.Dy()-ch)/2
+
+	// Copy onto an opaque white canvas (JPEG has no alpha; transparent PNGs get a white background)
+	dst := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Over)
+	return dst
+}
+
+// resize scales img down to maxWidth (keeping its ratio) by averaging the source pixels
+// each destination pixel covers; smaller images are returned unchanged
+func resize(img image.Image, maxWidth int) image.Image {
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		src = image.NewRGBA(img.Bounds())
+		draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= maxWidth {
+		return src
+	}
+	dw := maxWidth
+	dh := sh * dw / sw
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(src.Bounds().Min.X+x0, src.Bounds().Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					b += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
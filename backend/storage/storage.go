@@ -0,0 +1,115 @@
+// Package storage keeps uploaded files (product images) and serves their public URLs.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ImageStore saves images and resolves the URLs it handed out
+type ImageStore interface {
+	// Save stores data under a new unique name with the given extension (".jpg") and returns its public URL
+	Save(data []byte, ext string) (string, error)
+	// Delete removes a file saved by this store; URLs it does not own are ignored
+	Delete(url string) error
+	// Owns reports whether the URL points to a file saved by this store
+	Owns(url string) bool
+}
+
+// LocalStore keeps files in Dir and serves them under BaseURL + Prefix
+type LocalStore struct {
+	Dir     string // directory on disk, e.g. "uploads"
+	BaseURL string // public origin of the backend, e.g. "https://bakeflow.example.com"
+	Prefix  string // URL path the files are served at, e.g. "/uploads/"
+}
+
+// NewImageStoreFromEnv returns a LocalStore writing to UPLOAD_DIR (default "uploads") whose URLs
+// start with PUBLIC_BASE_URL (default http://localhost:$PORT). Messenger fetches images itself,
+// so PUBLIC_BASE_URL must be reachable from the internet in production.
+func NewImageStoreFromEnv() *LocalStore {
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		base = "http://localhost:" + port
+	}
+	return &LocalStore{Dir: dir, BaseURL: strings.TrimRight(base, "/"), Prefix: "/uploads/"}
+}
+
+// Save implements ImageStore
+func (s *LocalStore) Save(data []byte, ext string) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(b) + ext
+	if err := os.WriteFile(filepath.Join(s.Dir, name), data, 0o644); err != nil {
+		return "", err
+	}
+	return s.BaseURL + s.Prefix + name, nil
+}
+
+// Delete implements ImageStore
+func (s *LocalStore) Delete(url string) error {
+	name, ok := s.fileName(url)
+	if !ok {
+		return nil
+	}
+	err := os.Remove(filepath.Join(s.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Owns implements ImageStore
+func (s *LocalStore) Owns(url string) bool {
+	_, ok := s.fileName(url)
+	return ok
+}
+
+// fileName extracts the stored file name from one of our URLs
+func (s *LocalStore) fileName(url string) (string, bool) {
+	name, ok := strings.CutPrefix(url, s.BaseURL+s.Prefix)
+	if !ok || name == "" || path.Base(name) != name || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	return name, true
+}
+
+// Handler serves the stored files (mount it at Prefix)
+func (s *LocalStore) Handler() http.Handler {
+	return http.StripPrefix(s.Prefix, http.FileServer(noDirListing{http.Dir(s.Dir)}))
+}
+
+// noDirListing hides directory indexes from the file server
+type noDirListing struct {
+	fs http.FileSystem
+}
+
+func (n noDirListing) Open(name string) (http.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
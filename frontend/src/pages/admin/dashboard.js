@@ -18,6 +18,8 @@ export default function AdminDashboard() {
   const [error, setError] = useState(null);
   const [sidebarOpen, setSidebarOpen] = useState(true);
   const [previewCard, setPreviewCard] = useState(null);
+  const [popularItems, setPopularItems] = useState([]);
+  const [dailySales, setDailySales] = useState([]);
   const { notifications, unreadCount, hasUnread, addNotifications, markAsRead, markAllRead, clearAll } = useNotifications();
   const seenOrdersRef = useRef(new Set());
   const initializedRef = useRef(false);
@@ -98,9 +100,33 @@ export default function AdminDashboard() {
     }
   };
 
+  // Charts come from the server-side sales reports (last 30 days by default)
+  const fetchReports = async () => {
+    try {
+      const [salesRes, topRes] = await Promise.all([
+        fetch('http://localhost:8080/api/admin/reports/sales?interval=day'),
+        fetch('http://localhost:8080/api/admin/reports/top-products?sort=quantity&limit=5'),
+      ]);
+      if (salesRes.ok) {
+        const sales = await salesRes.json();
+        setDailySales((sales.periods || []).slice(-7).map(p => ({ date: p.period, total: p.revenue })));
+      }
+      if (topRes.ok) {
+        const top = await topRes.json();
+        setPopularItems((top.products || []).map(p => ({ name: p.name, count: p.quantity })));
+      }
+    } catch (e) {
+      console.error('Failed to load sales reports:', e);
+    }
+  };
+
   useEffect(() => {
-    fetchOrders();
-    const interval = setInterval(fetchOrders, 10000);
+    const refresh = () => {
+      fetchOrders();
+      fetchReports();
+    };
+    refresh();
+    const interval = setInterval(refresh, 10000);
     return () => clearInterval(interval);
   }, []);
 
@@ -116,29 +142,6 @@ export default function AdminDashboard() {
     }; 
   }, [orders]);
 
-  const popularItems = useMemo(() => {
-    const counts = {};
-    orders.forEach(o => (o.items || []).forEach(it => {
-      counts[it.product] = (counts[it.product] || 0) + it.quantity;
-    }));
-    return Object.entries(counts)
-      .sort((a,b) => b[1]-a[1])
-      .slice(0,5)
-      .map(([name,count]) => ({ name, count }));
-  }, [orders]);
-
-  const dailySales = useMemo(() => {
-    const map = {};
-    orders.forEach(o => {
-      const d = new Date(o.created_at).toISOString().slice(0,10);
-      map[d] = (map[d] || 0) + (o.total_amount || 0);
-    });
-    return Object.entries(map)
-      .sort((a,b) => a[0].localeCompare(b[0]))
-      .slice(-7)
-      .map(([date,total]) => ({ date, total }));
-  }, [orders]);
-
   return (
     <>
       <Head>
@@ -353,6 +353,12 @@ export default function OrdersPage() {
                               <div key={idx} className="d-flex justify-content-between align-items-center py-3 border-bottom">
                                 <div className="flex-grow-1">
                                   <div className="fw-semibold">{item.product}</div>
+                                  {item.options && item.options.length > 0 && (
+                                    <small className="d-block text-muted">{item.options.map((o) => `${o.group}: ${o.option}`).join(' · ')}</small>
+                                  )}
+                                  {item.inscription && (
+                                    <small className="d-block fst-italic">✍️ "{item.inscription}"</small>
+                                  )}
                                   <small className="text-muted">{formatCurrency(item.price)} × {item.quantity}</small>
                                 </div>
                                 <div className="fw-bold">{formatCurrency(item.price * item.quantity)}</div>
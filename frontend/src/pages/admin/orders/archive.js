@@ -93,6 +93,12 @@ export default function OrdersArchivePage() {
                           <div key={idx} className="d-flex justify-content-between align-items-center py-2 border-bottom">
                             <div className="flex-grow-1">
                               <div className="fw-semibold">{item.product}</div>
+                              {item.options && item.options.length > 0 && (
+                                <small className="d-block text-muted">{item.options.map((o) => `${o.group}: ${o.option}`).join(' · ')}</small>
+                              )}
+                              {item.inscription && (
+                                <small className="d-block fst-italic">✍️ "{item.inscription}"</small>
+                              )}
                               <small className="text-muted">${item.price.toFixed(2)} × {item.quantity}</small>
                             </div>
                             <div className="fw-bold">${(item.price * item.quantity).toFixed(2)}</div>
@@ -10,6 +10,7 @@ import { formatCurrency } from '../../utils/formatCurrency';
 export default function ProductsPage() {
   const API_BASE = process.env.NEXT_PUBLIC_API_BASE || 'http://localhost:8080';
   const [products, setProducts] = useState([]);
+  const [categories, setCategories] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const [sidebarOpen, setSidebarOpen] = useState(true);
@@ -54,6 +55,13 @@ export default function ProductsPage() {
     fetchProducts();
   }, [filter]);
 
+  useEffect(() => {
+    fetch(`${API_BASE}/api/categories`)
+      .then((res) => res.json())
+      .then((data) => setCategories(data.categories || []))
+      .catch((e) => console.error('Failed to load categories', e));
+  }, []);
+
   const deleteProduct = async (id) => {
     if (!confirm('Are you sure you want to archive this product?')) return;
 
@@ -236,11 +244,9 @@ export default function ProductsPage() {
                           onChange={(e) => setFilter({...filter, category: e.target.value})}
                         >
                           <option value="">{t('allCategories')}</option>
-                          <option value="Cakes">Cakes</option>
-                          <option value="Cupcakes">Cupcakes</option>
-                          <option value="Muffins">Muffins</option>
-                          <option value="Tarts">Tarts</option>
-                          <option value="Cookies">Cookies</option>
+                          {categories.map((c) => (
+                            <option key={c.id} value={c.name}>{c.emoji} {c.name}</option>
+                          ))}
                         </select>
                       </div>
                       <div className="col-6 col-md-3">
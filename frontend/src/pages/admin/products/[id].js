@@ -14,17 +14,24 @@ export default function ProductFormPage() {
   const [sidebarOpen, setSidebarOpen] = useState(true);
   const [loading, setLoading] = useState(false);
   const [saving, setSaving] = useState(false);
+  const [uploading, setUploading] = useState(false);
+  const [etag, setEtag] = useState(null); // version of the loaded product, sent as If-Match
   const [notification, setNotification] = useState({ show: false, message: '', type: '' });
   const { notifications, unreadCount, hasUnread, markAsRead, markAllRead, clearAll } = useNotifications();
 
+  const [categories, setCategories] = useState([]);
   const [form, setForm] = useState({
     name: '',
+    sku: '',
     description: '',
-    category: 'Cakes',
+    category_id: '',
     price: '',
     stock: '',
     image_url: '',
-    status: 'draft'
+    status: 'draft',
+    name_my: '',
+    name_th: '',
+    tags: ''
   });
 
   const [errors, setErrors] = useState({});
@@ -35,20 +42,37 @@ export default function ProductFormPage() {
     }
   }, [id]);
 
+  useEffect(() => {
+    fetch('http://localhost:8080/api/categories')
+      .then((res) => res.json())
+      .then((data) => {
+        const list = data.categories || [];
+        setCategories(list);
+        // New products start in the first category
+        setForm((f) => (f.category_id || !list.length ? f : { ...f, category_id: list[0].id }));
+      })
+      .catch(() => showNotification('Failed to load categories', 'danger'));
+  }, []);
+
   const fetchProduct = async () => {
     setLoading(true);
     try {
       const res = await fetch(`http://localhost:8080/api/products/${id}`);
       const data = await res.json();
       if (data.product) {
+        setEtag(res.headers.get('ETag'));
         setForm({
           name: data.product.name || '',
+          sku: data.product.sku || '',
           description: data.product.description || '',
-          category: data.product.category || 'Cakes',
+          category_id: data.product.category_id || '',
           price: data.product.price || '',
           stock: data.product.stock || '',
           image_url: data.product.image_url || '',
-          status: data.product.status || 'draft'
+          status: data.product.status || 'draft',
+          name_my: (data.product.names || {}).my || '',
+          name_th: (data.product.names || {}).th || '',
+          tags: (data.product.tags || []).join(', ')
         });
       }
     } catch (e) {
@@ -63,7 +87,7 @@ export default function ProductFormPage() {
     
     if (!form.name.trim()) newErrors.name = 'Product name is required';
     if (form.name.length > 255) newErrors.name = 'Name must be less than 255 characters';
-    if (!form.category) newErrors.category = 'Category is required';
+    if (!form.category_id) newErrors.category = 'Category is required';
     if (!form.price || parseFloat(form.price) < 0) newErrors.price = 'Valid price is required';
     if (!form.stock || parseInt(form.stock) < 0) newErrors.stock = 'Valid stock quantity is required';
     
@@ -87,11 +111,18 @@ export default function ProductFormPage() {
       
       const method = isEdit ? 'PUT' : 'POST';
       
+      const headers = { 'Content-Type': 'application/json' };
+      if (isEdit && etag) headers['If-Match'] = etag;
+
+      const { name_my, name_th, tags, ...fields } = form;
       const res = await fetch(url, {
         method,
-        headers: { 'Content-Type': 'application/json' },
+        headers,
         body: JSON.stringify({
-          ...form,
+          ...fields,
+          names: { my: name_my, th: name_th },
+          tags: tags.split(',').map((t) => t.trim()).filter(Boolean),
+          category_id: parseInt(form.category_id),
           price: parseFloat(form.price),
           stock: parseInt(form.stock)
         })
@@ -105,6 +136,8 @@ export default function ProductFormPage() {
           'success'
         );
         setTimeout(() => router.push('/admin/products'), 1500);
+      } else if (res.status === 409) {
+        showNotification('Someone else changed this product. Reload the page to see their changes before saving.', 'warning');
       } else {
         showNotification(data.error || 'Failed to save product', 'danger');
       }
@@ -122,6 +155,30 @@ export default function ProductFormPage() {
     }, 100);
   };
 
+  const uploadImage = async (e) => {
+    const file = e.target.files[0];
+    e.target.value = '';
+    if (!file) return;
+    const body = new FormData();
+    body.append('image', file);
+    setUploading(true);
+    try {
+      const res = await fetch(`http://localhost:8080/api/products/${id}/image`, { method: 'POST', body });
+      const data = await res.json();
+      if (data.success) {
+        setForm((f) => ({ ...f, image_url: data.image_url }));
+        setEtag(res.headers.get('ETag'));
+        showNotification('Image uploaded', 'success');
+      } else {
+        showNotification(data.error || 'Failed to upload image', 'danger');
+      }
+    } catch (err) {
+      showNotification('Failed to upload image', 'danger');
+    } finally {
+      setUploading(false);
+    }
+  };
+
   const showNotification = (message, type) => {
     setNotification({ show: true, message, type });
     setTimeout(() => setNotification({ show: false, message: '', type: '' }), 5000);
@@ -198,6 +255,57 @@ export default function ProductFormPage() {
                             {errors.name && <div className="invalid-feedback">{errors.name}</div>}
                           </div>
 
+                          {/* SKU */}
+                          <div className="mb-3">
+                            <label className="form-label fw-semibold">SKU</label>
+                            <input
+                              type="text"
+                              className="form-control"
+                              value={form.sku}
+                              maxLength={64}
+                              onChange={(e) => setForm({...form, sku: e.target.value})}
+                              placeholder="Optional, e.g., CAKE-CHOC-8"
+                            />
+                            <small className="text-muted">Used to match rows in bulk imports</small>
+                          </div>
+
+                          {/* Localized names */}
+                          <div className="row">
+                            <div className="col-md-6 mb-3">
+                              <label className="form-label fw-semibold">Name (Burmese)</label>
+                              <input
+                                type="text"
+                                className="form-control"
+                                value={form.name_my}
+                                onChange={(e) => setForm({...form, name_my: e.target.value})}
+                                placeholder="Optional"
+                              />
+                            </div>
+                            <div className="col-md-6 mb-3">
+                              <label className="form-label fw-semibold">Name (Thai)</label>
+                              <input
+                                type="text"
+                                className="form-control"
+                                value={form.name_th}
+                                onChange={(e) => setForm({...form, name_th: e.target.value})}
+                                placeholder="Optional"
+                              />
+                            </div>
+                          </div>
+
+                          {/* Search tags */}
+                          <div className="mb-3">
+                            <label className="form-label fw-semibold">Search Tags</label>
+                            <input
+                              type="text"
+                              className="form-control"
+                              value={form.tags}
+                              onChange={(e) => setForm({...form, tags: e.target.value})}
+                              placeholder="e.g., chocolate, birthday, eggless"
+                            />
+                            <small className="text-muted">Comma separated; customers can find the product by these words</small>
+                          </div>
+
                           {/* Description */}
                           <div className="mb-3">
                             <label className="form-label fw-semibold">Description</label>
@@ -215,16 +323,12 @@ export default function ProductFormPage() {
                             <label className="form-label fw-semibold">Category *</label>
                             <select
                               className={`form-select ${errors.category ? 'is-invalid' : ''}`}
-                              value={form.category}
-                              onChange={(e) => setForm({...form, category: e.target.value})}
+                              value={form.category_id}
+                              onChange={(e) => setForm({...form, category_id: e.target.value})}
                             >
-                              <option value="Cakes">Cakes</option>
-                              <option value="Cupcakes">Cupcakes</option>
-                              <option value="Muffins">Muffins</option>
-                              <option value="Tarts">Tarts</option>
-                              <option value="Cookies">Cookies</option>
-                              <option value="Pastries">Pastries</option>
-                              <option value="Breads">Breads</option>
+                              {categories.map((c) => (
+                                <option key={c.id} value={c.id}>{c.emoji} {c.name}{c.active ? '' : ' (hidden)'}</option>
+                              ))}
                             </select>
                             {errors.category && <div className="invalid-feedback">{errors.category}</div>}
                           </div>
@@ -267,6 +371,18 @@ export default function ProductFormPage() {
                               onChange={(e) => setForm({...form, image_url: e.target.value})}
                               placeholder="https://example.com/image.jpg"
                             />
+                            {isEdit ? (
+                              <div className="mt-2">
+                                <label className="btn btn-outline-secondary btn-sm mb-0">
+                                  <i className="bi bi-upload me-1"></i>
+                                  {uploading ? 'Uploading...' : 'Upload image'}
+                                  <input type="file" accept="image/jpeg,image/png,image/gif" hidden disabled={uploading} onChange={uploadImage} />
+                                </label>
+                                <small className="text-muted ms-2">JPEG, PNG or GIF up to 5 MB, cropped to 1200×628</small>
+                              </div>
+                            ) : (
+                              <small className="text-muted">Save the product first to upload an image.</small>
+                            )}
                             {form.image_url && (
                               <div className="mt-2">
                                 <img 